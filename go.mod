@@ -3,12 +3,15 @@ module github.com/perdasilva/replee
 go 1.20
 
 require (
+	github.com/Masterminds/semver/v3 v3.2.1
 	github.com/dop251/goja v0.0.0-20230605162241-28ee0ee714f3
 	github.com/gdamore/tcell/v2 v2.6.0
 	github.com/go-air/gini v1.0.4
+	github.com/mattn/go-runewidth v0.0.14
 	github.com/rivo/tview v0.0.0-00010101000000-000000000000
 	github.com/stretchr/testify v1.8.3
 	github.com/wk8/go-ordered-map/v2 v2.1.8
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -22,14 +25,12 @@ require (
 	github.com/kr/pretty v0.3.1 // indirect
 	github.com/lucasb-eyer/go-colorful v1.2.0 // indirect
 	github.com/mailru/easyjson v0.7.7 // indirect
-	github.com/mattn/go-runewidth v0.0.14 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/rivo/uniseg v0.4.3 // indirect
 	github.com/rogpeppe/go-internal v1.10.0 // indirect
 	golang.org/x/sys v0.5.0 // indirect
 	golang.org/x/term v0.5.0 // indirect
 	golang.org/x/text v0.7.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
 
 replace github.com/rivo/tview => github.com/perdasilva/tview v0.0.0-20230617123416-770647c9dfd3
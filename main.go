@@ -2,63 +2,278 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"github.com/dop251/goja"
+	"github.com/gdamore/tcell/v2"
 	"github.com/perdasilva/replee/pkg/replee/repl"
 	"github.com/perdasilva/replee/pkg/replee/terminal"
 	"github.com/rivo/tview"
 	"strings"
 )
 
+// tab keys are pressed with Alt: t opens a tab, w closes it, r renames it, n and p switch to the next and previous
+// tabs and 1 to 9 to the tab with that number
+const (
+	keyNewTab      = 't'
+	keyCloseTab    = 'w'
+	keyRenameTab   = 'r'
+	keyNextTab     = 'n'
+	keyPreviousTab = 'p'
+)
+
 type ReplUI struct {
-	app  *tview.Application
-	main *tview.Pages
-	vm   *goja.Runtime
+	app        *tview.Application
+	ctx        context.Context
+	root       *tview.Flex
+	top        *tview.Pages
+	tabBar     *tview.TextView
+	rename     *tview.InputField
+	main       *tview.Pages
+	graph      *terminal.GraphView
+	help       *terminal.HelpView
+	history    *terminal.SharedHistory
+	workspaces []*workspace
+	current    int
+	nextTab    int
 }
 
-func NewReplUI(app *tview.Application, vm *goja.Runtime) *ReplUI {
+func NewReplUI(ctx context.Context, app *tview.Application) (*ReplUI, error) {
 	ui := &ReplUI{
-		app:  app,
-		main: tview.NewPages(),
-		vm:   vm,
+		app:     app,
+		ctx:     ctx,
+		root:    tview.NewFlex().SetDirection(tview.FlexRow),
+		top:     tview.NewPages(),
+		tabBar:  tview.NewTextView().SetDynamicColors(true).SetWrap(false),
+		rename:  tview.NewInputField().SetLabel("rename tab: "),
+		main:    tview.NewPages(),
+		history: terminal.NewSharedHistory(),
 	}
-	ui.main.AddPage("replee", terminal.NewRepleeTerminal(app, ui.execute), true, true)
-	return ui
+	ui.graph = terminal.NewGraphView().SetDoneFunc(ui.showTerminal)
+	ui.help = terminal.NewHelpView().SetDoneFunc(ui.showTerminal)
+	ui.main.AddPage("graph", ui.graph, true, false)
+	ui.main.AddPage("help", ui.help, true, false)
+	ui.main.SetInputCapture(ui.handleKey)
+	app.SetInputCapture(ui.handleInterrupt)
+	ui.rename.SetDoneFunc(ui.renameTab)
+	ui.top.AddPage("tabs", ui.tabBar, true, true)
+	ui.top.AddPage("rename", ui.rename, true, false)
+	ui.root.AddItem(ui.top, 1, 0, false)
+	ui.root.AddItem(ui.main, 0, 1, true)
+	if err := ui.newTab(); err != nil {
+		return nil, err
+	}
+	return ui, nil
 }
 
-func (ui *ReplUI) execute(command string) *terminal.Output {
-	response := &terminal.Output{
-		IsErr:       false,
-		IsSyntaxErr: false,
-		Output:      "",
+// handleInterrupt interrupts the command running in the current tab on Ctrl-C, which otherwise quits
+func (ui *ReplUI) handleInterrupt(event *tcell.EventKey) *tcell.EventKey {
+	if event.Key() == tcell.KeyCtrlC && ui.workspace().terminal.Running() {
+		ui.workspace().interrupt()
+		return nil
 	}
+	return event
+}
 
-	value, err := ui.vm.RunString(command)
-	if err != nil {
-		response.IsErr = true
-		response.Output = err.Error()
-		if exception, ok := err.(*goja.Exception); ok && strings.Index(exception.Value().String(), "Unexpected end of input") != -1 {
-			response.IsSyntaxErr = true
+func (ui *ReplUI) handleKey(event *tcell.EventKey) *tcell.EventKey {
+	name, _ := ui.main.GetFrontPage()
+	switch {
+	case event.Key() == tcell.KeyF1 && name == "help", event.Key() == tcell.KeyF2 && name == "graph":
+		ui.showTerminal()
+	case event.Key() == tcell.KeyF1:
+		ui.showHelp()
+	case event.Key() == tcell.KeyF2:
+		ui.showGraph()
+	case event.Key() == tcell.KeyRune && event.Modifiers() == tcell.ModAlt:
+		return ui.handleTabKey(event)
+	default:
+		return event
+	}
+	return nil
+}
+
+func (ui *ReplUI) handleTabKey(event *tcell.EventKey) *tcell.EventKey {
+	switch r := event.Rune(); {
+	case r == keyNewTab:
+		if err := ui.newTab(); err != nil {
+			ui.showTabError(err)
 		}
-	} else {
-		if !goja.IsNull(value) && !goja.IsUndefined(value) {
-			response.Output = value.String()
+	case r == keyCloseTab:
+		ui.closeTab()
+	case r == keyRenameTab:
+		ui.rename.SetText(ui.workspace().name)
+		ui.top.SwitchToPage("rename")
+		ui.app.SetFocus(ui.rename)
+	case r == keyNextTab:
+		ui.switchTab((ui.current + 1) % len(ui.workspaces))
+	case r == keyPreviousTab:
+		ui.switchTab((ui.current + len(ui.workspaces) - 1) % len(ui.workspaces))
+	case r >= '1' && r <= '9':
+		if i := int(r - '1'); i < len(ui.workspaces) {
+			ui.switchTab(i)
 		}
+	default:
+		return event
 	}
-	return response
+	return nil
 }
 
-func main() {
-	ctx := context.Background()
+// workspace returns the workspace of the current tab
+func (ui *ReplUI) workspace() *workspace {
+	return ui.workspaces[ui.current]
+}
+
+// newTab opens a tab with a new runtime and switches to it
+func (ui *ReplUI) newTab() error {
+	ui.nextTab++
 	vm := goja.New()
 	vm.SetFieldNameMapper(goja.TagFieldNameMapper("json", true))
+	w := &workspace{
+		page:         fmt.Sprintf("tab-%d", ui.nextTab),
+		name:         fmt.Sprintf("tab %d", ui.nextTab),
+		app:          ui.app,
+		vm:           vm,
+		declarations: repl.NewDeclarations(),
+		commands:     repl.NewCommandContexts(ui.ctx),
+		loop:         repl.NewEventLoop(vm),
+		docs:         repl.NewHelp(),
+		renderers:    repl.NewRenderers(),
+	}
+	w.terminal = terminal.NewRepleeTerminal(ui.app, ui.history, w.execute).SetCompleter(func(text string) (int, []string) {
+		// completing looks names up in the runtime, there's nothing to complete while something else uses it
+		if !w.lock.TryLock() {
+			return len(text), nil
+		}
+		defer w.lock.Unlock()
+		return repl.Complete(vm, w.declarations, text)
+	})
+	if err := w.bind(ui); err != nil {
+		return err
+	}
+	if err := repl.BootstrapRepleeVM(ui.ctx, vm, repl.OnSolve(w.onSolve), repl.WithHelp(w.docs),
+		repl.WithRenderers(w.renderers), repl.WithConsole(w.print),
+		repl.WithEventLoop(w.loop), repl.WithCommandContexts(w.commands.Context)); err != nil {
+		return err
+	}
+	ui.workspaces = append(ui.workspaces, w)
+	ui.main.AddPage(w.page, w.terminal, true, false)
+	ui.switchTab(len(ui.workspaces) - 1)
+	return nil
+}
 
-	if err := repl.BootstrapRepleeVM(ctx, vm); err != nil {
-		panic(err)
+// closeTab closes the current tab, unless it's the last one
+func (ui *ReplUI) closeTab() {
+	if len(ui.workspaces) == 1 {
+		return
+	}
+	if ui.workspace().terminal.Running() {
+		ui.workspace().interrupt()
+	}
+	ui.main.RemovePage(ui.workspace().page)
+	ui.workspaces = append(ui.workspaces[:ui.current], ui.workspaces[ui.current+1:]...)
+	if ui.current == len(ui.workspaces) {
+		ui.current--
 	}
+	ui.switchTab(ui.current)
+}
+
+func (ui *ReplUI) switchTab(i int) {
+	ui.current = i
+	ui.showTabs()
+	ui.showTerminal()
+}
+
+// renameTab renames the current tab to the text of the rename field when it's done with Enter
+func (ui *ReplUI) renameTab(key tcell.Key) {
+	if name := strings.TrimSpace(ui.rename.GetText()); key == tcell.KeyEnter && name != "" {
+		ui.workspace().name = name
+	}
+	ui.showTabs()
+	ui.showTerminal()
+}
+
+// findWorkspace returns the workspace of the tab with the given name, or with the given number from 1. Commands
+// call it off the goroutine of the application, which it looks the tabs up on.
+func (ui *ReplUI) findWorkspace(tab goja.Value) (*workspace, error) {
+	var found *workspace
+	ui.app.QueueUpdate(func() {
+		switch t := tab.Export().(type) {
+		case int64:
+			if t >= 1 && int(t) <= len(ui.workspaces) {
+				found = ui.workspaces[t-1]
+			}
+		case string:
+			for _, w := range ui.workspaces {
+				if w.name == t && found == nil {
+					found = w
+				}
+			}
+		}
+	})
+	if found == nil {
+		return nil, fmt.Errorf("no tab %s", tab.String())
+	}
+	return found, nil
+}
+
+func (ui *ReplUI) showTabs() {
+	sb := &strings.Builder{}
+	for i, w := range ui.workspaces {
+		if i == ui.current {
+			sb.WriteString("[black:yellow]")
+		}
+		sb.WriteString(fmt.Sprintf(" %d %s ", i+1, tview.Escape(w.name)))
+		if i == ui.current {
+			sb.WriteString("[-:-]")
+		}
+		sb.WriteString(" ")
+	}
+	ui.tabBar.SetText(sb.String())
+	ui.top.SwitchToPage("tabs")
+}
+
+func (ui *ReplUI) showTabError(err error) {
+	ui.tabBar.SetText(fmt.Sprintf("[red]%s", tview.Escape(err.Error())))
+}
+
+func (ui *ReplUI) showGraph() {
+	ui.main.SwitchToPage("graph")
+	ui.app.SetFocus(ui.graph)
+}
+
+// showHelp shows the help browser, with a topic for the overview and for every documented binding of the current tab
+func (ui *ReplUI) showHelp() {
+	w := ui.workspace()
+	// the help looks bindings up in the runtime, which a command, or a copy from another tab, may be using
+	if !w.lock.TryLock() {
+		ui.showTabError(fmt.Errorf("%s is running a command, Ctrl-C interrupts it", w.name))
+		return
+	}
+	defer w.lock.Unlock()
+	docs := w.docs
+	topics := []terminal.HelpTopic{{Name: "overview", Text: docs.Overview()}}
+	for _, doc := range docs.Docs() {
+		topics = append(topics, terminal.HelpTopic{Name: doc.Name, Text: docs.Lookup(doc.Name)})
+	}
+	ui.help.SetTopics(topics)
+	ui.main.SwitchToPage("help")
+	ui.app.SetFocus(ui.help)
+}
+
+func (ui *ReplUI) showTerminal() {
+	w := ui.workspace()
+	ui.main.SwitchToPage(w.page)
+	ui.app.SetFocus(w.terminal)
+}
+
+func main() {
+	ctx := context.Background()
 	app := tview.NewApplication().SetScreen(terminal.NewScreen())
-	ui := NewReplUI(app, vm)
+	ui, err := NewReplUI(ctx, app)
+	if err != nil {
+		panic(err)
+	}
 
-	if err := app.SetRoot(ui.main, true).EnableMouse(true).SetFocus(ui.main).Run(); err != nil {
+	if err := app.SetRoot(ui.root, true).EnableMouse(true).SetFocus(ui.main).Run(); err != nil {
 		panic(err)
 	}
 }
@@ -137,15 +137,20 @@ type MutableConstraint interface {
 }
 
 // AppliedConstraint values compose a single Constraint with the
-// Variable it applies to.
+// Variable it applies to. Provenance is set when the problem the
+// constraint came from recorded which variable source added it.
 type AppliedConstraint struct {
   Variable   Variable
   Constraint Constraint
+  Provenance *Provenance `json:",omitempty"`
 }
 
 // String implements fmt.Stringer and returns a human-readable message
 // representing the receiver.
 func (a AppliedConstraint) String() string {
+  if a.Provenance != nil {
+    return fmt.Sprintf("%s (%s)", a.Constraint.String(a.Variable.VariableID()), a.Provenance)
+  }
   return a.Constraint.String(a.Variable.VariableID())
 }
 
@@ -0,0 +1,354 @@
+// Package catalog reads operator file-based catalogs and turns them into
+// resolution problems over their bundles.
+package catalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/perdasilva/replee/pkg/deppy/semver"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const (
+	SchemaPackage = "olm.package"
+	SchemaChannel = "olm.channel"
+	SchemaBundle  = "olm.bundle"
+
+	PropertyPackage         = "olm.package"
+	PropertyGVK             = "olm.gvk"
+	PropertyGVKRequired     = "olm.gvk.required"
+	PropertyPackageRequired = "olm.package.required"
+)
+
+type Catalog struct {
+	Packages map[string]*Package
+}
+
+type Package struct {
+	Name           string
+	DefaultChannel string
+	Channels       map[string]*Channel
+	Bundles        map[string]*Bundle
+}
+
+type Channel struct {
+	Name    string         `json:"name"`
+	Package string         `json:"package"`
+	Entries []ChannelEntry `json:"entries"`
+}
+
+type ChannelEntry struct {
+	Name     string   `json:"name"`
+	Replaces string   `json:"replaces,omitempty"`
+	Skips    []string `json:"skips,omitempty"`
+}
+
+type Bundle struct {
+	Name             string
+	Package          string
+	Image            string
+	Version          *semver.Version
+	ProvidedAPIs     []GVK
+	RequiredAPIs     []GVK
+	RequiredPackages []semver.Requirement
+}
+
+type GVK struct {
+	Group   string `json:"group"`
+	Version string `json:"version"`
+	Kind    string `json:"kind"`
+}
+
+func (g GVK) String() string {
+	return fmt.Sprintf("%s/%s/%s", g.Group, g.Version, g.Kind)
+}
+
+type meta struct {
+	Schema         string     `json:"schema"`
+	Name           string     `json:"name"`
+	Package        string     `json:"package"`
+	DefaultChannel string     `json:"defaultChannel"`
+	Image          string     `json:"image"`
+	Properties     []property `json:"properties"`
+	Entries        []ChannelEntry
+}
+
+type property struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+// Load reads a catalog from a file, or from all the .json, .yaml and .yml files under a directory
+func Load(path string) (*Catalog, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	if info.IsDir() {
+		err := filepath.WalkDir(path, func(p string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			switch filepath.Ext(p) {
+			case ".json", ".yaml", ".yml":
+				if !d.IsDir() {
+					files = append(files, p)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		files = append(files, path)
+	}
+
+	var metas []meta
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		m, err := parseMetas(data)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %s: %w", file, err)
+		}
+		metas = append(metas, m...)
+	}
+	return build(metas)
+}
+
+// Parse reads a catalog from a stream of JSON objects or YAML documents
+func Parse(data []byte) (*Catalog, error) {
+	metas, err := parseMetas(data)
+	if err != nil {
+		return nil, err
+	}
+	return build(metas)
+}
+
+func parseMetas(data []byte) ([]meta, error) {
+	var metas []meta
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		decoder := json.NewDecoder(bytes.NewReader(trimmed))
+		for {
+			m := meta{}
+			if err := decoder.Decode(&m); errors.Is(err, io.EOF) {
+				return metas, nil
+			} else if err != nil {
+				return nil, err
+			}
+			metas = append(metas, m)
+		}
+	}
+
+	// YAML documents go through JSON so that json.RawMessage property values work
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var raw interface{}
+		if err := decoder.Decode(&raw); errors.Is(err, io.EOF) {
+			return metas, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if raw == nil {
+			continue
+		}
+		jsonBytes, err := json.Marshal(raw)
+		if err != nil {
+			return nil, err
+		}
+		m := meta{}
+		if err := json.Unmarshal(jsonBytes, &m); err != nil {
+			return nil, err
+		}
+		metas = append(metas, m)
+	}
+}
+
+func build(metas []meta) (*Catalog, error) {
+	c := &Catalog{Packages: map[string]*Package{}}
+	pkg := func(name string) *Package {
+		if _, ok := c.Packages[name]; !ok {
+			c.Packages[name] = &Package{
+				Name:     name,
+				Channels: map[string]*Channel{},
+				Bundles:  map[string]*Bundle{},
+			}
+		}
+		return c.Packages[name]
+	}
+
+	for _, m := range metas {
+		switch m.Schema {
+		case SchemaPackage:
+			pkg(m.Name).DefaultChannel = m.DefaultChannel
+		case SchemaChannel:
+			if m.Package == "" {
+				return nil, fmt.Errorf("channel %s does not name its package", m.Name)
+			}
+			pkg(m.Package).Channels[m.Name] = &Channel{
+				Name:    m.Name,
+				Package: m.Package,
+				Entries: m.Entries,
+			}
+		case SchemaBundle:
+			b, err := parseBundle(m)
+			if err != nil {
+				return nil, err
+			}
+			pkg(b.Package).Bundles[b.Name] = b
+		}
+	}
+
+	for _, p := range c.Packages {
+		for _, ch := range p.Channels {
+			for _, entry := range ch.Entries {
+				if _, ok := p.Bundles[entry.Name]; !ok {
+					return nil, fmt.Errorf("channel %s of package %s refers to unknown bundle %s", ch.Name, p.Name, entry.Name)
+				}
+			}
+		}
+	}
+	return c, nil
+}
+
+func parseBundle(m meta) (*Bundle, error) {
+	b := &Bundle{
+		Name:    m.Name,
+		Package: m.Package,
+		Image:   m.Image,
+	}
+	for _, p := range m.Properties {
+		switch p.Type {
+		case PropertyPackage:
+			value := struct {
+				PackageName string `json:"packageName"`
+				Version     string `json:"version"`
+			}{}
+			if err := json.Unmarshal(p.Value, &value); err != nil {
+				return nil, fmt.Errorf("bundle %s: %w", m.Name, err)
+			}
+			if b.Package == "" {
+				b.Package = value.PackageName
+			}
+			version, err := semver.ParseVersion(value.Version)
+			if err != nil {
+				return nil, fmt.Errorf("bundle %s has invalid version %q: %w", m.Name, value.Version, err)
+			}
+			b.Version = version
+		case PropertyGVK, PropertyGVKRequired:
+			gvk := GVK{}
+			if err := json.Unmarshal(p.Value, &gvk); err != nil {
+				return nil, fmt.Errorf("bundle %s: %w", m.Name, err)
+			}
+			if p.Type == PropertyGVK {
+				b.ProvidedAPIs = append(b.ProvidedAPIs, gvk)
+			} else {
+				b.RequiredAPIs = append(b.RequiredAPIs, gvk)
+			}
+		case PropertyPackageRequired:
+			requirement := semver.Requirement{}
+			if err := json.Unmarshal(p.Value, &requirement); err != nil {
+				return nil, fmt.Errorf("bundle %s: %w", m.Name, err)
+			}
+			if _, err := requirement.Range(); err != nil {
+				return nil, fmt.Errorf("bundle %s: %w", m.Name, err)
+			}
+			b.RequiredPackages = append(b.RequiredPackages, requirement)
+		}
+	}
+	if b.Package == "" {
+		return nil, fmt.Errorf("bundle %s does not name its package", m.Name)
+	}
+	if b.Version == nil {
+		return nil, fmt.Errorf("bundle %s does not have a version", m.Name)
+	}
+	return b, nil
+}
+
+// ChannelNames returns the package's channel names, default channel first and the others alphabetically
+func (p *Package) ChannelNames() []string {
+	var names []string
+	for name := range p.Channels {
+		if name != p.DefaultChannel {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if _, ok := p.Channels[p.DefaultChannel]; ok {
+		names = append([]string{p.DefaultChannel}, names...)
+	}
+	return names
+}
+
+// PreferredBundles returns the package's bundles in order of preference: channel by channel, following
+// ChannelNames, newest version first within a channel. Bundles that aren't in any channel come last.
+func (p *Package) PreferredBundles() []*Bundle {
+	var out []*Bundle
+	seen := map[string]struct{}{}
+	add := func(bundles []*Bundle) {
+		sortNewestFirst(bundles)
+		for _, b := range bundles {
+			if _, ok := seen[b.Name]; !ok {
+				seen[b.Name] = struct{}{}
+				out = append(out, b)
+			}
+		}
+	}
+	for _, name := range p.ChannelNames() {
+		var bundles []*Bundle
+		for _, entry := range p.Channels[name].Entries {
+			bundles = append(bundles, p.Bundles[entry.Name])
+		}
+		add(bundles)
+	}
+	var rest []*Bundle
+	for _, b := range p.Bundles {
+		rest = append(rest, b)
+	}
+	add(rest)
+	return out
+}
+
+// BundleChannels returns the names of the channels the bundle is in, in ChannelNames order
+func (p *Package) BundleChannels(bundleName string) []string {
+	var out []string
+	for _, name := range p.ChannelNames() {
+		for _, entry := range p.Channels[name].Entries {
+			if entry.Name == bundleName {
+				out = append(out, name)
+				break
+			}
+		}
+	}
+	return out
+}
+
+func sortNewestFirst(bundles []*Bundle) {
+	sort.SliceStable(bundles, func(i, j int) bool {
+		if c := bundles[i].Version.Compare(bundles[j].Version); c != 0 {
+			return c > 0
+		}
+		return bundles[i].Name < bundles[j].Name
+	})
+}
+
+// PackageNames returns the names of the catalog's packages in alphabetical order
+func (c *Catalog) PackageNames() []string {
+	var names []string
+	for name := range c.Packages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
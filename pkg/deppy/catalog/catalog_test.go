@@ -0,0 +1,119 @@
+package catalog_test
+
+import (
+	"context"
+	"github.com/perdasilva/replee/pkg/deppy"
+	. "github.com/perdasilva/replee/pkg/deppy/catalog"
+	"github.com/perdasilva/replee/pkg/deppy/constraints"
+	"github.com/perdasilva/replee/pkg/deppy/resolution"
+	"github.com/perdasilva/replee/pkg/deppy/resolver"
+	"github.com/perdasilva/replee/pkg/deppy/semver"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const etcd = `
+{"schema": "olm.package", "name": "etcd", "defaultChannel": "stable"}
+{"schema": "olm.channel", "name": "stable", "package": "etcd", "entries": [{"name": "etcd.v0.9.0"}, {"name": "etcd.v0.9.2", "replaces": "etcd.v0.9.0"}]}
+{"schema": "olm.channel", "name": "alpha", "package": "etcd", "entries": [{"name": "etcd.v1.0.0"}]}
+{"schema": "olm.bundle", "name": "etcd.v0.9.0", "package": "etcd", "properties": [
+  {"type": "olm.package", "value": {"packageName": "etcd", "version": "0.9.0"}},
+  {"type": "olm.gvk", "value": {"group": "etcd.database.coreos.com", "version": "v1beta2", "kind": "EtcdCluster"}}
+]}
+{"schema": "olm.bundle", "name": "etcd.v0.9.2", "package": "etcd", "properties": [
+  {"type": "olm.package", "value": {"packageName": "etcd", "version": "0.9.2"}},
+  {"type": "olm.gvk", "value": {"group": "etcd.database.coreos.com", "version": "v1beta2", "kind": "EtcdCluster"}}
+]}
+{"schema": "olm.bundle", "name": "etcd.v1.0.0", "package": "etcd", "properties": [
+  {"type": "olm.package", "value": {"packageName": "etcd", "version": "1.0.0"}},
+  {"type": "olm.gvk", "value": {"group": "etcd.database.coreos.com", "version": "v1beta2", "kind": "EtcdCluster"}}
+]}
+`
+
+const prometheus = `
+schema: olm.package
+name: prometheus
+defaultChannel: beta
+---
+schema: olm.channel
+name: beta
+package: prometheus
+entries:
+  - name: prometheus.v0.22.2
+---
+schema: olm.bundle
+name: prometheus.v0.22.2
+package: prometheus
+properties:
+  - type: olm.package
+    value:
+      packageName: prometheus
+      version: 0.22.2
+  - type: olm.gvk.required
+    value:
+      group: etcd.database.coreos.com
+      version: v1beta2
+      kind: EtcdCluster
+  - type: olm.package.required
+    value:
+      packageName: etcd
+      versionRange: ">=0.9.0"
+`
+
+func writeCatalog(t *testing.T) string {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "etcd.json"), []byte(etcd), 0o644))
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "prometheus"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "prometheus", "catalog.yaml"), []byte(prometheus), 0o644))
+	return dir
+}
+
+func TestLoad(t *testing.T) {
+	catalog, err := Load(writeCatalog(t))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"etcd", "prometheus"}, catalog.PackageNames())
+
+	pkg := catalog.Packages["etcd"]
+	assert.Equal(t, []string{"stable", "alpha"}, pkg.ChannelNames())
+	var names []string
+	for _, b := range pkg.PreferredBundles() {
+		names = append(names, b.Name)
+	}
+	assert.Equal(t, []string{"etcd.v0.9.2", "etcd.v0.9.0", "etcd.v1.0.0"}, names)
+
+	b := catalog.Packages["prometheus"].Bundles["prometheus.v0.22.2"]
+	assert.Equal(t, "0.22.2", b.Version.String())
+	assert.Equal(t, []GVK{{Group: "etcd.database.coreos.com", Version: "v1beta2", Kind: "EtcdCluster"}}, b.RequiredAPIs)
+	assert.Equal(t, []semver.Requirement{{PackageName: "etcd", VersionRange: ">=0.9.0"}}, b.RequiredPackages)
+}
+
+func TestParse_UnknownBundle(t *testing.T) {
+	_, err := Parse([]byte(`{"schema": "olm.channel", "name": "stable", "package": "etcd", "entries": [{"name": "etcd.v0.9.0"}]}`))
+	assert.EqualError(t, err, "channel stable of package etcd refers to unknown bundle etcd.v0.9.0")
+}
+
+func TestCatalogVariableSource(t *testing.T) {
+	ctx := context.Background()
+	requirement, err := semver.ParseRequirement("prometheus >=0.22.0")
+	assert.NoError(t, err)
+	source, err := NewCatalogVariableSourceFromPath(writeCatalog(t), requirement)
+	assert.NoError(t, err)
+
+	problem, err := resolution.NewResolutionProblemBuilder("test").WithVariableSources(source).Build(ctx)
+	assert.NoError(t, err)
+
+	v, err := problem.(deppy.MutableResolutionProblem).GetMutableVariable(BundleVariableID("prometheus", "prometheus.v0.22.2"), VariableKindBundle)
+	assert.NoError(t, err)
+	c, _ := v.GetConstraint("requires-package/etcd")
+	assert.Equal(t, []deppy.Identifier{"etcd/etcd.v0.9.2", "etcd/etcd.v0.9.0", "etcd/etcd.v1.0.0"}, c.(*constraints.DependencyConstraint).Elements())
+
+	solution, err := resolver.NewDeppyResolver().Solve(ctx, problem)
+	assert.NoError(t, err)
+	assert.Empty(t, solution.NotSatisfiable())
+	assert.True(t, solution.IsSelected("prometheus/prometheus.v0.22.2"))
+	assert.True(t, solution.IsSelected("etcd/etcd.v0.9.2"))
+	assert.False(t, solution.IsSelected("etcd/etcd.v0.9.0"))
+	assert.False(t, solution.IsSelected("etcd/etcd.v1.0.0"))
+}
@@ -0,0 +1,194 @@
+package catalog
+
+import (
+	"context"
+	"github.com/perdasilva/replee/pkg/deppy"
+	"github.com/perdasilva/replee/pkg/deppy/semver"
+	"github.com/perdasilva/replee/pkg/deppy/variables"
+)
+
+const (
+	VariableKindBundle          = "deppy.variable.bundle"
+	VariableKindPackage         = "deppy.variable.package"
+	VariableKindRequiredPackage = "deppy.variable.required-package"
+)
+
+// NewCatalogVariableSource returns a variable source that activates, when offered the bootstrap (nil) variable:
+//   - a bundle variable for every bundle in the catalog, with a dependency constraint for each API and package
+//     it requires listing the candidate bundles in order of preference (see Package.PreferredBundles)
+//   - a package variable for every package, allowing at most one of its bundles to be selected
+//   - a mandatory required package variable for every requirement, depending on the matching bundles
+func NewCatalogVariableSource(variableSourceID deppy.Identifier, catalog *Catalog, requirements ...semver.Requirement) deppy.VariableSource {
+	return &catalogVariableSource{
+		variableSourceID: variableSourceID,
+		catalog:          catalog,
+		requirements:     requirements,
+	}
+}
+
+// NewCatalogVariableSourceFromPath loads the catalog at path and returns a catalog variable source identified by the path
+func NewCatalogVariableSourceFromPath(path string, requirements ...semver.Requirement) (deppy.VariableSource, error) {
+	catalog, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewCatalogVariableSource(deppy.Identifier(path), catalog, requirements...), nil
+}
+
+// BundleVariableID returns the identifier of the variable of the bundle in the package
+func BundleVariableID(packageName string, bundleName string) deppy.Identifier {
+	return deppy.Identifierf("%s/%s", packageName, bundleName)
+}
+
+var _ deppy.VariableSource = &catalogVariableSource{}
+
+type catalogVariableSource struct {
+	variableSourceID deppy.Identifier
+	catalog          *Catalog
+	requirements     []semver.Requirement
+}
+
+func (c *catalogVariableSource) VariableSourceID() deppy.Identifier {
+	return c.variableSourceID
+}
+
+func (c *catalogVariableSource) VariableFilterFunc() deppy.VarFilterFn {
+	return nil
+}
+
+func (c *catalogVariableSource) Update(_ context.Context, problem deppy.MutableResolutionProblem, variable deppy.MutableVariable) error {
+	if variable != nil {
+		return nil
+	}
+	vars, err := c.variables()
+	if err != nil {
+		return err
+	}
+	for _, v := range vars {
+		if err := problem.ActivateVariable(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *catalogVariableSource) Finalize(_ context.Context, _ deppy.MutableResolutionProblem) error {
+	return nil
+}
+
+func (c *catalogVariableSource) variables() ([]deppy.MutableVariable, error) {
+	var vars []deppy.MutableVariable
+	for _, packageName := range c.catalog.PackageNames() {
+		pkg := c.catalog.Packages[packageName]
+		bundles := pkg.PreferredBundles()
+
+		bundleIDs := make([]deppy.Identifier, 0, len(bundles))
+		for _, b := range bundles {
+			v, err := c.bundleVariable(pkg, b)
+			if err != nil {
+				return nil, err
+			}
+			vars = append(vars, v)
+			bundleIDs = append(bundleIDs, v.VariableID())
+		}
+
+		v := variables.NewMutableVariable(deppy.Identifier(packageName), VariableKindPackage, map[string]interface{}{
+			"package":        packageName,
+			"defaultChannel": pkg.DefaultChannel,
+		})
+		if err := v.AddAtMost("at-most-one-bundle", 1, bundleIDs...); err != nil {
+			return nil, err
+		}
+		vars = append(vars, v)
+	}
+
+	for _, r := range c.requirements {
+		candidates, err := c.packageCandidates(r.PackageName, r.VersionRange)
+		if err != nil {
+			return nil, err
+		}
+		v := variables.NewMutableVariable(deppy.Identifierf("required/%s", r.PackageName), VariableKindRequiredPackage, map[string]interface{}{
+			"package":      r.PackageName,
+			"versionRange": r.VersionRange,
+		})
+		if err := v.AddMandatory("required"); err != nil {
+			return nil, err
+		}
+		if err := v.AddDependency(deppy.Identifierf("requires-package/%s", r.PackageName), candidates...); err != nil {
+			return nil, err
+		}
+		vars = append(vars, v)
+	}
+	return vars, nil
+}
+
+func (c *catalogVariableSource) bundleVariable(pkg *Package, b *Bundle) (deppy.MutableVariable, error) {
+	providedAPIs := make([]interface{}, 0, len(b.ProvidedAPIs))
+	for _, gvk := range b.ProvidedAPIs {
+		providedAPIs = append(providedAPIs, gvk.String())
+	}
+	channels := make([]interface{}, 0)
+	for _, channel := range pkg.BundleChannels(b.Name) {
+		channels = append(channels, channel)
+	}
+	v := variables.NewMutableVariable(BundleVariableID(pkg.Name, b.Name), VariableKindBundle, map[string]interface{}{
+		"package":      pkg.Name,
+		"bundle":       b.Name,
+		"version":      b.Version.String(),
+		"image":        b.Image,
+		"channels":     channels,
+		"providedAPIs": providedAPIs,
+	})
+
+	for _, gvk := range b.RequiredAPIs {
+		if err := v.AddDependency(deppy.Identifierf("requires-api/%s", gvk), c.apiProviders(gvk)...); err != nil {
+			return nil, err
+		}
+	}
+	for _, r := range b.RequiredPackages {
+		candidates, err := c.packageCandidates(r.PackageName, r.VersionRange)
+		if err != nil {
+			return nil, err
+		}
+		if err := v.AddDependency(deppy.Identifierf("requires-package/%s", r.PackageName), candidates...); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// apiProviders returns the bundles providing the API, package by package in alphabetical order, and in order
+// of preference within a package
+func (c *catalogVariableSource) apiProviders(gvk GVK) []deppy.Identifier {
+	var ids []deppy.Identifier
+	for _, packageName := range c.catalog.PackageNames() {
+		for _, b := range c.catalog.Packages[packageName].PreferredBundles() {
+			for _, provided := range b.ProvidedAPIs {
+				if provided == gvk {
+					ids = append(ids, BundleVariableID(packageName, b.Name))
+					break
+				}
+			}
+		}
+	}
+	return ids
+}
+
+// packageCandidates returns the package's bundles within the version range, in order of preference
+func (c *catalogVariableSource) packageCandidates(packageName string, versionRange string) ([]deppy.Identifier, error) {
+	constraint, err := semver.ParseRange(versionRange)
+	if err != nil {
+		return nil, deppy.Fatalf("package %s: %s", packageName, err)
+	}
+	pkg, ok := c.catalog.Packages[packageName]
+	if !ok {
+		return nil, nil
+	}
+	var ids []deppy.Identifier
+	for _, b := range pkg.PreferredBundles() {
+		if constraint.Contains(b.Version) {
+			ids = append(ids, BundleVariableID(packageName, b.Name))
+		}
+	}
+	return ids, nil
+}
@@ -149,6 +149,12 @@ func (constraint *ConflictConstraint) Anchor() bool {
 	return false
 }
 
+func (constraint *ConflictConstraint) ConflictingVariableID() deppy.Identifier {
+	constraint.lock.RLock()
+	defer constraint.lock.RUnlock()
+	return constraint.conflictingVariableID
+}
+
 func (constraint *ConflictConstraint) SetConflictingVariableID(id deppy.Identifier) error {
 	constraint.lock.Lock()
 	defer constraint.lock.Unlock()
@@ -366,6 +372,12 @@ func (constraint *AtMostConstraint) String(subject deppy.Identifier) string {
 	return fmt.Sprintf("%s permits at most %d of %s", subject, constraint.n, strings.Join(s, ", "))
 }
 
+func (constraint *AtMostConstraint) N() int {
+	constraint.lock.RLock()
+	defer constraint.lock.RUnlock()
+	return constraint.n
+}
+
 func (constraint *AtMostConstraint) SetN(n int) error {
 	constraint.lock.Lock()
 	defer constraint.lock.Unlock()
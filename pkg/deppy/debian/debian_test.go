@@ -0,0 +1,129 @@
+package debian_test
+
+import (
+	"context"
+	"github.com/perdasilva/replee/pkg/deppy"
+	"github.com/perdasilva/replee/pkg/deppy/constraints"
+	. "github.com/perdasilva/replee/pkg/deppy/debian"
+	"github.com/perdasilva/replee/pkg/deppy/resolution"
+	"github.com/perdasilva/replee/pkg/deppy/resolver"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tt := []struct {
+		a, b string
+		want int
+	}{
+		{a: "1.0", b: "1.0", want: 0},
+		{a: "1.0", b: "1.0-0", want: 0},
+		{a: "1.10", b: "1.9", want: 1},
+		{a: "1.0~rc1", b: "1.0", want: -1},
+		{a: "1.0~rc1", b: "1.0~rc1~1", want: 1},
+		{a: "1:0.1", b: "2.0", want: 1},
+		{a: "2.0-1", b: "2.0-1ubuntu1", want: -1},
+		{a: "1.0a", b: "1.0+", want: -1},
+		{a: "1.001", b: "1.1", want: 0},
+	}
+	for _, tc := range tt {
+		t.Run(tc.a+" "+tc.b, func(t *testing.T) {
+			got := CompareVersions(tc.a, tc.b)
+			switch {
+			case tc.want < 0:
+				assert.Negative(t, got)
+			case tc.want > 0:
+				assert.Positive(t, got)
+			default:
+				assert.Zero(t, got)
+			}
+		})
+	}
+}
+
+func TestParseRelations(t *testing.T) {
+	groups, err := ParseRelations("libc6:any (>= 2.34), default-mta | mail-transport-agent [amd64], python3 (>> 3.9) <!nocheck>")
+	assert.NoError(t, err)
+	assert.Equal(t, [][]Relation{
+		{{Name: "libc6", Op: ">=", Version: "2.34"}},
+		{{Name: "default-mta"}, {Name: "mail-transport-agent"}},
+		{{Name: "python3", Op: ">>", Version: "3.9"}},
+	}, groups)
+
+	_, err = ParseRelations("libc6 (~ 2)")
+	assert.EqualError(t, err, `unknown relation operator "" in "libc6 (~ 2)"`)
+}
+
+const packages = `Package: mutt
+Version: 2.2.9-1
+Architecture: amd64
+Depends: libc6 (>= 2.34),
+ default-mta | mail-transport-agent
+Breaks: mutt-patched
+
+Package: libc6
+Version: 2.36-9
+Architecture: amd64
+
+Package: libc6
+Version: 2.31-13
+Architecture: amd64
+
+Package: exim4
+Version: 4.96-15
+Architecture: all
+Provides: mail-transport-agent
+Conflicts: mail-transport-agent
+
+Package: postfix
+Version: 3.7.5-2
+Architecture: amd64
+Provides: mail-transport-agent
+Conflicts: mail-transport-agent
+
+Package: mutt-patched
+Version: 1.0
+Architecture: amd64
+`
+
+func TestPackagesVariableSource(t *testing.T) {
+	ctx := context.Background()
+	index, err := Parse(strings.NewReader(packages))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"exim4", "libc6", "mutt", "mutt-patched", "postfix"}, index.Names())
+
+	install, err := ParseInstallRequest("mutt, mutt-patched")
+	assert.NoError(t, err)
+	problem, err := resolution.NewResolutionProblemBuilder("test").
+		WithVariableSources(NewPackagesVariableSource("Packages", index, install...)).
+		Build(ctx)
+	assert.NoError(t, err)
+
+	mutt, err := problem.(deppy.MutableResolutionProblem).GetMutableVariable("mutt=2.2.9-1", VariableKindPackageVersion)
+	assert.NoError(t, err)
+	c, _ := mutt.GetConstraint("depends/libc6 (>= 2.34)")
+	assert.Equal(t, []deppy.Identifier{"libc6=2.36-9"}, c.(*constraints.DependencyConstraint).Elements())
+	c, _ = mutt.GetConstraint("depends/default-mta | mail-transport-agent")
+	assert.Equal(t, []deppy.Identifier{"exim4=4.96-15", "postfix=3.7.5-2"}, c.(*constraints.DependencyConstraint).Elements())
+	_, ok := mutt.GetConstraint("breaks/mutt-patched=1.0")
+	assert.True(t, ok)
+
+	solution, err := resolver.NewDeppyResolver().Solve(ctx, problem)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, solution.NotSatisfiable())
+
+	install, err = ParseInstallRequest("mutt")
+	assert.NoError(t, err)
+	problem, err = resolution.NewResolutionProblemBuilder("test").
+		WithVariableSources(NewPackagesVariableSource("Packages", index, install...)).
+		Build(ctx)
+	assert.NoError(t, err)
+	solution, err = resolver.NewDeppyResolver().Solve(ctx, problem)
+	assert.NoError(t, err)
+	assert.Empty(t, solution.NotSatisfiable())
+	assert.True(t, solution.IsSelected("mutt=2.2.9-1"))
+	assert.True(t, solution.IsSelected("libc6=2.36-9"))
+	assert.True(t, solution.IsSelected("exim4=4.96-15"))
+	assert.False(t, solution.IsSelected("postfix=3.7.5-2"))
+}
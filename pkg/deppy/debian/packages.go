@@ -0,0 +1,373 @@
+// Package debian imports Debian Packages index files and turns them into resolution problems over
+// package versions.
+package debian
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Package is a version of a package, as described by a stanza of a Packages index
+type Package struct {
+	Name         string
+	Version      string
+	Architecture string
+	Depends      [][]Relation
+	Conflicts    []Relation
+	Breaks       []Relation
+	Provides     []Relation
+}
+
+// Relation names a package, optionally restricted to the versions for which Op Version holds
+type Relation struct {
+	Name    string `json:"name"`
+	Op      string `json:"op,omitempty"`
+	Version string `json:"version,omitempty"`
+}
+
+func (r Relation) String() string {
+	if r.Op == "" {
+		return r.Name
+	}
+	return fmt.Sprintf("%s (%s %s)", r.Name, r.Op, r.Version)
+}
+
+// Satisfies returns true if the version satisfies the relation's version restriction
+func (r Relation) Satisfies(version string) bool {
+	if r.Op == "" {
+		return true
+	}
+	c := CompareVersions(version, r.Version)
+	switch r.Op {
+	case "<<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case "=":
+		return c == 0
+	case ">=":
+		return c >= 0
+	case ">>":
+		return c > 0
+	}
+	return false
+}
+
+// Index holds the package versions of one or more Packages files
+type Index struct {
+	Packages []*Package
+	byName   map[string][]*Package
+	provides map[string][]provider
+}
+
+type provider struct {
+	pkg     *Package
+	version string
+}
+
+// Load reads a Packages index file, which may be gzip compressed
+func Load(path string) (*Index, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	index, err := Parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", path, err)
+	}
+	return index, nil
+}
+
+// Parse reads a Packages index. Pre-Depends are treated like Depends, the other fields are ignored.
+// Only the first stanza of a package version is kept.
+func Parse(r io.Reader) (*Index, error) {
+	index := &Index{
+		byName:   map[string][]*Package{},
+		provides: map[string][]provider{},
+	}
+	seen := map[string]struct{}{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	fields := map[string]string{}
+	var last string
+	lineNumber := 0
+	flush := func() error {
+		if len(fields) == 0 {
+			return nil
+		}
+		defer func() {
+			fields = map[string]string{}
+		}()
+		pkg, err := parsePackage(fields)
+		if err != nil {
+			return fmt.Errorf("stanza ending on line %d: %w", lineNumber, err)
+		}
+		key := pkg.Name + "=" + pkg.Version
+		if _, ok := seen[key]; ok {
+			return nil
+		}
+		seen[key] = struct{}{}
+		index.add(pkg)
+		return nil
+	}
+
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Text()
+		switch {
+		case strings.TrimSpace(line) == "":
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		case line[0] == ' ' || line[0] == '\t':
+			if last == "" {
+				return nil, fmt.Errorf("line %d: continuation line without a field", lineNumber)
+			}
+			fields[last] += " " + strings.TrimSpace(line)
+		default:
+			i := strings.Index(line, ":")
+			if i < 0 {
+				return nil, fmt.Errorf("line %d: expected a field, got %q", lineNumber, line)
+			}
+			last = strings.ToLower(line[:i])
+			fields[last] = strings.TrimSpace(line[i+1:])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	index.sort()
+	return index, nil
+}
+
+func parsePackage(fields map[string]string) (*Package, error) {
+	pkg := &Package{
+		Name:         fields["package"],
+		Version:      fields["version"],
+		Architecture: fields["architecture"],
+	}
+	if pkg.Name == "" {
+		return nil, fmt.Errorf("missing Package field")
+	}
+	if pkg.Version == "" {
+		return nil, fmt.Errorf("package %s is missing the Version field", pkg.Name)
+	}
+
+	for _, field := range []string{"pre-depends", "depends"} {
+		groups, err := ParseRelations(fields[field])
+		if err != nil {
+			return nil, fmt.Errorf("package %s: %w", pkg.Name, err)
+		}
+		pkg.Depends = append(pkg.Depends, groups...)
+	}
+	for _, f := range []struct {
+		field     string
+		relations *[]Relation
+	}{{"conflicts", &pkg.Conflicts}, {"breaks", &pkg.Breaks}, {"provides", &pkg.Provides}} {
+		groups, err := ParseRelations(fields[f.field])
+		if err != nil {
+			return nil, fmt.Errorf("package %s: %w", pkg.Name, err)
+		}
+		for _, group := range groups {
+			if len(group) != 1 {
+				return nil, fmt.Errorf("package %s: alternatives are not allowed in %s", pkg.Name, f.field)
+			}
+			*f.relations = append(*f.relations, group[0])
+		}
+	}
+	return pkg, nil
+}
+
+// ParseRelations parses a comma separated list of relations, each being a | separated list of alternatives,
+// e.g. "libc6 (>= 2.34), mail-transport-agent | postfix". Architecture qualifiers (libc6:any), architecture
+// restrictions ([amd64]) and build profiles (<!nocheck>) are dropped.
+func ParseRelations(s string) ([][]Relation, error) {
+	var groups [][]Relation
+	for _, group := range strings.Split(s, ",") {
+		if strings.TrimSpace(group) == "" {
+			continue
+		}
+		var alternatives []Relation
+		for _, alternative := range strings.Split(group, "|") {
+			r, err := parseRelation(alternative)
+			if err != nil {
+				return nil, err
+			}
+			alternatives = append(alternatives, r)
+		}
+		groups = append(groups, alternatives)
+	}
+	return groups, nil
+}
+
+func parseRelation(s string) (Relation, error) {
+	s = strings.TrimSpace(s)
+	// the name ends where the version restriction, architecture restriction or build profile starts
+	end := strings.IndexAny(s, " \t([<")
+	if end < 0 {
+		end = len(s)
+	}
+	r := Relation{Name: s[:end]}
+	if i := strings.Index(r.Name, ":"); i >= 0 {
+		r.Name = r.Name[:i]
+	}
+	if r.Name == "" {
+		return Relation{}, fmt.Errorf("malformed relation %q", s)
+	}
+
+	rest := strings.TrimSpace(s[end:])
+	if !strings.HasPrefix(rest, "(") {
+		return r, nil
+	}
+	j := strings.Index(rest, ")")
+	if j < 0 {
+		return Relation{}, fmt.Errorf("malformed relation %q", s)
+	}
+	restriction := strings.TrimSpace(rest[1:j])
+	op := restriction[:len(restriction)-len(strings.TrimLeft(restriction, "<>="))]
+	r.Op = op
+	r.Version = strings.TrimSpace(restriction[len(op):])
+	// < and > are obsolete spellings of <= and >=
+	switch r.Op {
+	case "<":
+		r.Op = "<="
+	case ">":
+		r.Op = ">="
+	case "<<", "<=", "=", ">=", ">>":
+	default:
+		return Relation{}, fmt.Errorf("unknown relation operator %q in %q", op, s)
+	}
+	if r.Version == "" {
+		return Relation{}, fmt.Errorf("missing version in %q", s)
+	}
+	return r, nil
+}
+
+func (x *Index) add(pkg *Package) {
+	x.Packages = append(x.Packages, pkg)
+	x.byName[pkg.Name] = append(x.byName[pkg.Name], pkg)
+	for _, p := range pkg.Provides {
+		x.provides[p.Name] = append(x.provides[p.Name], provider{pkg: pkg, version: p.Version})
+	}
+}
+
+// sort orders the versions of each package, and the providers of each virtual package, newest first
+func (x *Index) sort() {
+	for _, versions := range x.byName {
+		sort.SliceStable(versions, func(i, j int) bool {
+			return CompareVersions(versions[i].Version, versions[j].Version) > 0
+		})
+	}
+	for _, providers := range x.provides {
+		sort.SliceStable(providers, func(i, j int) bool {
+			if providers[i].pkg.Name != providers[j].pkg.Name {
+				return providers[i].pkg.Name < providers[j].pkg.Name
+			}
+			return CompareVersions(providers[i].pkg.Version, providers[j].pkg.Version) > 0
+		})
+	}
+}
+
+// Names returns the names of the real packages in the index in alphabetical order
+func (x *Index) Names() []string {
+	names := make([]string, 0, len(x.byName))
+	for name := range x.byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Versions returns the versions of the named package, newest first
+func (x *Index) Versions(name string) []*Package {
+	return x.byName[name]
+}
+
+// Satisfying returns the package versions that satisfy the relation, newest first: versions of the named
+// package, followed by the packages providing it. Unversioned provides only satisfy unversioned relations.
+func (x *Index) Satisfying(r Relation) []*Package {
+	var out []*Package
+	for _, pkg := range x.byName[r.Name] {
+		if r.Satisfies(pkg.Version) {
+			out = append(out, pkg)
+		}
+	}
+	for _, p := range x.provides[r.Name] {
+		if r.Op == "" || (p.version != "" && r.Satisfies(p.version)) {
+			out = append(out, p.pkg)
+		}
+	}
+	return out
+}
+
+// ParseInstallRequest parses the packages to install, given like relations, e.g. "bash (>= 5), coreutils"
+func ParseInstallRequest(s string) ([]Relation, error) {
+	groups, err := ParseRelations(s)
+	if err != nil {
+		return nil, err
+	}
+	var out []Relation
+	for _, group := range groups {
+		if len(group) != 1 {
+			return nil, fmt.Errorf("alternatives are not allowed in install requests")
+		}
+		out = append(out, group[0])
+	}
+	return out, nil
+}
+
+// String renders the package version as a Packages stanza
+func (p *Package) String() string {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "Package: %s\nVersion: %s\n", p.Name, p.Version)
+	if p.Architecture != "" {
+		fmt.Fprintf(&b, "Architecture: %s\n", p.Architecture)
+	}
+	writeRelations := func(field string, groups [][]Relation) {
+		if len(groups) == 0 {
+			return
+		}
+		var parts []string
+		for _, group := range groups {
+			var alternatives []string
+			for _, r := range group {
+				alternatives = append(alternatives, r.String())
+			}
+			parts = append(parts, strings.Join(alternatives, " | "))
+		}
+		fmt.Fprintf(&b, "%s: %s\n", field, strings.Join(parts, ", "))
+	}
+	single := func(relations []Relation) [][]Relation {
+		var groups [][]Relation
+		for _, r := range relations {
+			groups = append(groups, []Relation{r})
+		}
+		return groups
+	}
+	writeRelations("Depends", p.Depends)
+	writeRelations("Conflicts", single(p.Conflicts))
+	writeRelations("Breaks", single(p.Breaks))
+	writeRelations("Provides", single(p.Provides))
+	return b.String()
+}
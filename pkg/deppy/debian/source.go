@@ -0,0 +1,218 @@
+package debian
+
+import (
+	"context"
+	"github.com/perdasilva/replee/pkg/deppy"
+	"github.com/perdasilva/replee/pkg/deppy/semver"
+	"github.com/perdasilva/replee/pkg/deppy/variables"
+	"strings"
+)
+
+const (
+	VariableKindPackageVersion = "deppy.variable.deb"
+	VariableKindPackage        = "deppy.variable.deb-package"
+	VariableKindInstall        = "deppy.variable.deb-install"
+)
+
+// NewPackagesVariableSource returns a variable source that activates, when offered the bootstrap (nil) variable:
+//   - a package version variable for every package version in the index, or only for those the packages to install
+//     can transitively depend on if there are any, identified by name=version, with
+//     a dependency constraint for each Depends entry listing the package versions that satisfy its alternatives,
+//     in order, newest first within an alternative, and a conflict constraint on each package version it
+//     Conflicts with or Breaks among those. A package never conflicts with its own versions.
+//   - a package variable for every package name, allowing at most one of its versions to be selected
+//   - a mandatory install variable for every package to install, depending on the versions that satisfy it
+func NewPackagesVariableSource(variableSourceID deppy.Identifier, index *Index, install ...Relation) deppy.VariableSource {
+	return &packagesVariableSource{
+		variableSourceID: variableSourceID,
+		index:            index,
+		install:          install,
+	}
+}
+
+// NewPackagesVariableSourceFromFile loads the Packages index at path and returns a variable source identified by the path
+func NewPackagesVariableSourceFromFile(path string, install ...Relation) (deppy.VariableSource, error) {
+	index, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewPackagesVariableSource(deppy.Identifier(path), index, install...), nil
+}
+
+// PackageVariableID returns the identifier of the package version's variable
+func PackageVariableID(pkg *Package) deppy.Identifier {
+	return deppy.Identifierf("%s=%s", pkg.Name, pkg.Version)
+}
+
+var _ deppy.VariableSource = &packagesVariableSource{}
+
+type packagesVariableSource struct {
+	variableSourceID deppy.Identifier
+	index            *Index
+	install          []Relation
+}
+
+func (p *packagesVariableSource) VariableSourceID() deppy.Identifier {
+	return p.variableSourceID
+}
+
+func (p *packagesVariableSource) VariableFilterFunc() deppy.VarFilterFn {
+	return nil
+}
+
+func (p *packagesVariableSource) Update(_ context.Context, problem deppy.MutableResolutionProblem, variable deppy.MutableVariable) error {
+	if variable != nil {
+		return nil
+	}
+	vars, err := p.variables()
+	if err != nil {
+		return err
+	}
+	for _, v := range vars {
+		if err := problem.ActivateVariable(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *packagesVariableSource) Finalize(_ context.Context, _ deppy.MutableResolutionProblem) error {
+	return nil
+}
+
+func (p *packagesVariableSource) variables() ([]deppy.MutableVariable, error) {
+	included := p.closure()
+	var vars []deppy.MutableVariable
+	for _, name := range p.index.Names() {
+		var ids []deppy.Identifier
+		for _, pkg := range p.index.Versions(name) {
+			if !included(pkg) {
+				continue
+			}
+			v, err := p.packageVersionVariable(pkg, included)
+			if err != nil {
+				return nil, err
+			}
+			vars = append(vars, v)
+			ids = append(ids, v.VariableID())
+		}
+		if len(ids) == 0 {
+			continue
+		}
+
+		v := variables.NewMutableVariable(deppy.Identifier(name), VariableKindPackage, map[string]interface{}{
+			semver.PackageProperty: name,
+		})
+		if err := v.AddAtMost("at-most-one-version", 1, ids...); err != nil {
+			return nil, err
+		}
+		vars = append(vars, v)
+	}
+
+	for _, r := range p.install {
+		v := variables.NewMutableVariable(deppy.Identifierf("install/%s", r.Name), VariableKindInstall, map[string]interface{}{
+			"relation": r.String(),
+		})
+		if err := v.AddMandatory("install"); err != nil {
+			return nil, err
+		}
+		if err := v.AddDependency(deppy.Identifierf("depends/%s", r), p.satisfying([]Relation{r})...); err != nil {
+			return nil, err
+		}
+		vars = append(vars, v)
+	}
+	return vars, nil
+}
+
+// closure returns whether a package version can be depended on, transitively, by the packages to install.
+// Without packages to install, every package version is included.
+func (p *packagesVariableSource) closure() func(pkg *Package) bool {
+	if len(p.install) == 0 {
+		return func(*Package) bool {
+			return true
+		}
+	}
+	included := map[*Package]struct{}{}
+	var queue []*Package
+	visit := func(alternatives []Relation) {
+		for _, r := range alternatives {
+			for _, pkg := range p.index.Satisfying(r) {
+				if _, ok := included[pkg]; !ok {
+					included[pkg] = struct{}{}
+					queue = append(queue, pkg)
+				}
+			}
+		}
+	}
+	visit(p.install)
+	for len(queue) > 0 {
+		pkg := queue[0]
+		queue = queue[1:]
+		for _, group := range pkg.Depends {
+			visit(group)
+		}
+	}
+	return func(pkg *Package) bool {
+		_, ok := included[pkg]
+		return ok
+	}
+}
+
+func (p *packagesVariableSource) packageVersionVariable(pkg *Package, included func(pkg *Package) bool) (deppy.MutableVariable, error) {
+	v := variables.NewMutableVariable(PackageVariableID(pkg), VariableKindPackageVersion, map[string]interface{}{
+		semver.PackageProperty: pkg.Name,
+		semver.VersionProperty: pkg.Version,
+		"architecture":         pkg.Architecture,
+	})
+
+	for _, group := range pkg.Depends {
+		alternatives := make([]string, len(group))
+		for i, r := range group {
+			alternatives[i] = r.String()
+		}
+		constraintID := deppy.Identifierf("depends/%s", strings.Join(alternatives, " | "))
+		if err := v.AddDependency(constraintID, p.satisfying(group)...); err != nil {
+			return nil, err
+		}
+	}
+
+	conflicts := map[deppy.Identifier]struct{}{}
+	for _, field := range []struct {
+		prefix    string
+		relations []Relation
+	}{{"conflicts", pkg.Conflicts}, {"breaks", pkg.Breaks}} {
+		for _, r := range field.relations {
+			for _, other := range p.index.Satisfying(r) {
+				if other.Name == pkg.Name || !included(other) {
+					continue
+				}
+				otherID := PackageVariableID(other)
+				constraintID := deppy.Identifierf("%s/%s", field.prefix, otherID)
+				if _, ok := conflicts[constraintID]; ok {
+					continue
+				}
+				conflicts[constraintID] = struct{}{}
+				if err := v.AddConflict(constraintID, otherID); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return v, nil
+}
+
+// satisfying returns the package versions satisfying the alternatives, in order and without duplicates
+func (p *packagesVariableSource) satisfying(alternatives []Relation) []deppy.Identifier {
+	var ids []deppy.Identifier
+	seen := map[deppy.Identifier]struct{}{}
+	for _, r := range alternatives {
+		for _, pkg := range p.index.Satisfying(r) {
+			id := PackageVariableID(pkg)
+			if _, ok := seen[id]; !ok {
+				seen[id] = struct{}{}
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
@@ -0,0 +1,102 @@
+package debian
+
+import (
+	"strconv"
+	"strings"
+)
+
+// CompareVersions compares two Debian package versions ([epoch:]upstream[-revision]) the way dpkg does,
+// returning a negative number, zero or a positive number if a is older than, the same as, or newer than b
+func CompareVersions(a string, b string) int {
+	epochA, upstreamA, revisionA := splitVersion(a)
+	epochB, upstreamB, revisionB := splitVersion(b)
+	if epochA != epochB {
+		if epochA < epochB {
+			return -1
+		}
+		return 1
+	}
+	if c := compareFragment(upstreamA, upstreamB); c != 0 {
+		return c
+	}
+	return compareFragment(revisionA, revisionB)
+}
+
+func splitVersion(version string) (int, string, string) {
+	epoch := 0
+	if i := strings.Index(version, ":"); i >= 0 {
+		if e, err := strconv.Atoi(version[:i]); err == nil {
+			epoch = e
+		}
+		version = version[i+1:]
+	}
+	revision := ""
+	if i := strings.LastIndex(version, "-"); i >= 0 {
+		revision = version[i+1:]
+		version = version[:i]
+	}
+	return epoch, version, revision
+}
+
+// order ranks a character in the non digit parts of a version: ~ sorts before everything, even the
+// end of the part, and letters sort before the other characters
+func order(c byte) int {
+	switch {
+	case c == 0 || isDigit(c):
+		return 0
+	case c == '~':
+		return -1
+	case (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+		return int(c)
+	default:
+		return int(c) + 256
+	}
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// compareFragment compares alternating runs of non digits, character by character, and digits, numerically
+func compareFragment(a string, b string) int {
+	at := func(s string, i int) byte {
+		if i < len(s) {
+			return s[i]
+		}
+		return 0
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		for (i < len(a) && !isDigit(a[i])) || (j < len(b) && !isDigit(b[j])) {
+			if oa, ob := order(at(a, i)), order(at(b, j)); oa != ob {
+				return oa - ob
+			}
+			i++
+			j++
+		}
+		for at(a, i) == '0' {
+			i++
+		}
+		for at(b, j) == '0' {
+			j++
+		}
+		firstDiff := 0
+		for isDigit(at(a, i)) && isDigit(at(b, j)) {
+			if firstDiff == 0 {
+				firstDiff = int(a[i]) - int(b[j])
+			}
+			i++
+			j++
+		}
+		if isDigit(at(a, i)) {
+			return 1
+		}
+		if isDigit(at(b, j)) {
+			return -1
+		}
+		if firstDiff != 0 {
+			return firstDiff
+		}
+	}
+	return 0
+}
@@ -0,0 +1,182 @@
+// Package dimacs reads DIMACS CNF files, such as those written by solver.WriteDIMACS, and turns them
+// into raw resolution problems.
+package dimacs
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/perdasilva/replee/pkg/deppy"
+	"github.com/perdasilva/replee/pkg/deppy/resolution"
+	"github.com/perdasilva/replee/pkg/deppy/variables"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	VariableKindVariable = "deppy.variable.dimacs"
+	VariableKindClause   = "deppy.variable.dimacs-clause"
+	VariableKindNegation = "deppy.variable.dimacs-negation"
+)
+
+// Formula is a CNF formula. Clauses hold DIMACS literals: positive or negative variable numbers.
+type Formula struct {
+	NumVariables int
+	Clauses      [][]int
+	// Names holds the variable identifiers found in "c variable <n> <quoted id>" comments
+	Names map[int]deppy.Identifier
+}
+
+// Parse reads a DIMACS CNF file
+func Parse(r io.Reader) (*Formula, error) {
+	f := &Formula{Names: map[int]deppy.Identifier{}}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	seenHeader := false
+	numClauses := 0
+	var clause []int
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || line == "%":
+			continue
+		case strings.HasPrefix(line, "c"):
+			var n int
+			var id string
+			if _, err := fmt.Sscanf(line, "c variable %d %q", &n, &id); err == nil {
+				f.Names[n] = deppy.Identifier(id)
+			}
+			continue
+		case strings.HasPrefix(line, "p"):
+			if _, err := fmt.Sscanf(line, "p cnf %d %d", &f.NumVariables, &numClauses); err != nil {
+				return nil, fmt.Errorf("line %d: malformed problem line %q", lineNumber, line)
+			}
+			seenHeader = true
+			continue
+		}
+		if !seenHeader {
+			return nil, fmt.Errorf("line %d: clause before the problem line", lineNumber)
+		}
+		for _, field := range strings.Fields(line) {
+			m, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid literal %q", lineNumber, field)
+			}
+			if m == 0 {
+				f.Clauses = append(f.Clauses, clause)
+				clause = nil
+				continue
+			}
+			if m > f.NumVariables || -m > f.NumVariables {
+				return nil, fmt.Errorf("line %d: literal %d is out of range for %d variables", lineNumber, m, f.NumVariables)
+			}
+			clause = append(clause, m)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !seenHeader {
+		return nil, fmt.Errorf("no problem line found")
+	}
+	if len(clause) > 0 {
+		f.Clauses = append(f.Clauses, clause)
+	}
+	if len(f.Clauses) != numClauses {
+		return nil, fmt.Errorf("expected %d clauses, found %d", numClauses, len(f.Clauses))
+	}
+	return f, nil
+}
+
+// Load reads a DIMACS CNF file from path
+func Load(path string) (*Formula, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	f, err := Parse(file)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", path, err)
+	}
+	return f, nil
+}
+
+// VariableID returns the identifier of the variable of the DIMACS variable: its name, or x<n> if it has none
+func (f *Formula) VariableID(n int) deppy.Identifier {
+	if name, ok := f.Names[n]; ok {
+		return name
+	}
+	return deppy.Identifierf("x%d", n)
+}
+
+// Problem returns a raw resolution problem equivalent to the formula, using only the built-in constraints:
+//   - a variable for every DIMACS variable (see VariableID)
+//   - for every variable that appears negated, a negation variable not/<id> that conflicts with it, and
+//     a mandatory not/<id>/complete variable depending on either of them, so that exactly one is selected
+//   - for every clause, a mandatory clause/<n> variable depending on the variables of its positive literals
+//     and the negation variables of its negative literals
+//
+// The problem is satisfiable if, and only if, the formula is, and the DIMACS variables selected in a
+// solution satisfy the formula.
+func (f *Formula) Problem(resolutionProblemID deppy.Identifier) (*resolution.MutableResolutionProblem, error) {
+	problem := resolution.NewMutableResolutionProblem(resolutionProblemID)
+	for n := 1; n <= f.NumVariables; n++ {
+		v := variables.NewMutableVariable(f.VariableID(n), VariableKindVariable, map[string]interface{}{
+			"dimacs": n,
+		})
+		if err := problem.ActivateVariable(v); err != nil {
+			return nil, err
+		}
+	}
+
+	negated := map[int]struct{}{}
+	for i, clause := range f.Clauses {
+		v := variables.NewMutableVariable(deppy.Identifierf("clause/%d", i+1), VariableKindClause, nil)
+		if err := v.AddMandatory("clause"); err != nil {
+			return nil, err
+		}
+		literals := make([]deppy.Identifier, 0, len(clause))
+		for _, m := range clause {
+			if m > 0 {
+				literals = append(literals, f.VariableID(m))
+				continue
+			}
+			id := f.VariableID(-m)
+			literals = append(literals, deppy.Identifierf("not/%s", id))
+			if _, ok := negated[-m]; ok {
+				continue
+			}
+			negated[-m] = struct{}{}
+			if err := addNegation(problem, id); err != nil {
+				return nil, err
+			}
+		}
+		if err := v.AddDependency("literals", literals...); err != nil {
+			return nil, err
+		}
+		if err := problem.ActivateVariable(v); err != nil {
+			return nil, err
+		}
+	}
+	return problem, nil
+}
+
+func addNegation(problem deppy.MutableResolutionProblem, id deppy.Identifier) error {
+	negation := variables.NewMutableVariable(deppy.Identifierf("not/%s", id), VariableKindNegation, nil)
+	if err := negation.AddConflict("negates", id); err != nil {
+		return err
+	}
+	if err := problem.ActivateVariable(negation); err != nil {
+		return err
+	}
+	complete := variables.NewMutableVariable(deppy.Identifierf("not/%s/complete", id), VariableKindClause, nil)
+	if err := complete.AddMandatory("clause"); err != nil {
+		return err
+	}
+	if err := complete.AddDependency("literals", id, negation.VariableID()); err != nil {
+		return err
+	}
+	return problem.ActivateVariable(complete)
+}
@@ -0,0 +1,82 @@
+package dimacs_test
+
+import (
+	"bytes"
+	"context"
+	"github.com/perdasilva/replee/pkg/deppy"
+	. "github.com/perdasilva/replee/pkg/deppy/dimacs"
+	"github.com/perdasilva/replee/pkg/deppy/resolution"
+	"github.com/perdasilva/replee/pkg/deppy/resolver"
+	"github.com/perdasilva/replee/pkg/deppy/solver"
+	"github.com/perdasilva/replee/pkg/deppy/variables"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	f, err := Parse(strings.NewReader(`c example
+c variable 1 "a"
+p cnf 3 2
+1 -2 0
+2 3
+0
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, f.NumVariables)
+	assert.Equal(t, [][]int{{1, -2}, {2, 3}}, f.Clauses)
+	assert.Equal(t, deppy.Identifier("a"), f.VariableID(1))
+	assert.Equal(t, deppy.Identifier("x2"), f.VariableID(2))
+
+	_, err = Parse(strings.NewReader("p cnf 1 1\n2 0\n"))
+	assert.EqualError(t, err, "line 2: literal 2 is out of range for 1 variables")
+}
+
+func TestRoundTrip(t *testing.T) {
+	tt := []struct {
+		name        string
+		conflict    bool
+		satisfiable bool
+	}{
+		{name: "satisfiable", satisfiable: true},
+		{name: "not satisfiable", conflict: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			problem := resolution.NewMutableResolutionProblem("test")
+			a := variables.NewMutableVariable("a", "deppy.var.test", nil)
+			assert.NoError(t, a.AddMandatory("mandatory"))
+			assert.NoError(t, a.AddDependency("dependency", "b", "c"))
+			b := variables.NewMutableVariable("b", "deppy.var.test", nil)
+			assert.NoError(t, b.AddConflict("conflict", "a"))
+			c := variables.NewMutableVariable("c", "deppy.var.test", nil)
+			if tc.conflict {
+				assert.NoError(t, c.AddProhibited("prohibited"))
+			}
+			for _, v := range []deppy.MutableVariable{a, b, c} {
+				assert.NoError(t, problem.ActivateVariable(v))
+			}
+
+			vars, err := problem.GetVariables()
+			assert.NoError(t, err)
+			buf := &bytes.Buffer{}
+			assert.NoError(t, solver.WriteDIMACS(buf, vars))
+			assert.Regexp(t, `c variable \d+ "a"`, buf.String())
+			assert.Contains(t, buf.String(), `"a" "dependency"`)
+
+			f, err := Parse(buf)
+			assert.NoError(t, err)
+			imported, err := f.Problem("imported")
+			assert.NoError(t, err)
+			solution, err := resolver.NewDeppyResolver().Solve(ctx, imported)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.satisfiable, len(solution.NotSatisfiable()) == 0)
+			if tc.satisfiable {
+				assert.True(t, solution.IsSelected("a"))
+				assert.False(t, solution.IsSelected("b"))
+				assert.True(t, solution.IsSelected("c"))
+			}
+		})
+	}
+}
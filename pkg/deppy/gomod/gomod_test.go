@@ -0,0 +1,117 @@
+package gomod_test
+
+import (
+	"context"
+	"github.com/perdasilva/replee/pkg/deppy"
+	"github.com/perdasilva/replee/pkg/deppy/constraints"
+	. "github.com/perdasilva/replee/pkg/deppy/gomod"
+	"github.com/perdasilva/replee/pkg/deppy/resolution"
+	"github.com/perdasilva/replee/pkg/deppy/resolver"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const modGraph = `example.com/main example.com/a@v1.0.0
+example.com/main example.com/b@v1.1.0
+example.com/a@v1.0.0 example.com/b@v1.2.0
+example.com/a@v1.1.0 example.com/b@v1.3.0
+`
+
+func TestParseModGraph(t *testing.T) {
+	g, err := ParseModGraph([]byte(modGraph))
+	assert.NoError(t, err)
+	assert.Equal(t, Module{Path: "example.com/main"}, g.Main)
+	assert.Equal(t, []string{"v1.1.0", "v1.2.0", "v1.3.0"}, g.Versions("example.com/b"))
+	assert.Equal(t, []Module{
+		{Path: "example.com/main"},
+		{Path: "example.com/a", Version: "v1.0.0"},
+		{Path: "example.com/b", Version: "v1.2.0"},
+	}, g.MVS())
+
+	_, err = ParseModGraph([]byte("example.com/main example.com/a"))
+	assert.EqualError(t, err, "line 1: required module example.com/a has no version")
+}
+
+func TestGraphVariableSource(t *testing.T) {
+	tt := []struct {
+		name     string
+		options  []Option
+		order    []deppy.Identifier
+		selected []deppy.Identifier
+	}{
+		{
+			name:     "newest first",
+			order:    []deppy.Identifier{"example.com/a@v1.1.0", "example.com/a@v1.0.0"},
+			selected: []deppy.Identifier{"example.com/main", "example.com/a@v1.1.0", "example.com/b@v1.3.0"},
+		}, {
+			name:     "prefer minimal",
+			options:  []Option{PreferMinimal()},
+			order:    []deppy.Identifier{"example.com/a@v1.0.0", "example.com/a@v1.1.0"},
+			selected: []deppy.Identifier{"example.com/main", "example.com/a@v1.0.0", "example.com/b@v1.2.0"},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			g, err := ParseModGraph([]byte(modGraph))
+			assert.NoError(t, err)
+			problem, err := resolution.NewResolutionProblemBuilder("test").
+				WithVariableSources(NewGraphVariableSource("go.mod", g, tc.options...)).
+				Build(ctx)
+			assert.NoError(t, err)
+
+			main, err := problem.(deppy.MutableResolutionProblem).GetMutableVariable("example.com/main", VariableKindModule)
+			assert.NoError(t, err)
+			c, _ := main.GetConstraint("requires/example.com/a")
+			assert.Equal(t, tc.order, c.(*constraints.DependencyConstraint).Elements())
+
+			solution, err := resolver.NewDeppyResolver().Solve(ctx, problem)
+			assert.NoError(t, err)
+			assert.Empty(t, solution.NotSatisfiable())
+			var selected []deppy.Identifier
+			for id, v := range solution.SelectedVariables() {
+				if v.Kind() == VariableKindModule {
+					selected = append(selected, id)
+				}
+			}
+			assert.ElementsMatch(t, tc.selected, selected)
+		})
+	}
+}
+
+func TestLoadGoMod(t *testing.T) {
+	dir := t.TempDir()
+	write := func(path string, content string) {
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	write(filepath.Join(dir, "go.mod"), `module example.com/main
+
+go 1.20
+
+require (
+	example.com/a v1.0.0
+	example.com/b v1.1.0 // indirect
+)
+`)
+	write(filepath.Join(dir, "go.sum"), `example.com/a v1.0.0 h1:a=
+example.com/a v1.0.0/go.mod h1:a=
+example.com/b v1.1.0/go.mod h1:b=
+example.com/b v1.2.0 h1:b=
+example.com/b v1.2.0/go.mod h1:b=
+`)
+	write(filepath.Join(dir, "cache", "example.com", "a", "@v", "v1.0.0.mod"), `module example.com/a
+require example.com/b v1.2.0
+`)
+
+	g, err := LoadGoMod(filepath.Join(dir, "go.mod"), filepath.Join(dir, "go.sum"), filepath.Join(dir, "cache"))
+	assert.NoError(t, err)
+	assert.Equal(t, []Module{{Path: "example.com/b", Version: "v1.2.0"}}, g.Requirements[Module{Path: "example.com/a", Version: "v1.0.0"}])
+	assert.Equal(t, []Module{
+		{Path: "example.com/main"},
+		{Path: "example.com/a", Version: "v1.0.0"},
+		{Path: "example.com/b", Version: "v1.2.0"},
+	}, g.MVS())
+}
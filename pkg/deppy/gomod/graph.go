@@ -0,0 +1,343 @@
+// Package gomod imports Go module requirement graphs, from go.mod and go.sum files or from saved
+// `go mod graph` output, and turns them into resolution problems.
+package gomod
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"github.com/perdasilva/replee/pkg/deppy/semver"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Module is a module version. The main module has no version.
+type Module struct {
+	Path    string `json:"path"`
+	Version string `json:"version,omitempty"`
+}
+
+func (m Module) String() string {
+	if m.Version == "" {
+		return m.Path
+	}
+	return m.Path + "@" + m.Version
+}
+
+// Graph is a module requirement graph: each module version requires a minimum version of other modules
+type Graph struct {
+	Main         Module
+	Requirements map[Module][]Module
+}
+
+func newGraph() *Graph {
+	return &Graph{Requirements: map[Module][]Module{}}
+}
+
+func (g *Graph) add(m Module) {
+	if _, ok := g.Requirements[m]; !ok {
+		g.Requirements[m] = nil
+	}
+}
+
+func (g *Graph) require(from Module, to Module) {
+	g.add(from)
+	g.add(to)
+	g.Requirements[from] = append(g.Requirements[from], to)
+}
+
+// Modules returns the module versions in the graph, sorted by path and oldest version first
+func (g *Graph) Modules() []Module {
+	var modules []Module
+	for m := range g.Requirements {
+		modules = append(modules, m)
+	}
+	sort.Slice(modules, func(i, j int) bool {
+		if modules[i].Path != modules[j].Path {
+			return modules[i].Path < modules[j].Path
+		}
+		return compareVersions(modules[i].Version, modules[j].Version) < 0
+	})
+	return modules
+}
+
+// Versions returns the known versions of the module path, oldest first
+func (g *Graph) Versions(path string) []string {
+	var versions []string
+	for _, m := range g.Modules() {
+		if m.Path == path && m.Version != "" {
+			versions = append(versions, m.Version)
+		}
+	}
+	return versions
+}
+
+// MVS returns the build list minimal version selection picks for the main module: the highest version
+// of each module path reachable from the main module. The main module comes first, the rest by path.
+func (g *Graph) MVS() []Module {
+	selected := map[string]Module{}
+	seen := map[Module]struct{}{}
+	queue := []Module{g.Main}
+	for len(queue) > 0 {
+		m := queue[0]
+		queue = queue[1:]
+		if _, ok := seen[m]; ok {
+			continue
+		}
+		seen[m] = struct{}{}
+		if current, ok := selected[m.Path]; !ok || compareVersions(m.Version, current.Version) > 0 {
+			selected[m.Path] = m
+		}
+		queue = append(queue, g.Requirements[m]...)
+	}
+
+	buildList := []Module{g.Main}
+	delete(selected, g.Main.Path)
+	var paths []string
+	for path := range selected {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		buildList = append(buildList, selected[path])
+	}
+	return buildList
+}
+
+// compareVersions compares Go module versions. Versions that aren't semantic versions sort before
+// those that are, and among themselves alphabetically.
+func compareVersions(a string, b string) int {
+	va, errA := semver.ParseVersion(a)
+	vb, errB := semver.ParseVersion(b)
+	switch {
+	case errA == nil && errB == nil:
+		if c := va.Compare(vb); c != 0 {
+			return c
+		}
+	case errA == nil:
+		return 1
+	case errB == nil:
+		return -1
+	}
+	return strings.Compare(a, b)
+}
+
+// ParseModGraph parses the output of `go mod graph`. The main module is the only module without a version.
+func ParseModGraph(data []byte) (*Graph, error) {
+	g := newGraph()
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: expected two modules, got %q", lineNumber, scanner.Text())
+		}
+		from, to := parseModule(fields[0]), parseModule(fields[1])
+		if to.Version == "" {
+			return nil, fmt.Errorf("line %d: required module %s has no version", lineNumber, fields[1])
+		}
+		if from.Version == "" {
+			if g.Main.Path != "" && g.Main != from {
+				return nil, fmt.Errorf("line %d: found a second main module %s (main module is %s)", lineNumber, from, g.Main)
+			}
+			g.Main = from
+		}
+		g.require(from, to)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if g.Main.Path == "" {
+		return nil, fmt.Errorf("no main module found")
+	}
+	return g, nil
+}
+
+// LoadModGraph reads a file holding the output of `go mod graph`
+func LoadModGraph(path string) (*Graph, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	g, err := ParseModGraph(data)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", path, err)
+	}
+	return g, nil
+}
+
+func parseModule(s string) Module {
+	if i := strings.LastIndex(s, "@"); i > 0 {
+		return Module{Path: s[:i], Version: s[i+1:]}
+	}
+	return Module{Path: s}
+}
+
+// LoadGoMod builds a graph from a go.mod file and its go.sum. The main module requires what go.mod requires,
+// and every module version listed in go.sum becomes a node. go.sum doesn't record requirements, so the
+// requirements of the other module versions are read from their go.mod files in the module cache download
+// directory (e.g. $GOMODCACHE/cache/download) when modCache is set and the files are there.
+func LoadGoMod(goModPath string, goSumPath string, modCache string) (*Graph, error) {
+	data, err := os.ReadFile(goModPath)
+	if err != nil {
+		return nil, err
+	}
+	mainPath, requirements, err := ParseGoMod(data)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", goModPath, err)
+	}
+
+	g := newGraph()
+	g.Main = Module{Path: mainPath}
+	g.add(g.Main)
+	for _, r := range requirements {
+		g.require(g.Main, r)
+	}
+
+	if goSumPath != "" {
+		sum, err := os.ReadFile(goSumPath)
+		if err != nil {
+			return nil, err
+		}
+		modules, err := ParseGoSum(sum)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %s: %w", goSumPath, err)
+		}
+		for _, m := range modules {
+			g.add(m)
+		}
+	}
+
+	if modCache == "" {
+		return g, nil
+	}
+	for _, m := range g.Modules() {
+		if m.Version == "" {
+			continue
+		}
+		data, err := os.ReadFile(modCacheFile(modCache, m))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		_, requirements, err := ParseGoMod(data)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing go.mod of %s: %w", m, err)
+		}
+		for _, r := range requirements {
+			g.require(m, r)
+		}
+	}
+	return g, nil
+}
+
+// modCacheFile returns the path of the module version's go.mod in the module cache download directory,
+// where upper case letters in module paths and versions are escaped as ! followed by the lower case letter
+func modCacheFile(modCache string, m Module) string {
+	return filepath.Join(modCache, filepath.FromSlash(escape(m.Path)), "@v", escape(m.Version)+".mod")
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if unicode.IsUpper(r) {
+			b.WriteRune('!')
+			b.WriteRune(unicode.ToLower(r))
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// ParseGoMod returns the module path and the requirements declared in a go.mod file. Replace and exclude
+// directives are ignored.
+func ParseGoMod(data []byte) (string, []Module, error) {
+	var modulePath string
+	var requirements []Module
+	inRequireBlock := false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := scanner.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if inRequireBlock {
+			if fields[0] == ")" {
+				inRequireBlock = false
+				continue
+			}
+			r, err := parseRequirement(fields)
+			if err != nil {
+				return "", nil, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+			requirements = append(requirements, r)
+			continue
+		}
+
+		switch fields[0] {
+		case "module":
+			if len(fields) != 2 {
+				return "", nil, fmt.Errorf("line %d: malformed module directive", lineNumber)
+			}
+			modulePath = strings.Trim(fields[1], `"`)
+		case "require":
+			if len(fields) == 2 && fields[1] == "(" {
+				inRequireBlock = true
+				continue
+			}
+			r, err := parseRequirement(fields[1:])
+			if err != nil {
+				return "", nil, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+			requirements = append(requirements, r)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", nil, err
+	}
+	if modulePath == "" {
+		return "", nil, fmt.Errorf("no module directive found")
+	}
+	return modulePath, requirements, nil
+}
+
+func parseRequirement(fields []string) (Module, error) {
+	if len(fields) != 2 {
+		return Module{}, fmt.Errorf("malformed requirement %q", strings.Join(fields, " "))
+	}
+	return Module{Path: strings.Trim(fields[0], `"`), Version: fields[1]}, nil
+}
+
+// ParseGoSum returns the module versions listed in a go.sum file
+func ParseGoSum(data []byte) ([]Module, error) {
+	var modules []Module
+	seen := map[Module]struct{}{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("line %d: malformed go.sum line %q", lineNumber, scanner.Text())
+		}
+		m := Module{Path: fields[0], Version: strings.TrimSuffix(fields[1], "/go.mod")}
+		if _, ok := seen[m]; !ok {
+			seen[m] = struct{}{}
+			modules = append(modules, m)
+		}
+	}
+	return modules, scanner.Err()
+}
@@ -0,0 +1,158 @@
+package gomod
+
+import (
+	"context"
+	"github.com/perdasilva/replee/pkg/deppy"
+	"github.com/perdasilva/replee/pkg/deppy/semver"
+	"github.com/perdasilva/replee/pkg/deppy/variables"
+)
+
+const (
+	VariableKindModule     = "deppy.variable.module"
+	VariableKindModulePath = "deppy.variable.module-path"
+)
+
+type Option func(*options)
+
+type options struct {
+	preferMinimal bool
+}
+
+// PreferMinimal orders the candidates of each requirement oldest first, rather than newest first,
+// so that the solver prefers the minimum versions that satisfy the requirements
+func PreferMinimal() Option {
+	return func(o *options) {
+		o.preferMinimal = true
+	}
+}
+
+// NewGraphVariableSource returns a variable source that activates, when offered the bootstrap (nil) variable:
+//   - a module variable for every module version in the graph, identified by path@version, with the package and
+//     version properties set to the module path and version. The main module's variable is mandatory.
+//   - a dependency constraint on a module variable for each of its requirements, listing the known versions of
+//     the required module that are at least the required version, newest first unless PreferMinimal is given
+//   - a module path variable for every module path other than the main module's, identified by path@*, allowing
+//     at most one version of the module to be selected
+func NewGraphVariableSource(variableSourceID deppy.Identifier, graph *Graph, opts ...Option) deppy.VariableSource {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return &graphVariableSource{
+		variableSourceID: variableSourceID,
+		graph:            graph,
+		options:          o,
+	}
+}
+
+// ModuleVariableID returns the identifier of the module version's variable
+func ModuleVariableID(m Module) deppy.Identifier {
+	return deppy.Identifier(m.String())
+}
+
+var _ deppy.VariableSource = &graphVariableSource{}
+
+type graphVariableSource struct {
+	variableSourceID deppy.Identifier
+	graph            *Graph
+	options          *options
+}
+
+func (g *graphVariableSource) VariableSourceID() deppy.Identifier {
+	return g.variableSourceID
+}
+
+func (g *graphVariableSource) VariableFilterFunc() deppy.VarFilterFn {
+	return nil
+}
+
+func (g *graphVariableSource) Update(_ context.Context, problem deppy.MutableResolutionProblem, variable deppy.MutableVariable) error {
+	if variable != nil {
+		return nil
+	}
+	vars, err := g.variables()
+	if err != nil {
+		return err
+	}
+	for _, v := range vars {
+		if err := problem.ActivateVariable(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *graphVariableSource) Finalize(_ context.Context, _ deppy.MutableResolutionProblem) error {
+	return nil
+}
+
+func (g *graphVariableSource) variables() ([]deppy.MutableVariable, error) {
+	modules := g.graph.Modules()
+	known := map[string][]string{}
+	for _, m := range modules {
+		known[m.Path] = append(known[m.Path], m.Version)
+	}
+
+	var vars []deppy.MutableVariable
+	versions := map[string][]deppy.Identifier{}
+	var paths []string
+	for _, m := range modules {
+		v := variables.NewMutableVariable(ModuleVariableID(m), VariableKindModule, map[string]interface{}{
+			semver.PackageProperty: m.Path,
+			semver.VersionProperty: m.Version,
+		})
+		if m == g.graph.Main {
+			if err := v.AddMandatory("main-module"); err != nil {
+				return nil, err
+			}
+		}
+		for _, r := range g.graph.Requirements[m] {
+			if err := v.AddDependency(deppy.Identifierf("requires/%s", r.Path), g.candidates(r, known[r.Path])...); err != nil {
+				return nil, err
+			}
+		}
+		vars = append(vars, v)
+
+		if m.Path == g.graph.Main.Path {
+			continue
+		}
+		if _, ok := versions[m.Path]; !ok {
+			paths = append(paths, m.Path)
+		}
+		versions[m.Path] = append(versions[m.Path], v.VariableID())
+	}
+
+	for _, path := range paths {
+		v := variables.NewMutableVariable(deppy.Identifierf("%s@*", path), VariableKindModulePath, map[string]interface{}{
+			semver.PackageProperty: path,
+		})
+		if err := v.AddAtMost("at-most-one-version", 1, g.preferred(versions[path])...); err != nil {
+			return nil, err
+		}
+		vars = append(vars, v)
+	}
+	return vars, nil
+}
+
+// candidates returns the known versions, listed oldest first, of the required module that are at least the required version
+func (g *graphVariableSource) candidates(requirement Module, known []string) []deppy.Identifier {
+	var ids []deppy.Identifier
+	for _, version := range known {
+		if compareVersions(version, requirement.Version) >= 0 {
+			ids = append(ids, ModuleVariableID(Module{Path: requirement.Path, Version: version}))
+		}
+	}
+	return g.preferred(ids)
+}
+
+// preferred turns identifiers listed oldest version first into the preferred order
+func (g *graphVariableSource) preferred(oldestFirst []deppy.Identifier) []deppy.Identifier {
+	if g.options.preferMinimal {
+		return oldestFirst
+	}
+	out := make([]deppy.Identifier, len(oldestFirst))
+	for i, id := range oldestFirst {
+		out[len(oldestFirst)-1-i] = id
+	}
+	return out
+}
@@ -0,0 +1,260 @@
+// Package lint statically validates resolution problems, catching mistakes the solver either can't handle, such as
+// constraints referring to variables that aren't part of the problem, or can only report as an unsatisfiable problem.
+package lint
+
+import (
+	"fmt"
+	"github.com/perdasilva/replee/pkg/deppy"
+	"github.com/perdasilva/replee/pkg/deppy/constraints"
+	"sort"
+	"strings"
+)
+
+type Severity string
+
+const (
+	// SeverityError issues keep the problem from being solved
+	SeverityError Severity = "error"
+	// SeverityWarning issues are likely mistakes, but the problem can still be solved
+	SeverityWarning Severity = "warning"
+)
+
+// Checks reported by Lint
+const (
+	CheckDanglingReference   = "dangling-reference"
+	CheckNegativeAtMost      = "negative-at-most"
+	CheckEmptyDependency     = "empty-dependency"
+	CheckSelfConflict        = "self-conflict"
+	CheckUnsatisfiableAnchor = "unsatisfiable-anchor"
+	CheckUnreachableVariable = "unreachable-variable"
+)
+
+// Issue is a problem found by Lint. ConstraintID is empty for issues about the variable as a whole.
+type Issue struct {
+	Severity     Severity         `json:"severity"`
+	Check        string           `json:"check"`
+	VariableID   deppy.Identifier `json:"variableID"`
+	ConstraintID deppy.Identifier `json:"constraintID,omitempty"`
+	Message      string           `json:"message"`
+}
+
+func (i Issue) String() string {
+	if i.ConstraintID == "" {
+		return fmt.Sprintf("%s: %s: %s [%s]", i.Severity, i.VariableID, i.Message, i.Check)
+	}
+	return fmt.Sprintf("%s: %s (%s): %s [%s]", i.Severity, i.VariableID, i.ConstraintID, i.Message, i.Check)
+}
+
+// Report lists the issues of a problem, errors first
+type Report []Issue
+
+func (r Report) String() string {
+	if len(r) == 0 {
+		return "no issues"
+	}
+	lines := make([]string, 0, len(r))
+	for _, issue := range r {
+		lines = append(lines, issue.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Errors returns the issues that keep the problem from being solved
+func (r Report) Errors() Report {
+	var errs Report
+	for _, issue := range r {
+		if issue.Severity == SeverityError {
+			errs = append(errs, issue)
+		}
+	}
+	return errs
+}
+
+// HasErrors returns true if the report has issues that keep the problem from being solved
+func (r Report) HasErrors() bool {
+	return len(r.Errors()) > 0
+}
+
+// Error is returned when a problem can't be solved because of lint errors
+type Error struct {
+	Report Report
+}
+
+func (e *Error) Error() string {
+	errs := e.Report.Errors()
+	return fmt.Sprintf("problem has %d lint error(s):\n%s", len(errs), errs)
+}
+
+// Lint checks the activated variables of the problem for:
+//   - constraints referring to variables that aren't part of the problem (error)
+//   - at most constraints with a negative limit (error)
+//   - dependencies without candidates, which keep the variable from ever being selected
+//   - variables conflicting with themselves, which keeps them from ever being selected
+//   - mandatory variables that are trivially unsatisfiable: prohibited, conflicting with another mandatory
+//     variable, or with a dependency whose candidates are all prohibited or missing
+//   - variables that no mandatory variable depends on, directly or not, and so are never selected
+func Lint(problem deppy.ResolutionProblem) (Report, error) {
+	vars, err := problem.GetVariables()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(vars, func(i, j int) bool {
+		return vars[i].VariableID() < vars[j].VariableID()
+	})
+
+	l := &linter{
+		variables:  map[deppy.Identifier]deppy.Variable{},
+		mandatory:  map[deppy.Identifier]bool{},
+		prohibited: map[deppy.Identifier]bool{},
+	}
+	for _, v := range vars {
+		l.variables[v.VariableID()] = v
+		for _, c := range v.Constraints() {
+			switch c.(type) {
+			case *constraints.MandatoryConstraint:
+				l.mandatory[v.VariableID()] = true
+			case *constraints.ProhibitedConstraint:
+				l.prohibited[v.VariableID()] = true
+			}
+		}
+	}
+	for _, v := range vars {
+		l.lintVariable(v)
+	}
+	l.lintReachability(vars)
+
+	sort.SliceStable(l.report, func(i, j int) bool {
+		return l.report[i].Severity == SeverityError && l.report[j].Severity != SeverityError
+	})
+	return l.report, nil
+}
+
+type linter struct {
+	variables  map[deppy.Identifier]deppy.Variable
+	mandatory  map[deppy.Identifier]bool
+	prohibited map[deppy.Identifier]bool
+	report     Report
+}
+
+func (l *linter) add(severity Severity, check string, variableID, constraintID deppy.Identifier, format string, args ...interface{}) {
+	l.report = append(l.report, Issue{
+		Severity:     severity,
+		Check:        check,
+		VariableID:   variableID,
+		ConstraintID: constraintID,
+		Message:      fmt.Sprintf(format, args...),
+	})
+}
+
+func (l *linter) lintVariable(v deppy.Variable) {
+	id := v.VariableID()
+	if l.mandatory[id] && l.prohibited[id] {
+		l.add(SeverityWarning, CheckUnsatisfiableAnchor, id, "", "variable is both mandatory and prohibited")
+	}
+
+	cs := v.Constraints()
+	sort.Slice(cs, func(i, j int) bool {
+		return cs[i].ConstraintID() < cs[j].ConstraintID()
+	})
+	for _, c := range cs {
+		constraintID := c.ConstraintID()
+		switch c := c.(type) {
+		case *constraints.ConflictConstraint:
+			other := c.ConflictingVariableID()
+			switch {
+			case !l.exists(other):
+				l.add(SeverityError, CheckDanglingReference, id, constraintID, "conflicts with %s, which is not part of the problem", other)
+			case other == id:
+				l.add(SeverityWarning, CheckSelfConflict, id, constraintID, "variable conflicts with itself and can never be selected")
+				if l.mandatory[id] {
+					l.add(SeverityWarning, CheckUnsatisfiableAnchor, id, constraintID, "mandatory variable conflicts with itself")
+				}
+			case l.mandatory[id] && l.mandatory[other]:
+				l.add(SeverityWarning, CheckUnsatisfiableAnchor, id, constraintID, "mandatory variable conflicts with mandatory variable %s", other)
+			}
+		case *constraints.DependencyConstraint:
+			elements := c.Elements()
+			for _, missing := range l.missing(elements) {
+				l.add(SeverityError, CheckDanglingReference, id, constraintID, "depends on %s, which is not part of the problem", missing)
+			}
+			if len(elements) == 0 {
+				l.add(SeverityWarning, CheckEmptyDependency, id, constraintID, "dependency has no candidates, the variable can never be selected")
+				if l.mandatory[id] {
+					l.add(SeverityWarning, CheckUnsatisfiableAnchor, id, constraintID, "mandatory variable has a dependency without candidates")
+				}
+				continue
+			}
+			if l.mandatory[id] && !l.anySelectable(elements) {
+				l.add(SeverityWarning, CheckUnsatisfiableAnchor, id, constraintID, "every candidate of the dependency of the mandatory variable is prohibited or missing")
+			}
+		case *constraints.AtMostConstraint:
+			for _, missing := range l.missing(c.Elements()) {
+				l.add(SeverityError, CheckDanglingReference, id, constraintID, "at most constraint refers to %s, which is not part of the problem", missing)
+			}
+			if c.N() < 0 {
+				l.add(SeverityError, CheckNegativeAtMost, id, constraintID, "at most constraint has a negative limit of %d", c.N())
+			}
+		}
+	}
+}
+
+// lintReachability reports the variables that can't be reached from a mandatory variable through dependencies.
+// Problems without mandatory variables are left alone, as nothing is ever selected.
+func (l *linter) lintReachability(vars []deppy.Variable) {
+	if len(l.mandatory) == 0 {
+		return
+	}
+	reached := map[deppy.Identifier]bool{}
+	var queue []deppy.Identifier
+	for _, v := range vars {
+		if l.mandatory[v.VariableID()] {
+			reached[v.VariableID()] = true
+			queue = append(queue, v.VariableID())
+		}
+	}
+	for len(queue) > 0 {
+		v := l.variables[queue[0]]
+		queue = queue[1:]
+		for _, c := range v.Constraints() {
+			dependency, ok := c.(*constraints.DependencyConstraint)
+			if !ok {
+				continue
+			}
+			for _, id := range dependency.Elements() {
+				if !reached[id] && l.exists(id) {
+					reached[id] = true
+					queue = append(queue, id)
+				}
+			}
+		}
+	}
+	for _, v := range vars {
+		if !reached[v.VariableID()] {
+			l.add(SeverityWarning, CheckUnreachableVariable, v.VariableID(), "", "no mandatory variable depends on it, it will never be selected")
+		}
+	}
+}
+
+func (l *linter) exists(id deppy.Identifier) bool {
+	_, ok := l.variables[id]
+	return ok
+}
+
+func (l *linter) missing(ids []deppy.Identifier) []deppy.Identifier {
+	var missing []deppy.Identifier
+	for _, id := range ids {
+		if !l.exists(id) {
+			missing = append(missing, id)
+		}
+	}
+	return missing
+}
+
+func (l *linter) anySelectable(ids []deppy.Identifier) bool {
+	for _, id := range ids {
+		if l.exists(id) && !l.prohibited[id] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,196 @@
+package lint_test
+
+import (
+	"context"
+	"errors"
+	"github.com/perdasilva/replee/pkg/deppy"
+	. "github.com/perdasilva/replee/pkg/deppy/lint"
+	"github.com/perdasilva/replee/pkg/deppy/resolution"
+	"github.com/perdasilva/replee/pkg/deppy/resolver"
+	"github.com/perdasilva/replee/pkg/deppy/variables"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func newProblem(t *testing.T, vars ...deppy.MutableVariable) *resolution.MutableResolutionProblem {
+	problem := resolution.NewMutableResolutionProblem("test")
+	for _, v := range vars {
+		assert.NoError(t, problem.ActivateVariable(v))
+	}
+	return problem
+}
+
+func newVariable(t *testing.T, id deppy.Identifier, add func(v deppy.MutableVariable) error) deppy.MutableVariable {
+	v := variables.NewMutableVariable(id, "deppy.var.test", nil)
+	if add != nil {
+		assert.NoError(t, add(v))
+	}
+	return v
+}
+
+func TestLint(t *testing.T) {
+	tt := []struct {
+		name     string
+		vars     func(t *testing.T) []deppy.MutableVariable
+		expected Report
+	}{
+		{
+			name: "clean problem",
+			vars: func(t *testing.T) []deppy.MutableVariable {
+				return []deppy.MutableVariable{
+					newVariable(t, "a", func(v deppy.MutableVariable) error {
+						if err := v.AddMandatory("mandatory"); err != nil {
+							return err
+						}
+						return v.AddDependency("dependency", "b")
+					}),
+					newVariable(t, "b", nil),
+				}
+			},
+		}, {
+			name: "dangling references",
+			vars: func(t *testing.T) []deppy.MutableVariable {
+				return []deppy.MutableVariable{
+					newVariable(t, "a", func(v deppy.MutableVariable) error {
+						if err := v.AddMandatory("mandatory"); err != nil {
+							return err
+						}
+						if err := v.AddConflict("conflict", "x"); err != nil {
+							return err
+						}
+						if err := v.AddAtMost("at-most", 1, "y"); err != nil {
+							return err
+						}
+						return v.AddDependency("dependency", "b", "z")
+					}),
+					newVariable(t, "b", nil),
+				}
+			},
+			expected: Report{
+				{Severity: SeverityError, Check: CheckDanglingReference, VariableID: "a", ConstraintID: "at-most", Message: "at most constraint refers to y, which is not part of the problem"},
+				{Severity: SeverityError, Check: CheckDanglingReference, VariableID: "a", ConstraintID: "conflict", Message: "conflicts with x, which is not part of the problem"},
+				{Severity: SeverityError, Check: CheckDanglingReference, VariableID: "a", ConstraintID: "dependency", Message: "depends on z, which is not part of the problem"},
+			},
+		}, {
+			name: "negative at most",
+			vars: func(t *testing.T) []deppy.MutableVariable {
+				return []deppy.MutableVariable{
+					newVariable(t, "a", func(v deppy.MutableVariable) error {
+						if err := v.AddMandatory("mandatory"); err != nil {
+							return err
+						}
+						return v.AddAtMost("at-most", -1, "a")
+					}),
+				}
+			},
+			expected: Report{
+				{Severity: SeverityError, Check: CheckNegativeAtMost, VariableID: "a", ConstraintID: "at-most", Message: "at most constraint has a negative limit of -1"},
+			},
+		}, {
+			name: "unsatisfiable anchors",
+			vars: func(t *testing.T) []deppy.MutableVariable {
+				return []deppy.MutableVariable{
+					newVariable(t, "a", func(v deppy.MutableVariable) error {
+						if err := v.AddMandatory("mandatory"); err != nil {
+							return err
+						}
+						if err := v.AddProhibited("prohibited"); err != nil {
+							return err
+						}
+						if err := v.AddConflict("self", "a"); err != nil {
+							return err
+						}
+						return v.AddDependency("empty")
+					}),
+					newVariable(t, "b", func(v deppy.MutableVariable) error {
+						if err := v.AddMandatory("mandatory"); err != nil {
+							return err
+						}
+						if err := v.AddConflict("conflict", "c"); err != nil {
+							return err
+						}
+						return v.AddDependency("dependency", "d")
+					}),
+					newVariable(t, "c", func(v deppy.MutableVariable) error {
+						return v.AddMandatory("mandatory")
+					}),
+					newVariable(t, "d", func(v deppy.MutableVariable) error {
+						return v.AddProhibited("prohibited")
+					}),
+				}
+			},
+			expected: Report{
+				{Severity: SeverityWarning, Check: CheckUnsatisfiableAnchor, VariableID: "a", Message: "variable is both mandatory and prohibited"},
+				{Severity: SeverityWarning, Check: CheckEmptyDependency, VariableID: "a", ConstraintID: "empty", Message: "dependency has no candidates, the variable can never be selected"},
+				{Severity: SeverityWarning, Check: CheckUnsatisfiableAnchor, VariableID: "a", ConstraintID: "empty", Message: "mandatory variable has a dependency without candidates"},
+				{Severity: SeverityWarning, Check: CheckSelfConflict, VariableID: "a", ConstraintID: "self", Message: "variable conflicts with itself and can never be selected"},
+				{Severity: SeverityWarning, Check: CheckUnsatisfiableAnchor, VariableID: "a", ConstraintID: "self", Message: "mandatory variable conflicts with itself"},
+				{Severity: SeverityWarning, Check: CheckUnsatisfiableAnchor, VariableID: "b", ConstraintID: "conflict", Message: "mandatory variable conflicts with mandatory variable c"},
+				{Severity: SeverityWarning, Check: CheckUnsatisfiableAnchor, VariableID: "b", ConstraintID: "dependency", Message: "every candidate of the dependency of the mandatory variable is prohibited or missing"},
+			},
+		}, {
+			name: "unreachable variables",
+			vars: func(t *testing.T) []deppy.MutableVariable {
+				return []deppy.MutableVariable{
+					newVariable(t, "a", func(v deppy.MutableVariable) error {
+						if err := v.AddMandatory("mandatory"); err != nil {
+							return err
+						}
+						return v.AddDependency("dependency", "b")
+					}),
+					newVariable(t, "b", func(v deppy.MutableVariable) error {
+						return v.AddDependency("dependency", "c")
+					}),
+					newVariable(t, "c", nil),
+					newVariable(t, "d", nil),
+				}
+			},
+			expected: Report{
+				{Severity: SeverityWarning, Check: CheckUnreachableVariable, VariableID: "d", Message: "no mandatory variable depends on it, it will never be selected"},
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			report, err := Lint(newProblem(t, tc.vars(t)...))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, report)
+		})
+	}
+}
+
+func TestLint_ErrorsFirst(t *testing.T) {
+	report, err := Lint(newProblem(t,
+		newVariable(t, "a", func(v deppy.MutableVariable) error {
+			if err := v.AddMandatory("mandatory"); err != nil {
+				return err
+			}
+			return v.AddConflict("self", "a")
+		}),
+		newVariable(t, "b", func(v deppy.MutableVariable) error {
+			return v.AddDependency("dependency", "missing")
+		}),
+	))
+	assert.NoError(t, err)
+	assert.True(t, report.HasErrors())
+	assert.Len(t, report.Errors(), 1)
+	assert.Equal(t, SeverityError, report[0].Severity)
+	assert.Equal(t, "error: b (dependency): depends on missing, which is not part of the problem [dangling-reference]", report[0].String())
+}
+
+func TestSolveLintsProblem(t *testing.T) {
+	problem := newProblem(t, newVariable(t, "a", func(v deppy.MutableVariable) error {
+		if err := v.AddMandatory("mandatory"); err != nil {
+			return err
+		}
+		return v.AddDependency("dependency", "missing")
+	}))
+
+	_, err := resolver.NewDeppyResolver().Solve(context.Background(), problem)
+	lintErr := &Error{}
+	assert.True(t, errors.As(err, &lintErr))
+	assert.EqualError(t, err, "problem has 1 lint error(s):\nerror: a (dependency): depends on missing, which is not part of the problem [dangling-reference]")
+
+	_, err = resolver.NewDeppyResolver().Solve(context.Background(), problem, resolver.DisableLint())
+	assert.ErrorContains(t, err, `variable "missing" referenced but not provided`)
+}
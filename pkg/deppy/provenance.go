@@ -0,0 +1,27 @@
+package deppy
+
+import "fmt"
+
+// Provenance records which VariableSource introduced a variable, property or
+// constraint while a resolution problem was being built. TriggerVariableID is
+// the variable passed to Update when the element was added; it is empty for the
+// bootstrap (nil variable) Update and for Finalize.
+type Provenance struct {
+	VariableSourceID  Identifier `json:"variableSourceID"`
+	TriggerVariableID Identifier `json:"triggerVariableID,omitempty"`
+}
+
+func (p Provenance) String() string {
+	if p.TriggerVariableID == "" {
+		return fmt.Sprintf("added by %s", p.VariableSourceID)
+	}
+	return fmt.Sprintf("added by %s while updating %s", p.VariableSourceID, p.TriggerVariableID)
+}
+
+// ProvenanceLookup is implemented by resolution problems that keep track of
+// the provenance of their variables, properties and constraints.
+type ProvenanceLookup interface {
+	VariableProvenance(variableID Identifier) (Provenance, bool)
+	PropertyProvenance(variableID Identifier, key string) (Provenance, bool)
+	ConstraintProvenance(variableID Identifier, constraintID Identifier) (Provenance, bool)
+}
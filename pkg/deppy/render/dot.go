@@ -0,0 +1,103 @@
+package render
+
+import (
+	"fmt"
+	"github.com/perdasilva/replee/pkg/deppy"
+	"strings"
+)
+
+// DOT renders the problem as a Graphviz digraph. Variables are boxes filled with the colour of their kind
+// (see the legend), mandatory variables have a double border and prohibited variables a dashed one.
+// Dependencies are arrows, through a junction point when there is more than one candidate, with the
+// candidates numbered in order of preference. Conflicts are red dashed lines and at most constraints are
+// dotted lines through a junction labelled with the limit. With a solution, selected variables have a
+// thick green border and unselected ones are faded, and the constraints of the conflict set are drawn in
+// thick red.
+func DOT(problem deppy.ResolutionProblem, opts ...Option) (string, error) {
+	g, err := newGraph(problem, opts...)
+	if err != nil {
+		return "", err
+	}
+
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "digraph %s {\n", dotQuote(string(problem.ResolutionProblemID())))
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box, style=\"rounded,filled\", fontname=\"Helvetica\"];\n")
+	b.WriteString("  edge [fontname=\"Helvetica\", fontsize=10];\n")
+
+	for _, n := range g.nodes {
+		fmt.Fprintf(b, "  %s [%s];\n", n.id, strings.Join(dotNodeAttributes(n), ", "))
+	}
+	for _, e := range g.edges {
+		fmt.Fprintf(b, "  %s -> %s [%s];\n", e.from, e.to, strings.Join(dotEdgeAttributes(e), ", "))
+	}
+
+	if len(g.kinds) > 0 {
+		b.WriteString("  subgraph cluster_legend {\n")
+		b.WriteString("    label=\"kinds\";\n")
+		for i, kind := range g.kinds {
+			fmt.Fprintf(b, "    k%d [label=%s, fillcolor=%s];\n", i, dotQuote(kind), dotQuote(kindColor(kind)))
+		}
+		b.WriteString("  }\n")
+	}
+	b.WriteString("}\n")
+	return b.String(), nil
+}
+
+func dotNodeAttributes(n *node) []string {
+	if n.junction {
+		return []string{"shape=circle", "style=solid", "width=0.3", "fixedsize=true", "fontsize=9", "label=" + dotQuote(n.label)}
+	}
+
+	attributes := []string{"label=" + dotQuote(n.nodeLabel())}
+	style := []string{"rounded", "filled"}
+	fill := n.color
+	switch {
+	case n.missing:
+		fill = "white"
+		style = append(style, "dashed")
+		attributes = append(attributes, "color="+dotQuote(colorConflict))
+	case n.selection == unselected:
+		fill = "white"
+		attributes = append(attributes, "fontcolor="+dotQuote(colorFaded), "color="+dotQuote(colorFaded))
+	}
+	if n.prohibited {
+		style = append(style, "dashed")
+	}
+	if n.mandatory {
+		attributes = append(attributes, "peripheries=2")
+	}
+	switch {
+	case n.conflict:
+		attributes = append(attributes, "color="+dotQuote(colorConflict), "penwidth=3")
+	case n.selection == selected:
+		attributes = append(attributes, "color="+dotQuote(colorSelected), "penwidth=3")
+	}
+	return append(attributes, "fillcolor="+dotQuote(fill), "style="+dotQuote(strings.Join(style, ",")))
+}
+
+func dotEdgeAttributes(e *edge) []string {
+	var attributes []string
+	if e.label != "" {
+		attributes = append(attributes, "label="+dotQuote(e.label))
+	}
+	switch e.kind {
+	case candidateEdge:
+		attributes = append(attributes, "style=dashed")
+	case conflictEdge:
+		attributes = append(attributes, "dir=none", "style=dashed", "color="+dotQuote(colorConflict))
+	case atMostEdge:
+		attributes = append(attributes, "dir=none", "style=dotted")
+	}
+	if e.conflict {
+		attributes = append(attributes, "color="+dotQuote(colorConflict), "penwidth=3")
+	}
+	return attributes
+}
+
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
@@ -0,0 +1,230 @@
+// Package render draws resolution problems, and their solutions, as Graphviz DOT and Mermaid graphs.
+package render
+
+import (
+	"fmt"
+	"github.com/perdasilva/replee/pkg/deppy"
+	"github.com/perdasilva/replee/pkg/deppy/constraints"
+	"github.com/perdasilva/replee/pkg/deppy/resolver"
+	"hash/fnv"
+	"sort"
+	"strings"
+)
+
+type Option func(*options)
+
+type options struct {
+	solution *resolver.Solution
+}
+
+// WithSolution highlights the variables the solution selected, or outlines the conflict set if the
+// problem isn't satisfiable
+func WithSolution(solution *resolver.Solution) Option {
+	return func(o *options) {
+		o.solution = solution
+	}
+}
+
+// palette holds the fill colours of variable kinds
+var palette = []string{
+	"#a6cee3", "#b2df8a", "#fdbf6f", "#cab2d6", "#ffff99", "#fb9a99", "#8dd3c7", "#bebada", "#80b1d3", "#fccde5",
+}
+
+const (
+	colorSelected = "#2e7d32"
+	colorConflict = "#d32f2f"
+	colorFaded    = "#9e9e9e"
+)
+
+type selection int
+
+const (
+	unknown selection = iota
+	selected
+	unselected
+)
+
+type edgeKind int
+
+const (
+	// dependency edges go from a variable to the one dependency it has, or to the junction of its dependencies
+	dependencyEdge edgeKind = iota
+	// candidate edges go from a dependency junction to a dependency, labelled with the order of preference
+	candidateEdge
+	conflictEdge
+	// atMostEdge edges go from a variable to the junction of its at most constraint, and from there to the elements
+	atMostEdge
+)
+
+type node struct {
+	id         string
+	label      string
+	color      string
+	junction   bool
+	missing    bool
+	mandatory  bool
+	prohibited bool
+	selection  selection
+	conflict   bool
+}
+
+type edge struct {
+	from, to string
+	kind     edgeKind
+	label    string
+	conflict bool
+}
+
+type graph struct {
+	nodes []*node
+	edges []*edge
+	kinds []string
+}
+
+// newGraph turns the problem into nodes and edges. Variables become nodes n0, n1, ..., in identifier order, and
+// identifiers constraints refer to that aren't in the problem become nodes marked as missing. Dependencies on more
+// than one variable and at most constraints become junction nodes j0, j1, ..., linked to the variables involved.
+func newGraph(problem deppy.ResolutionProblem, opts ...Option) (*graph, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	vars, err := problem.GetVariables()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(vars, func(i, j int) bool {
+		return vars[i].VariableID() < vars[j].VariableID()
+	})
+
+	g := &graph{}
+	ids := map[deppy.Identifier]*node{}
+	colors := map[string]string{}
+	for _, v := range vars {
+		if _, ok := colors[v.Kind()]; !ok {
+			colors[v.Kind()] = kindColor(v.Kind())
+			g.kinds = append(g.kinds, v.Kind())
+		}
+		n := &node{
+			id:    fmt.Sprintf("n%d", len(g.nodes)),
+			label: string(v.VariableID()),
+			color: colors[v.Kind()],
+		}
+		ids[v.VariableID()] = n
+		g.nodes = append(g.nodes, n)
+	}
+	sort.Strings(g.kinds)
+
+	nodeOf := func(id deppy.Identifier) *node {
+		if n, ok := ids[id]; ok {
+			return n
+		}
+		n := &node{
+			id:      fmt.Sprintf("n%d", len(g.nodes)),
+			label:   string(id),
+			missing: true,
+		}
+		ids[id] = n
+		g.nodes = append(g.nodes, n)
+		return n
+	}
+	junctions := 0
+	junction := func(label string) *node {
+		n := &node{
+			id:       fmt.Sprintf("j%d", junctions),
+			label:    label,
+			junction: true,
+		}
+		junctions++
+		g.nodes = append(g.nodes, n)
+		return n
+	}
+
+	conflictSet := map[deppy.Identifier]map[deppy.Identifier]struct{}{}
+	if o.solution != nil {
+		for _, applied := range o.solution.NotSatisfiable() {
+			if _, ok := conflictSet[applied.Variable.VariableID()]; !ok {
+				conflictSet[applied.Variable.VariableID()] = map[deppy.Identifier]struct{}{}
+			}
+			conflictSet[applied.Variable.VariableID()][applied.Constraint.ConstraintID()] = struct{}{}
+		}
+	}
+
+	for _, v := range vars {
+		subject := ids[v.VariableID()]
+		if o.solution != nil && len(o.solution.NotSatisfiable()) == 0 {
+			if o.solution.IsSelected(v.VariableID()) {
+				subject.selection = selected
+			} else {
+				subject.selection = unselected
+			}
+		}
+		if _, ok := conflictSet[v.VariableID()]; ok {
+			subject.conflict = true
+		}
+
+		cs := v.Constraints()
+		sort.Slice(cs, func(i, j int) bool {
+			return cs[i].ConstraintID() < cs[j].ConstraintID()
+		})
+		for _, c := range cs {
+			_, inConflict := conflictSet[v.VariableID()][c.ConstraintID()]
+			label := string(c.ConstraintID())
+			switch c := c.(type) {
+			case *constraints.MandatoryConstraint:
+				subject.mandatory = true
+			case *constraints.ProhibitedConstraint:
+				subject.prohibited = true
+			case *constraints.ConflictConstraint:
+				other := nodeOf(c.ConflictingVariableID())
+				g.edges = append(g.edges, &edge{from: subject.id, to: other.id, kind: conflictEdge, label: label, conflict: inConflict})
+			case *constraints.DependencyConstraint:
+				elements := c.Elements()
+				if len(elements) == 1 {
+					g.edges = append(g.edges, &edge{from: subject.id, to: nodeOf(elements[0]).id, kind: dependencyEdge, label: label, conflict: inConflict})
+					continue
+				}
+				j := junction("any")
+				if len(elements) == 0 {
+					j.label = "none"
+				}
+				g.edges = append(g.edges, &edge{from: subject.id, to: j.id, kind: dependencyEdge, label: label, conflict: inConflict})
+				for i, id := range elements {
+					g.edges = append(g.edges, &edge{from: j.id, to: nodeOf(id).id, kind: candidateEdge, label: fmt.Sprintf("%d", i+1), conflict: inConflict})
+				}
+			case *constraints.AtMostConstraint:
+				j := junction(fmt.Sprintf("≤%d", c.N()))
+				g.edges = append(g.edges, &edge{from: subject.id, to: j.id, kind: atMostEdge, label: label, conflict: inConflict})
+				for _, id := range c.Elements() {
+					g.edges = append(g.edges, &edge{from: j.id, to: nodeOf(id).id, kind: atMostEdge, conflict: inConflict})
+				}
+			}
+		}
+	}
+	return g, nil
+}
+
+// kindColor picks a palette colour for the kind, so that a kind has the same colour in every graph
+func kindColor(kind string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(kind))
+	return palette[h.Sum32()%uint32(len(palette))]
+}
+
+// nodeLabel returns the label of a variable node, with its mandatory or prohibited status
+func (n *node) nodeLabel() string {
+	var marks []string
+	if n.mandatory {
+		marks = append(marks, "mandatory")
+	}
+	if n.prohibited {
+		marks = append(marks, "prohibited")
+	}
+	if n.missing {
+		marks = append(marks, "missing")
+	}
+	if len(marks) == 0 {
+		return n.label
+	}
+	return fmt.Sprintf("%s\n(%s)", n.label, strings.Join(marks, ", "))
+}
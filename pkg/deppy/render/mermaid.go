@@ -0,0 +1,106 @@
+package render
+
+import (
+	"fmt"
+	"github.com/perdasilva/replee/pkg/deppy"
+	"strings"
+)
+
+// Mermaid renders the problem as a Mermaid flowchart, styled like DOT: variables are filled with the
+// colour of their kind, dependencies are arrows through a junction when there is more than one candidate,
+// conflicts are red dotted links and at most constraints are dotted links through a junction. With a
+// solution, selected variables have a thick green border, unselected ones are faded, and the conflict
+// set is drawn in thick red.
+func Mermaid(problem deppy.ResolutionProblem, opts ...Option) (string, error) {
+	g, err := newGraph(problem, opts...)
+	if err != nil {
+		return "", err
+	}
+
+	b := &strings.Builder{}
+	b.WriteString("flowchart LR\n")
+	classes := map[string]string{}
+	for i, kind := range g.kinds {
+		classes[kindColor(kind)] = fmt.Sprintf("kind%d", i)
+		fmt.Fprintf(b, "  classDef kind%d fill:%s\n", i, kindColor(kind))
+	}
+
+	for _, n := range g.nodes {
+		if n.junction {
+			fmt.Fprintf(b, "  %s((%s))\n", n.id, mermaidQuote(n.label))
+			continue
+		}
+		fmt.Fprintf(b, "  %s[%s]\n", n.id, mermaidQuote(n.nodeLabel()))
+		if class, ok := classes[n.color]; ok && !n.missing {
+			fmt.Fprintf(b, "  class %s %s\n", n.id, class)
+		}
+		if style := mermaidNodeStyle(n); style != "" {
+			fmt.Fprintf(b, "  style %s %s\n", n.id, style)
+		}
+	}
+
+	var linkStyles []string
+	for i, e := range g.edges {
+		label := ""
+		if e.label != "" {
+			label = "|" + mermaidQuote(e.label) + "|"
+		}
+		switch e.kind {
+		case dependencyEdge:
+			fmt.Fprintf(b, "  %s -->%s %s\n", e.from, label, e.to)
+		case candidateEdge:
+			fmt.Fprintf(b, "  %s -.->%s %s\n", e.from, label, e.to)
+		case conflictEdge, atMostEdge:
+			fmt.Fprintf(b, "  %s -.-%s %s\n", e.from, label, e.to)
+		}
+		switch {
+		case e.conflict:
+			linkStyles = append(linkStyles, fmt.Sprintf("  linkStyle %d stroke:%s,stroke-width:4px\n", i, colorConflict))
+		case e.kind == conflictEdge:
+			linkStyles = append(linkStyles, fmt.Sprintf("  linkStyle %d stroke:%s\n", i, colorConflict))
+		}
+	}
+	for _, style := range linkStyles {
+		b.WriteString(style)
+	}
+
+	if len(g.kinds) > 0 {
+		b.WriteString("  subgraph legend [kinds]\n")
+		for i, kind := range g.kinds {
+			fmt.Fprintf(b, "    k%d[%s]\n", i, mermaidQuote(kind))
+			fmt.Fprintf(b, "    class k%d kind%d\n", i, i)
+		}
+		b.WriteString("  end\n")
+	}
+	return b.String(), nil
+}
+
+func mermaidNodeStyle(n *node) string {
+	var style []string
+	switch {
+	case n.missing:
+		style = append(style, "fill:#fff", "stroke-dasharray:5 5", "stroke:"+colorConflict)
+	case n.selection == unselected:
+		style = append(style, "fill:#fff", "color:"+colorFaded, "stroke:"+colorFaded)
+	}
+	if n.prohibited {
+		style = append(style, "stroke-dasharray:5 5")
+	}
+	if n.mandatory {
+		style = append(style, "stroke-width:3px")
+	}
+	switch {
+	case n.conflict:
+		style = append(style, "stroke:"+colorConflict, "stroke-width:4px")
+	case n.selection == selected:
+		style = append(style, "stroke:"+colorSelected, "stroke-width:4px")
+	}
+	return strings.Join(style, ",")
+}
+
+// mermaidQuote quotes a label, using entity codes for the characters that would end it
+func mermaidQuote(s string) string {
+	s = strings.ReplaceAll(s, `"`, "#quot;")
+	s = strings.ReplaceAll(s, "\n", "<br/>")
+	return `"` + s + `"`
+}
@@ -0,0 +1,77 @@
+package render_test
+
+import (
+	"context"
+	"github.com/perdasilva/replee/pkg/deppy"
+	. "github.com/perdasilva/replee/pkg/deppy/render"
+	"github.com/perdasilva/replee/pkg/deppy/resolution"
+	"github.com/perdasilva/replee/pkg/deppy/resolver"
+	"github.com/perdasilva/replee/pkg/deppy/variables"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func testProblem(t *testing.T, prohibitC bool) *resolution.MutableResolutionProblem {
+	problem := resolution.NewMutableResolutionProblem("test")
+	a := variables.NewMutableVariable("a", "deppy.var.app", nil)
+	assert.NoError(t, a.AddMandatory("mandatory"))
+	assert.NoError(t, a.AddDependency("dependency", "b", "c"))
+	assert.NoError(t, a.AddAtMost("at-most", 1, "b", "c"))
+	b := variables.NewMutableVariable("b", "deppy.var.lib", nil)
+	assert.NoError(t, b.AddConflict("conflict", "a"))
+	c := variables.NewMutableVariable("c", "deppy.var.lib", nil)
+	if prohibitC {
+		assert.NoError(t, c.AddProhibited("prohibited"))
+	}
+	for _, v := range []deppy.MutableVariable{a, b, c} {
+		assert.NoError(t, problem.ActivateVariable(v))
+	}
+	return problem
+}
+
+func TestDOT(t *testing.T) {
+	problem := testProblem(t, false)
+	solution, err := resolver.NewDeppyResolver().Solve(context.Background(), problem)
+	assert.NoError(t, err)
+
+	dot, err := DOT(problem, WithSolution(solution))
+	assert.NoError(t, err)
+	assert.Contains(t, dot, `digraph "test" {`)
+	assert.Contains(t, dot, `n0 [label="a\n(mandatory)", peripheries=2, color="#2e7d32", penwidth=3`)
+	assert.Contains(t, dot, `n1 [label="b", fontcolor="#9e9e9e"`)
+	assert.Contains(t, dot, `n0 -> j1 [label="dependency"];`)
+	assert.Contains(t, dot, `j1 -> n1 [label="1", style=dashed];`)
+	assert.Contains(t, dot, `j1 -> n2 [label="2", style=dashed];`)
+	assert.Contains(t, dot, `j0 [shape=circle, style=solid, width=0.3, fixedsize=true, fontsize=9, label="≤1"];`)
+	assert.Contains(t, dot, `n1 -> n0 [label="conflict", dir=none, style=dashed, color="#d32f2f"];`)
+	assert.Contains(t, dot, `k0 [label="deppy.var.app"`)
+}
+
+func TestDOT_NotSatisfiable(t *testing.T) {
+	problem := testProblem(t, true)
+	solution, err := resolver.NewDeppyResolver().Solve(context.Background(), problem)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, solution.NotSatisfiable())
+
+	dot, err := DOT(problem, WithSolution(solution))
+	assert.NoError(t, err)
+	assert.Contains(t, dot, `n2 [label="c\n(prohibited)", color="#d32f2f", penwidth=3`)
+	assert.Contains(t, dot, `n1 -> n0 [label="conflict", dir=none, style=dashed, color="#d32f2f", color="#d32f2f", penwidth=3];`)
+}
+
+func TestMermaid(t *testing.T) {
+	problem := testProblem(t, false)
+	solution, err := resolver.NewDeppyResolver().Solve(context.Background(), problem)
+	assert.NoError(t, err)
+
+	mermaid, err := Mermaid(problem, WithSolution(solution))
+	assert.NoError(t, err)
+	assert.Contains(t, mermaid, "flowchart LR\n")
+	assert.Contains(t, mermaid, `  n0["a<br/>(mandatory)"]`)
+	assert.Contains(t, mermaid, "  style n0 stroke-width:3px,stroke:#2e7d32,stroke-width:4px\n")
+	assert.Contains(t, mermaid, `  n0 -->|"dependency"| j1`)
+	assert.Contains(t, mermaid, `  j1 -.->|"1"| n1`)
+	assert.Contains(t, mermaid, `  n0 -.-|"at-most"| j0`)
+	assert.Contains(t, mermaid, `  n1 -.-|"conflict"| n0`)
+	assert.Contains(t, mermaid, "  subgraph legend [kinds]\n")
+}
@@ -5,19 +5,50 @@ import (
   "fmt"
   "github.com/perdasilva/replee/pkg/deppy"
   s "github.com/perdasilva/replee/pkg/deppy/variable_sources"
+  "sort"
+  "time"
 )
 
 var _ deppy.MutableResolutionProblem = &resolutionProblemBuilder{}
 
 type ResolutionProblemBuilder interface {
   WithVariableSources(variableSources ...deppy.VariableSource) ResolutionProblemBuilder
+  WithBuildOptions(options ...BuildOption) ResolutionProblemBuilder
   Build(ctx context.Context) (deppy.ResolutionProblem, error)
+  Step(ctx context.Context) (*BuildStep, error)
+  Peek() deppy.Variable
+  Queue() []deppy.Variable
+  Problem() deppy.MutableResolutionProblem
 }
 
 type resolutionProblemBuilder struct {
   MutableResolutionProblem
   variableSources map[deppy.Identifier]deppy.VariableSource
   variableQueue   []deppy.MutableVariable
+  // attribution is the provenance given to anything added to the problem
+  // it is only set while a variable source is being run by Step
+  attribution *deppy.Provenance
+  options     *buildOptions
+  // budget tracks resource usage against options, it is only set while a build is in progress
+  budget *buildBudget
+  // currentStep and sourceStep record what the running variable source adds to the problem
+  currentStep *BuildStep
+  sourceStep  *SourceStep
+  // touched are the variables the running variable source got hold of, whose changes are
+  // attributed to it once it returns
+  touched []deppy.Variable
+}
+
+// buildBudget only counts the time spent inside Step, so that time spent between
+// steps while debugging does not count against the build time budget
+type buildBudget struct {
+  spent       time.Duration
+  stepStart   time.Time
+  sourceStart time.Time
+  iterations  int
+  enqueued    map[deppy.Identifier]int
+  elapsed     map[deppy.Identifier]time.Duration
+  err         error
 }
 
 func (b *resolutionProblemBuilder) ActivateVariable(v deppy.MutableVariable) error {
@@ -34,11 +65,65 @@ func (b *resolutionProblemBuilder) ActivateVariable(v deppy.MutableVariable) err
   if err != nil {
     return err
   }
+  b.recordProvenance(oldVar)
 
   if changed {
     b.variableQueue = append(b.variableQueue, v)
+    if b.attribution != nil && b.budget != nil {
+      b.budget.enqueued[b.attribution.VariableSourceID]++
+    }
+  }
+  return b.checkBudget()
+}
+
+func (b *resolutionProblemBuilder) DeactivateVariable(variableID deppy.Identifier, kind string) error {
+  if err := b.MutableResolutionProblem.DeactivateVariable(variableID, kind); err != nil {
+    return err
+  }
+  if v, ok := b.variables.GetValue(variableID); ok {
+    b.recordProvenance(v)
+  }
+  return b.checkBudget()
+}
+
+func (b *resolutionProblemBuilder) GetMutableVariable(variableID deppy.Identifier, kind string) (deppy.MutableVariable, error) {
+  v, err := b.MutableResolutionProblem.GetMutableVariable(variableID, kind)
+  if err != nil {
+    return nil, err
+  }
+  // the source changes the variable after getting it, so its provenance is only recorded once the source returns
+  b.touch(v)
+  if err := b.checkBudget(); err != nil {
+    return nil, err
+  }
+  return v, nil
+}
+
+func (b *resolutionProblemBuilder) attribute(variableSourceID deppy.Identifier, trigger deppy.Variable) {
+  b.attribution = &deppy.Provenance{
+    VariableSourceID: variableSourceID,
+  }
+  if trigger != nil {
+    b.attribution.TriggerVariableID = trigger.VariableID()
+  }
+}
+
+// touch notes that the running variable source may change the variable
+func (b *resolutionProblemBuilder) touch(v deppy.Variable) {
+  if b.attribution == nil || v == nil {
+    return
+  }
+  b.touched = append(b.touched, v)
+}
+
+func (b *resolutionProblemBuilder) recordProvenance(v deppy.Variable) {
+  if b.attribution == nil {
+    return
+  }
+  added := b.provenance.record(v, *b.attribution)
+  if b.sourceStep != nil {
+    b.sourceStep.Added = append(b.sourceStep.Added, added...)
   }
-  return nil
 }
 
 func NewResolutionProblemBuilder(problemID deppy.Identifier) ResolutionProblemBuilder {
@@ -46,6 +131,7 @@ func NewResolutionProblemBuilder(problemID deppy.Identifier) ResolutionProblemBu
     MutableResolutionProblem: *NewMutableResolutionProblem(problemID),
     variableSources:          map[deppy.Identifier]deppy.VariableSource{},
     variableQueue:            []deppy.MutableVariable{},
+    options:                  defaultBuildOptions(),
   }
 }
 
@@ -61,37 +147,250 @@ func (b *resolutionProblemBuilder) WithVariableSources(variableSources ...deppy.
   return b
 }
 
+func (b *resolutionProblemBuilder) WithBuildOptions(options ...BuildOption) ResolutionProblemBuilder {
+  b.options.apply(options...)
+  return b
+}
+
 func (b *resolutionProblemBuilder) Build(ctx context.Context) (deppy.ResolutionProblem, error) {
+  for {
+    // a canceled build is abandoned, the next one starts over
+    if err := ctx.Err(); err != nil {
+      b.finish()
+      return nil, fmt.Errorf("build canceled: %w", err)
+    }
+    step, err := b.Step(ctx)
+    if err != nil {
+      return nil, err
+    }
+    if step.Done {
+      return &b.MutableResolutionProblem, nil
+    }
+  }
+}
+
+// Step processes the next item of the build queue with every variable source and reports what
+// each source added to the problem. The first Step after construction, a completed build, or a fatal
+// error starts a new build with the bootstrap (nil) variable. Build picks up where Step left off.
+func (b *resolutionProblemBuilder) Step(ctx context.Context) (*BuildStep, error) {
+  if b.budget == nil {
+    b.start()
+  }
+
+  b.budget.stepStart = time.Now()
+  if b.options.maxBuildTime > 0 {
+    var cancel context.CancelFunc
+    ctx, cancel = context.WithTimeout(ctx, b.options.maxBuildTime-b.budget.spent)
+    defer cancel()
+  }
+
+  step, err := b.step(ctx)
+  if err != nil || step.Done {
+    b.finish()
+    return step, err
+  }
+  b.budget.spent += time.Since(b.budget.stepStart)
+  b.attribution = nil
+  return step, nil
+}
+
+// Peek returns the variable the next Step will process. It returns nil when the next Step is
+// the bootstrap step.
+func (b *resolutionProblemBuilder) Peek() deppy.Variable {
+  if b.budget == nil || len(b.variableQueue) == 0 {
+    return nil
+  }
+  if b.variableQueue[0] == nil {
+    return nil
+  }
+  return b.variableQueue[0]
+}
+
+// Queue returns the variables waiting to be processed, in order. A nil entry
+// stands for the bootstrap step.
+func (b *resolutionProblemBuilder) Queue() []deppy.Variable {
+  if b.budget == nil {
+    return []deppy.Variable{nil}
+  }
+  queue := make([]deppy.Variable, len(b.variableQueue))
+  for i, v := range b.variableQueue {
+    if v != nil {
+      queue[i] = v
+    }
+  }
+  return queue
+}
+
+// Problem returns the problem as it has been built so far
+func (b *resolutionProblemBuilder) Problem() deppy.MutableResolutionProblem {
+  return &b.MutableResolutionProblem
+}
+
+func (b *resolutionProblemBuilder) start() {
   // nil variable signals to variable sources that only create variables to start creating
   b.variableQueue = []deppy.MutableVariable{nil}
+  b.budget = &buildBudget{
+    enqueued: map[deppy.Identifier]int{},
+    elapsed:  map[deppy.Identifier]time.Duration{},
+  }
+}
 
+func (b *resolutionProblemBuilder) finish() {
+  b.variableQueue = []deppy.MutableVariable{}
+  b.attribution = nil
+  b.budget = nil
+  b.currentStep = nil
+}
+
+func (b *resolutionProblemBuilder) step(ctx context.Context) (*BuildStep, error) {
   var curVar deppy.MutableVariable
-  for len(b.variableQueue) > 0 {
-    curVar, b.variableQueue = b.variableQueue[0], b.variableQueue[1:]
-    for _, source := range b.variableSources {
-      err := source.Update(ctx, b, curVar)
+  curVar, b.variableQueue = b.variableQueue[0], b.variableQueue[1:]
+  b.budget.iterations++
+  b.currentStep = &BuildStep{
+    Iteration: b.budget.iterations,
+  }
+  if curVar != nil {
+    b.currentStep.Variable = curVar
+  }
+  if b.options.maxQueueIterations > 0 && b.budget.iterations > b.options.maxQueueIterations {
+    return b.currentStep, b.queueBudgetError()
+  }
+
+  for _, sourceID := range b.variableSourceIDs() {
+    source := b.variableSources[sourceID]
+    err := b.run(ctx, sourceID, curVar, false, func(ctx context.Context) error {
+      return source.Update(ctx, b, curVar)
+    })
+    // other errors are recorded in the step of the source, the other sources still run
+    if deppy.IsFatalError(err) {
+      return b.currentStep, err
+    }
+    // todo: this can probably be improved
+    if err := b.ActivateVariable(curVar); err != nil {
+      return b.currentStep, err
+    }
+
+    if len(b.variableQueue) == 0 {
+      err := b.run(ctx, sourceID, nil, true, func(ctx context.Context) error {
+        return source.Finalize(ctx, b)
+      })
       if deppy.IsFatalError(err) {
-        return nil, err
-      }
-      if err != nil {
-        fmt.Printf("DEBUG: %v\n", err)
-      }
-      // todo: this can probably be improved
-      if err := b.ActivateVariable(curVar); err != nil {
-        return nil, err
+        return b.currentStep, err
       }
+    }
+  }
 
-      if len(b.variableQueue) == 0 {
-        err := source.Finalize(ctx, b)
-        if deppy.IsFatalError(err) {
-          return nil, err
-        }
-        if err != nil {
-          fmt.Printf("DEBUG: %v\n", err)
-        }
-      }
+  for _, v := range b.variableQueue {
+    if v != nil {
+      b.currentStep.Queued = append(b.currentStep.Queued, v.VariableID())
     }
   }
+  b.currentStep.Done = len(b.variableQueue) == 0
+  return b.currentStep, nil
+}
+
+// variableSourceIDs returns the ids of the variable sources in a stable order
+func (b *resolutionProblemBuilder) variableSourceIDs() []deppy.Identifier {
+  sourceIDs := make([]deppy.Identifier, 0, len(b.variableSources))
+  for sourceID := range b.variableSources {
+    sourceIDs = append(sourceIDs, sourceID)
+  }
+  sort.Slice(sourceIDs, func(i, j int) bool {
+    return sourceIDs[i] < sourceIDs[j]
+  })
+  return sourceIDs
+}
+
+// run calls fn on behalf of the variable source, attributing anything it adds to the problem to the source
+// and enforcing the per-source and build budgets. Budget errors take precedence over whatever fn returns,
+// since sources (e.g. JS callbacks) may not propagate the errors returned to them.
+func (b *resolutionProblemBuilder) run(ctx context.Context, sourceID deppy.Identifier, trigger deppy.Variable, finalize bool, fn func(ctx context.Context) error) error {
+  b.attribute(sourceID, trigger)
+  b.sourceStep = &SourceStep{
+    VariableSourceID: sourceID,
+    Finalize:         finalize,
+  }
+  b.budget.sourceStart = time.Now()
+  if b.options.maxSourceTime > 0 {
+    var cancel context.CancelFunc
+    ctx, cancel = context.WithTimeout(ctx, b.options.maxSourceTime)
+    defer cancel()
+  }
+  b.touched = nil
+  b.touch(trigger)
+  err := fn(ctx)
+  // whatever the source added to the variables it touched has no provenance yet, so it is credited to the source
+  for _, v := range b.touched {
+    b.recordProvenance(v)
+  }
+  b.touched = nil
+  budgetErr := b.checkBudget()
+  b.budget.elapsed[sourceID] += time.Since(b.budget.sourceStart)
+  if budgetErr != nil {
+    err = budgetErr
+  }
+  if err != nil {
+    b.sourceStep.Error = err.Error()
+  }
+  if len(b.sourceStep.Added) > 0 || err != nil {
+    b.currentStep.Sources = append(b.currentStep.Sources, b.sourceStep)
+  }
+  return err
+}
+
+// checkBudget returns a fatal error naming the running variable source if it has exhausted any of the budgets
+func (b *resolutionProblemBuilder) checkBudget() error {
+  if b.budget == nil || b.attribution == nil {
+    return nil
+  }
+  if b.budget.err != nil {
+    return b.budget.err
+  }
+  sourceID := b.attribution.VariableSourceID
+  switch {
+  case b.options.maxVariables > 0 && b.variables.Len() > b.options.maxVariables:
+    b.budget.err = deppy.Fatalf("variable source %s exceeded the limit of %d variables", sourceID, b.options.maxVariables)
+  case b.options.maxSourceTime > 0 && time.Since(b.budget.sourceStart) > b.options.maxSourceTime:
+    b.budget.err = deppy.Fatalf("variable source %s exceeded the per-source time limit of %s", sourceID, b.options.maxSourceTime)
+  case b.options.maxBuildTime > 0 && b.budget.spent+time.Since(b.budget.stepStart) > b.options.maxBuildTime:
+    b.budget.err = deppy.Fatalf("variable source %s exceeded the build time limit of %s", b.slowestSource(), b.options.maxBuildTime)
+  }
+  return b.budget.err
+}
+
+// slowestSource returns the variable source that has taken the most time during the build, including the running one
+func (b *resolutionProblemBuilder) slowestSource() deppy.Identifier {
+  elapsed := map[deppy.Identifier]time.Duration{}
+  for sourceID, d := range b.budget.elapsed {
+    elapsed[sourceID] = d
+  }
+  elapsed[b.attribution.VariableSourceID] += time.Since(b.budget.sourceStart)
+  return topSource(elapsed)
+}
 
-  return &b.MutableResolutionProblem, nil
+// queueBudgetError names the variable source that queued the most variables as the offender
+func (b *resolutionProblemBuilder) queueBudgetError() error {
+  if len(b.budget.enqueued) == 0 {
+    return deppy.Fatalf("exceeded the limit of %d queue iterations", b.options.maxQueueIterations)
+  }
+  sourceID := topSource(b.budget.enqueued)
+  return deppy.Fatalf("variable source %s exceeded the limit of %d queue iterations (queued %d variables)", sourceID, b.options.maxQueueIterations, b.budget.enqueued[sourceID])
+}
+
+// topSource returns the variable source with the highest usage, ties are broken by source id
+func topSource[T int | time.Duration](usage map[deppy.Identifier]T) deppy.Identifier {
+  var sourceIDs []deppy.Identifier
+  for sourceID := range usage {
+    sourceIDs = append(sourceIDs, sourceID)
+  }
+  sort.Slice(sourceIDs, func(i, j int) bool {
+    if usage[sourceIDs[i]] != usage[sourceIDs[j]] {
+      return usage[sourceIDs[i]] > usage[sourceIDs[j]]
+    }
+    return sourceIDs[i] < sourceIDs[j]
+  })
+  if len(sourceIDs) == 0 {
+    return ""
+  }
+  return sourceIDs[0]
 }
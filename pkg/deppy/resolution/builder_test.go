@@ -0,0 +1,282 @@
+package resolution_test
+
+import (
+	"context"
+	"errors"
+	"github.com/perdasilva/replee/pkg/deppy"
+	"github.com/perdasilva/replee/pkg/deppy/resolution"
+	"github.com/perdasilva/replee/pkg/deppy/resolver"
+	"github.com/perdasilva/replee/pkg/deppy/variable_sources"
+	"github.com/perdasilva/replee/pkg/deppy/variables"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestResolutionProblemBuilder_Provenance(t *testing.T) {
+	ctx := context.Background()
+	bootstrap := variable_sources.NewVariableSourceBuilder("bootstrap").
+		WithUpdateFn(func(ctx context.Context, problem deppy.MutableResolutionProblem, variable deppy.MutableVariable) error {
+			v := variables.NewMutableVariable("a", "deppy.var.test", map[string]interface{}{"key": "value"})
+			if err := v.AddMandatory("mandatory"); err != nil {
+				return err
+			}
+			return problem.ActivateVariable(v)
+		}).Build(ctx)
+	prohibitor := variable_sources.NewVariableSourceBuilder("prohibitor").
+		WithVariableFilterFn(func(v deppy.Variable) bool {
+			return v != nil && v.VariableID() == "a"
+		}).
+		WithUpdateFn(func(ctx context.Context, problem deppy.MutableResolutionProblem, variable deppy.MutableVariable) error {
+			return variable.AddProhibited("prohibited")
+		}).Build(ctx)
+
+	problem, err := resolution.NewResolutionProblemBuilder("test").
+		WithVariableSources(bootstrap, prohibitor).
+		Build(ctx)
+	assert.NoError(t, err)
+
+	lookup, ok := problem.(deppy.ProvenanceLookup)
+	assert.True(t, ok)
+
+	p, ok := lookup.VariableProvenance("a")
+	assert.True(t, ok)
+	assert.Equal(t, deppy.Provenance{VariableSourceID: "bootstrap"}, p)
+
+	p, ok = lookup.PropertyProvenance("a", "key")
+	assert.True(t, ok)
+	assert.Equal(t, deppy.Provenance{VariableSourceID: "bootstrap"}, p)
+
+	p, ok = lookup.ConstraintProvenance("a", "mandatory")
+	assert.True(t, ok)
+	assert.Equal(t, deppy.Provenance{VariableSourceID: "bootstrap"}, p)
+
+	p, ok = lookup.ConstraintProvenance("a", "prohibited")
+	assert.True(t, ok)
+	assert.Equal(t, deppy.Provenance{VariableSourceID: "prohibitor", TriggerVariableID: "a"}, p)
+
+	_, ok = lookup.ConstraintProvenance("a", "unknown")
+	assert.False(t, ok)
+
+	solution, err := resolver.NewDeppyResolver().Solve(ctx, problem)
+	assert.NoError(t, err)
+	assert.Len(t, solution.NotSatisfiable(), 2)
+	for _, appliedConstraint := range solution.NotSatisfiable() {
+		assert.NotNil(t, appliedConstraint.Provenance)
+	}
+	assert.Contains(t, solution.NotSatisfiable().Error(), "added by prohibitor while updating a")
+}
+
+func TestResolutionProblemBuilder_ProvenanceOfMutableVariables(t *testing.T) {
+	ctx := context.Background()
+	bootstrap := variable_sources.NewVariableSourceBuilder("bootstrap").
+		WithUpdateFn(func(ctx context.Context, problem deppy.MutableResolutionProblem, variable deppy.MutableVariable) error {
+			if err := problem.ActivateVariable(variables.NewMutableVariable("a", "deppy.var.test", map[string]interface{}{"n": 1})); err != nil {
+				return err
+			}
+			return problem.ActivateVariable(variables.NewMutableVariable("b", "deppy.var.test", map[string]interface{}{"n": 2}))
+		}).Build(ctx)
+	onB := func(v deppy.Variable) bool {
+		return v != nil && v.VariableID() == "b"
+	}
+	// both sources change a while updating b, a-src runs first
+	aSrc := variable_sources.NewVariableSourceBuilder("a-src").
+		WithVariableFilterFn(onB).
+		WithUpdateFn(func(ctx context.Context, problem deppy.MutableResolutionProblem, variable deppy.MutableVariable) error {
+			a, err := problem.GetMutableVariable("a", "deppy.var.test")
+			if err != nil {
+				return err
+			}
+			return a.AddProhibited("prohibited")
+		}).Build(ctx)
+	bSrc := variable_sources.NewVariableSourceBuilder("b-src").
+		WithVariableFilterFn(onB).
+		WithUpdateFn(func(ctx context.Context, problem deppy.MutableResolutionProblem, variable deppy.MutableVariable) error {
+			a, err := problem.GetMutableVariable("a", "deppy.var.test")
+			if err != nil {
+				return err
+			}
+			return a.SetProperty("key", "value")
+		}).Build(ctx)
+
+	problem, err := resolution.NewResolutionProblemBuilder("test").
+		WithVariableSources(bootstrap, aSrc, bSrc).
+		Build(ctx)
+	assert.NoError(t, err)
+
+	lookup, ok := problem.(deppy.ProvenanceLookup)
+	assert.True(t, ok)
+
+	p, ok := lookup.ConstraintProvenance("a", "prohibited")
+	assert.True(t, ok)
+	assert.Equal(t, deppy.Provenance{VariableSourceID: "a-src", TriggerVariableID: "b"}, p)
+
+	p, ok = lookup.PropertyProvenance("a", "key")
+	assert.True(t, ok)
+	assert.Equal(t, deppy.Provenance{VariableSourceID: "b-src", TriggerVariableID: "b"}, p)
+}
+
+func TestResolutionProblemBuilder_BuildOptions(t *testing.T) {
+	ctx := context.Background()
+	runaway := func() deppy.VariableSource {
+		n := 0
+		return variable_sources.NewVariableSourceBuilder("runaway").
+			WithVariableFilterFn(func(v deppy.Variable) bool {
+				return true
+			}).
+			WithUpdateFn(func(ctx context.Context, problem deppy.MutableResolutionProblem, variable deppy.MutableVariable) error {
+				n++
+				return problem.ActivateVariable(variables.NewMutableVariable(deppy.Identifierf("v%d", n), "deppy.var.test", map[string]interface{}{"n": n}))
+			}).Build(ctx)
+	}
+	bounded := variable_sources.NewVariableSourceBuilder("bounded").
+		WithUpdateFn(func(ctx context.Context, problem deppy.MutableResolutionProblem, variable deppy.MutableVariable) error {
+			return problem.ActivateVariable(variables.NewMutableVariable("a", "deppy.var.test", nil))
+		}).Build(ctx)
+
+	tt := []struct {
+		name          string
+		options       []resolution.BuildOption
+		expectedError string
+	}{
+		{
+			name:          "max variables",
+			options:       []resolution.BuildOption{resolution.MaxVariables(10)},
+			expectedError: "variable source runaway exceeded the limit of 10 variables",
+		}, {
+			name:          "max queue iterations",
+			options:       []resolution.BuildOption{resolution.MaxQueueIterations(10)},
+			expectedError: "variable source runaway exceeded the limit of 10 queue iterations (queued 10 variables)",
+		}, {
+			name:          "max build time",
+			options:       []resolution.BuildOption{resolution.MaxBuildTime(10 * time.Millisecond)},
+			expectedError: "variable source runaway exceeded the build time limit of 10ms",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := resolution.NewResolutionProblemBuilder("test").
+				WithVariableSources(runaway(), bounded).
+				WithBuildOptions(tc.options...).
+				Build(ctx)
+			assert.True(t, deppy.IsFatalError(err))
+			assert.EqualError(t, err, tc.expectedError)
+		})
+	}
+}
+
+func TestResolutionProblemBuilder_MaxSourceTime(t *testing.T) {
+	ctx := context.Background()
+	slow := variable_sources.NewVariableSourceBuilder("slow").
+		WithUpdateFn(func(ctx context.Context, problem deppy.MutableResolutionProblem, variable deppy.MutableVariable) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}).Build(ctx)
+
+	_, err := resolution.NewResolutionProblemBuilder("test").
+		WithVariableSources(slow).
+		WithBuildOptions(resolution.MaxSourceTime(10 * time.Millisecond)).
+		Build(ctx)
+	assert.EqualError(t, err, "variable source slow exceeded the per-source time limit of 10ms")
+}
+
+func TestResolutionProblemBuilder_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	n := 0
+	// the source ignores the context, the build stops between steps all the same
+	runaway := variable_sources.NewVariableSourceBuilder("runaway").
+		WithVariableFilterFn(func(v deppy.Variable) bool {
+			return true
+		}).
+		WithUpdateFn(func(ctx context.Context, problem deppy.MutableResolutionProblem, variable deppy.MutableVariable) error {
+			n++
+			if n == 5 {
+				cancel()
+			}
+			return problem.ActivateVariable(variables.NewMutableVariable(deppy.Identifierf("v%d", n), "deppy.var.test", map[string]interface{}{"n": n}))
+		}).Build(ctx)
+
+	_, err := resolution.NewResolutionProblemBuilder("test").
+		WithVariableSources(runaway).
+		Build(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 5, n)
+}
+
+func TestResolutionProblemBuilder_Step(t *testing.T) {
+	ctx := context.Background()
+	bootstrap := variable_sources.NewVariableSourceBuilder("bootstrap").
+		WithUpdateFn(func(ctx context.Context, problem deppy.MutableResolutionProblem, variable deppy.MutableVariable) error {
+			v := variables.NewMutableVariable("a", "deppy.var.test", nil)
+			if err := v.AddDependency("dependency", "b"); err != nil {
+				return err
+			}
+			return problem.ActivateVariable(v)
+		}).Build(ctx)
+	dependencies := variable_sources.NewVariableSourceBuilder("dependencies").
+		WithVariableFilterFn(func(v deppy.Variable) bool {
+			return v != nil && v.VariableID() == "a"
+		}).
+		WithUpdateFn(func(ctx context.Context, problem deppy.MutableResolutionProblem, variable deppy.MutableVariable) error {
+			return problem.ActivateVariable(variables.NewMutableVariable("b", "deppy.var.test", map[string]interface{}{"key": "value"}))
+		}).Build(ctx)
+
+	builder := resolution.NewResolutionProblemBuilder("test").WithVariableSources(bootstrap, dependencies)
+	assert.Nil(t, builder.Peek())
+	assert.Equal(t, []deppy.Variable{nil}, builder.Queue())
+
+	step, err := builder.Step(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, step.Iteration)
+	assert.Nil(t, step.Variable)
+	assert.False(t, step.Done)
+	assert.Equal(t, []*resolution.SourceStep{{
+		VariableSourceID: "bootstrap",
+		Added: []resolution.Addition{
+			{VariableID: "a"},
+			{VariableID: "a", ConstraintID: "dependency"},
+		},
+	}}, step.Sources)
+	assert.Equal(t, []deppy.Identifier{"a"}, step.Queued)
+	assert.Equal(t, deppy.Identifier("a"), builder.Peek().VariableID())
+
+	step, err = builder.Step(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, deppy.Identifier("a"), step.Variable.VariableID())
+	assert.Equal(t, []*resolution.SourceStep{{
+		VariableSourceID: "dependencies",
+		Added: []resolution.Addition{
+			{VariableID: "b"},
+			{VariableID: "b", Property: "key"},
+		},
+	}}, step.Sources)
+
+	problem, err := builder.Build(ctx)
+	assert.NoError(t, err)
+	vars, err := problem.GetVariables()
+	assert.NoError(t, err)
+	assert.Len(t, vars, 2)
+	assert.Nil(t, builder.Peek())
+}
+
+func TestResolutionProblemBuilder_StepError(t *testing.T) {
+	ctx := context.Background()
+	failing := variable_sources.NewVariableSourceBuilder("a-failing").
+		WithUpdateFn(func(ctx context.Context, problem deppy.MutableResolutionProblem, variable deppy.MutableVariable) error {
+			return errors.New("failed")
+		}).Build(ctx)
+	bootstrap := variable_sources.NewVariableSourceBuilder("b-bootstrap").
+		WithUpdateFn(func(ctx context.Context, problem deppy.MutableResolutionProblem, variable deppy.MutableVariable) error {
+			return problem.ActivateVariable(variables.NewMutableVariable("a", "deppy.var.test", map[string]interface{}{"key": "value"}))
+		}).Build(ctx)
+
+	// the error of a source is recorded in its step, and the sources after it still run
+	step, err := resolution.NewResolutionProblemBuilder("test").WithVariableSources(failing, bootstrap).Step(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []*resolution.SourceStep{
+		{VariableSourceID: "a-failing", Error: "failed"},
+		{VariableSourceID: "b-bootstrap", Added: []resolution.Addition{{VariableID: "a"}, {VariableID: "a", Property: "key"}}},
+	}, step.Sources)
+}
@@ -0,0 +1,272 @@
+package resolution
+
+import (
+  "fmt"
+  "github.com/perdasilva/replee/pkg/deppy"
+  "github.com/perdasilva/replee/pkg/deppy/constraints"
+  "path"
+  "sort"
+  "strings"
+  "text/tabwriter"
+)
+
+// Query selects variables of a problem. Empty fields match any variable: Kind is matched exactly,
+// ID is a glob pattern (see path.Match) and Property is either a property key the variable must have,
+// or key=value to also match the value of the property.
+type Query struct {
+  Kind     string `json:"kind"`
+  ID       string `json:"id"`
+  Property string `json:"property"`
+}
+
+func (q Query) matches(v deppy.Variable) (bool, error) {
+  if q.Kind != "" && v.Kind() != q.Kind {
+    return false, nil
+  }
+  if q.ID != "" {
+    if ok, err := path.Match(q.ID, string(v.VariableID())); err != nil || !ok {
+      return false, err
+    }
+  }
+  if q.Property != "" {
+    key, value, hasValue := strings.Cut(q.Property, "=")
+    property, ok := v.GetProperty(key)
+    if !ok || (hasValue && fmt.Sprintf("%v", property) != value) {
+      return false, nil
+    }
+  }
+  return true, nil
+}
+
+// VariableTable is a list of variables that prints as a table
+type VariableTable []deppy.Variable
+
+func (t VariableTable) String() string {
+  rows := make([][]string, 0, len(t))
+  for _, v := range t {
+    rows = append(rows, []string{string(v.VariableID()), v.Kind(), fmt.Sprintf("%d", len(v.GetConstraintIDs())), propertySummary(v)})
+  }
+  return table([]string{"ID", "KIND", "CONSTRAINTS", "PROPERTIES"}, rows)
+}
+
+// ConstraintRow is a constraint of a variable, along with whether it is activated
+type ConstraintRow struct {
+  deppy.AppliedConstraint
+  Activated bool `json:"activated"`
+}
+
+// ConstraintTable is a list of constraints that prints as a table
+type ConstraintTable []ConstraintRow
+
+func (t ConstraintTable) String() string {
+  rows := make([][]string, 0, len(t))
+  for _, row := range t {
+    activated := "yes"
+    if !row.Activated {
+      activated = "no"
+    }
+    rows = append(rows, []string{
+      string(row.Variable.VariableID()),
+      string(row.Constraint.ConstraintID()),
+      constraintType(row.Constraint),
+      activated,
+      row.Constraint.String(row.Variable.VariableID()),
+    })
+  }
+  return table([]string{"VARIABLE", "CONSTRAINT", "TYPE", "ACTIVATED", "DESCRIPTION"}, rows)
+}
+
+// ProblemStats summarizes the variables and constraints of a problem
+type ProblemStats struct {
+  Variables            int            `json:"variables"`
+  DeactivatedVariables int            `json:"deactivatedVariables"`
+  Kinds                map[string]int `json:"kinds"`
+  Constraints          map[string]int `json:"constraints"`
+  MissingReferences    int            `json:"missingReferences"`
+}
+
+func (s *ProblemStats) String() string {
+  rows := [][]string{
+    {"variables", fmt.Sprintf("%d", s.Variables)},
+    {"deactivated variables", fmt.Sprintf("%d", s.DeactivatedVariables)},
+    {"missing references", fmt.Sprintf("%d", s.MissingReferences)},
+  }
+  for _, kind := range sortedKeys(s.Kinds) {
+    rows = append(rows, []string{"kind " + kind, fmt.Sprintf("%d", s.Kinds[kind])})
+  }
+  for _, constraintType := range sortedKeys(s.Constraints) {
+    rows = append(rows, []string{constraintType + " constraints", fmt.Sprintf("%d", s.Constraints[constraintType])})
+  }
+  return table([]string{"STAT", "COUNT"}, rows)
+}
+
+// Find returns the activated variables matching the query, in identifier order
+func (m *MutableResolutionProblem) Find(query Query) (VariableTable, error) {
+  vars, err := m.GetMutableVariables()
+  if err != nil {
+    return nil, err
+  }
+  var found VariableTable
+  for _, v := range vars {
+    ok, err := query.matches(v)
+    if err != nil {
+      return nil, err
+    }
+    if ok {
+      found = append(found, v)
+    }
+  }
+  sort.Slice(found, func(i, j int) bool {
+    return found[i].VariableID() < found[j].VariableID()
+  })
+  return found, nil
+}
+
+// ConstraintsOf returns every constraint of the variable, including the deactivated ones, in identifier order
+func (m *MutableResolutionProblem) ConstraintsOf(variableID deppy.Identifier) (ConstraintTable, error) {
+  v, ok := m.variables.GetValue(variableID)
+  if !ok {
+    return nil, deppy.NotFoundErrorf("%s", variableID)
+  }
+  return m.constraintRows(v, func(deppy.Constraint) bool { return true }), nil
+}
+
+// Dependents returns the dependency constraints of activated variables that the variable can satisfy
+func (m *MutableResolutionProblem) Dependents(variableID deppy.Identifier) (ConstraintTable, error) {
+  vars, err := m.GetMutableVariables()
+  if err != nil {
+    return nil, err
+  }
+  sort.Slice(vars, func(i, j int) bool {
+    return vars[i].VariableID() < vars[j].VariableID()
+  })
+  var dependents ConstraintTable
+  for _, v := range vars {
+    dependents = append(dependents, m.constraintRows(v, func(c deppy.Constraint) bool {
+      dependency, ok := c.(*constraints.DependencyConstraint)
+      if !ok {
+        return false
+      }
+      for _, id := range dependency.Elements() {
+        if id == variableID {
+          return true
+        }
+      }
+      return false
+    })...)
+  }
+  return dependents, nil
+}
+
+// Stats counts the variables of the problem by kind and their activated constraints by type
+func (m *MutableResolutionProblem) Stats() (*ProblemStats, error) {
+  stats := &ProblemStats{
+    Kinds:       map[string]int{},
+    Constraints: map[string]int{},
+  }
+  vars, err := m.GetMutableVariables()
+  if err != nil {
+    return nil, err
+  }
+  stats.Variables = len(vars)
+  stats.DeactivatedVariables = m.variables.Len() - len(vars)
+  for _, v := range vars {
+    stats.Kinds[v.Kind()]++
+    for _, c := range v.Constraints() {
+      stats.Constraints[constraintType(c)]++
+      for _, id := range referencedVariableIDs(c) {
+        if !m.variables.Has(id) {
+          stats.MissingReferences++
+        }
+      }
+    }
+  }
+  return stats, nil
+}
+
+func (m *MutableResolutionProblem) constraintRows(v deppy.Variable, include func(deppy.Constraint) bool) ConstraintTable {
+  constraintIDs := v.GetConstraintIDs()
+  sort.Slice(constraintIDs, func(i, j int) bool {
+    return constraintIDs[i] < constraintIDs[j]
+  })
+  var rows ConstraintTable
+  for _, constraintID := range constraintIDs {
+    c, ok := v.GetConstraint(constraintID)
+    if !ok || !include(c) {
+      continue
+    }
+    activated, _ := v.IsActivated(constraintID)
+    row := ConstraintRow{
+      AppliedConstraint: deppy.AppliedConstraint{Variable: v, Constraint: c},
+      Activated:         activated,
+    }
+    if provenance, ok := m.ConstraintProvenance(v.VariableID(), constraintID); ok {
+      row.Provenance = &provenance
+    }
+    rows = append(rows, row)
+  }
+  return rows
+}
+
+func constraintType(c deppy.Constraint) string {
+  switch c.(type) {
+  case *constraints.MandatoryConstraint:
+    return "mandatory"
+  case *constraints.ProhibitedConstraint:
+    return "prohibited"
+  case *constraints.ConflictConstraint:
+    return "conflict"
+  case *constraints.DependencyConstraint:
+    return "dependency"
+  case *constraints.AtMostConstraint:
+    return "at-most"
+  }
+  return fmt.Sprintf("%T", c)
+}
+
+func referencedVariableIDs(c deppy.Constraint) []deppy.Identifier {
+  switch c := c.(type) {
+  case *constraints.ConflictConstraint:
+    return []deppy.Identifier{c.ConflictingVariableID()}
+  case *constraints.DependencyConstraint:
+    return c.Elements()
+  case *constraints.AtMostConstraint:
+    return c.Elements()
+  }
+  return nil
+}
+
+// propertySummary lists the properties of the variable as key=value, in key order
+func propertySummary(v deppy.Variable) string {
+  properties := v.GetProperties()
+  keys := make([]string, 0, len(properties))
+  for key := range properties {
+    keys = append(keys, key)
+  }
+  sort.Strings(keys)
+  summary := make([]string, 0, len(keys))
+  for _, key := range keys {
+    summary = append(summary, fmt.Sprintf("%s=%v", key, properties[key]))
+  }
+  return strings.Join(summary, " ")
+}
+
+func sortedKeys(m map[string]int) []string {
+  keys := make([]string, 0, len(m))
+  for key := range m {
+    keys = append(keys, key)
+  }
+  sort.Strings(keys)
+  return keys
+}
+
+func table(headers []string, rows [][]string) string {
+  sb := &strings.Builder{}
+  w := tabwriter.NewWriter(sb, 0, 0, 2, ' ', 0)
+  fmt.Fprintln(w, strings.Join(headers, "\t"))
+  for _, row := range rows {
+    fmt.Fprintln(w, strings.Join(row, "\t"))
+  }
+  _ = w.Flush()
+  return sb.String()
+}
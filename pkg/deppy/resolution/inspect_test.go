@@ -0,0 +1,98 @@
+package resolution_test
+
+import (
+	"github.com/perdasilva/replee/pkg/deppy"
+	"github.com/perdasilva/replee/pkg/deppy/resolution"
+	"github.com/perdasilva/replee/pkg/deppy/variables"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func inspectProblem(t *testing.T) *resolution.MutableResolutionProblem {
+	m := resolution.NewMutableResolutionProblem("test")
+	app := variables.NewMutableVariable("app", "deppy.var.app", nil)
+	assert.NoError(t, app.AddMandatory("mandatory"))
+	assert.NoError(t, app.AddDependency("needs-lib", "lib/v2", "lib/v1"))
+	assert.NoError(t, app.AddDependency("needs-missing", "missing"))
+	assert.NoError(t, app.RemoveProhibited("not-prohibited"))
+	assert.NoError(t, m.ActivateVariable(app))
+	for _, version := range []string{"v1", "v2"} {
+		lib := variables.NewMutableVariable(deppy.Identifierf("lib/%s", version), "deppy.var.lib", map[string]interface{}{"version": version})
+		assert.NoError(t, m.ActivateVariable(lib))
+	}
+	assert.NoError(t, m.DeactivateVariable("old", "deppy.var.lib"))
+	return m
+}
+
+func TestMutableResolutionProblem_Find(t *testing.T) {
+	m := inspectProblem(t)
+	tt := []struct {
+		name     string
+		query    resolution.Query
+		expected []deppy.Identifier
+	}{
+		{name: "everything", query: resolution.Query{}, expected: []deppy.Identifier{"app", "lib/v1", "lib/v2"}},
+		{name: "by kind", query: resolution.Query{Kind: "deppy.var.lib"}, expected: []deppy.Identifier{"lib/v1", "lib/v2"}},
+		{name: "by id glob", query: resolution.Query{ID: "lib/*2"}, expected: []deppy.Identifier{"lib/v2"}},
+		{name: "by property", query: resolution.Query{Property: "version"}, expected: []deppy.Identifier{"lib/v1", "lib/v2"}},
+		{name: "by property value", query: resolution.Query{Property: "version=v1"}, expected: []deppy.Identifier{"lib/v1"}},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			found, err := m.Find(tc.query)
+			assert.NoError(t, err)
+			var ids []deppy.Identifier
+			for _, v := range found {
+				ids = append(ids, v.VariableID())
+			}
+			assert.Equal(t, tc.expected, ids)
+		})
+	}
+
+	found, err := m.Find(resolution.Query{Property: "version=v1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "ID      KIND           CONSTRAINTS  PROPERTIES\nlib/v1  deppy.var.lib  0            version=v1\n", found.String())
+
+	_, err = m.Find(resolution.Query{ID: "["})
+	assert.Error(t, err)
+}
+
+func TestMutableResolutionProblem_ConstraintsOf(t *testing.T) {
+	m := inspectProblem(t)
+	cs, err := m.ConstraintsOf("app")
+	assert.NoError(t, err)
+	assert.Len(t, cs, 4)
+	assert.Equal(t, deppy.Identifier("mandatory"), cs[0].Constraint.ConstraintID())
+	assert.True(t, cs[0].Activated)
+	assert.False(t, cs[3].Activated)
+	assert.Contains(t, cs.String(), "app       not-prohibited  prohibited  no         app is ProhibitedConstraint\n")
+
+	_, err = m.ConstraintsOf("nope")
+	assert.EqualError(t, err, "variable with id nope not found")
+}
+
+func TestMutableResolutionProblem_Dependents(t *testing.T) {
+	m := inspectProblem(t)
+	dependents, err := m.Dependents("lib/v1")
+	assert.NoError(t, err)
+	assert.Len(t, dependents, 1)
+	assert.Equal(t, deppy.Identifier("app"), dependents[0].Variable.VariableID())
+	assert.Equal(t, deppy.Identifier("needs-lib"), dependents[0].Constraint.ConstraintID())
+
+	dependents, err = m.Dependents("app")
+	assert.NoError(t, err)
+	assert.Empty(t, dependents)
+}
+
+func TestMutableResolutionProblem_Stats(t *testing.T) {
+	stats, err := inspectProblem(t).Stats()
+	assert.NoError(t, err)
+	assert.Equal(t, &resolution.ProblemStats{
+		Variables:            3,
+		DeactivatedVariables: 1,
+		Kinds:                map[string]int{"deppy.var.app": 1, "deppy.var.lib": 2},
+		Constraints:          map[string]int{"mandatory": 1, "dependency": 2},
+		MissingReferences:    1,
+	}, stats)
+	assert.Contains(t, stats.String(), "kind deppy.var.lib      2\n")
+}
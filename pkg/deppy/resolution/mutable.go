@@ -9,16 +9,19 @@ import (
 )
 
 var _ deppy.MutableResolutionProblem = &MutableResolutionProblem{}
+var _ deppy.ProvenanceLookup = &MutableResolutionProblem{}
 
 type MutableResolutionProblem struct {
   resolutionProblemID deppy.Identifier
   variables           *utils.ActivationMap[deppy.Identifier, deppy.MutableVariable]
+  provenance          *provenanceIndex
 }
 
 func NewMutableResolutionProblem(resolutionProblemID deppy.Identifier) *MutableResolutionProblem {
   return &MutableResolutionProblem{
     resolutionProblemID: resolutionProblemID,
     variables:           utils.NewActivationMap[deppy.Identifier, deppy.MutableVariable](),
+    provenance:          newProvenanceIndex(),
   }
 }
 
@@ -51,6 +54,7 @@ func (m *MutableResolutionProblem) UnmarshalJSON(jsonBytes []byte) error {
   }
   m.resolutionProblemID = data.ResolutionProblemID
   m.variables = utils.NewActivationMap[deppy.Identifier, deppy.MutableVariable]()
+  m.provenance = newProvenanceIndex()
   varIDs := data.Variables.Keys()
   for i := 0; i < len(varIDs); i++ {
     variableID := varIDs[i]
@@ -144,6 +148,55 @@ func (m *MutableResolutionProblem) GetVariables() ([]deppy.Variable, error) {
   return vars, nil
 }
 
+// GetAllVariables returns every variable of the problem, including the deactivated ones GetVariables leaves out
+func (m *MutableResolutionProblem) GetAllVariables() ([]deppy.Variable, error) {
+  var vars []deppy.Variable
+  for _, id := range m.variables.Keys() {
+    vars = append(vars, m.variables.MustGet(id))
+  }
+  return vars, nil
+}
+
+// IsActivated returns whether the variable is activated, or a not found error if it isn't part of the problem
+func (m *MutableResolutionProblem) IsActivated(variableID deppy.Identifier) (bool, error) {
+  return m.variables.IsActivated(variableID)
+}
+
 func (m *MutableResolutionProblem) Options() []deppy.ResolutionOption {
   return nil
 }
+
+// VariableProvenance returns the provenance of the variable, if it was added while building the problem
+func (m *MutableResolutionProblem) VariableProvenance(variableID deppy.Identifier) (deppy.Provenance, bool) {
+  if m.provenance == nil {
+    return deppy.Provenance{}, false
+  }
+  return m.provenance.variable(variableID)
+}
+
+// PropertyProvenance returns the provenance of a variable's property, if it was added while building the problem
+func (m *MutableResolutionProblem) PropertyProvenance(variableID deppy.Identifier, key string) (deppy.Provenance, bool) {
+  if m.provenance == nil {
+    return deppy.Provenance{}, false
+  }
+  return m.provenance.property(variableID, key)
+}
+
+// ConstraintProvenance returns the provenance of a variable's constraint, if it was added while building the problem
+func (m *MutableResolutionProblem) ConstraintProvenance(variableID deppy.Identifier, constraintID deppy.Identifier) (deppy.Provenance, bool) {
+  if m.provenance == nil {
+    return deppy.Provenance{}, false
+  }
+  return m.provenance.constraint(variableID, constraintID)
+}
+
+// Provenance returns a summary of the provenance of a variable, its properties and its constraints
+func (m *MutableResolutionProblem) Provenance(variableID deppy.Identifier) (*VariableProvenance, error) {
+  if !m.variables.Has(variableID) {
+    return nil, deppy.NotFoundErrorf("%s", variableID)
+  }
+  if m.provenance == nil {
+    return &VariableProvenance{VariableID: variableID}, nil
+  }
+  return m.provenance.summary(variableID), nil
+}
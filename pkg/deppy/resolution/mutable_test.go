@@ -95,3 +95,25 @@ func TestMutableResolutionProblem_MarshalJSON(t *testing.T) {
 //		})
 //	}
 //}
+
+func TestMutableResolutionProblem_GetAllVariables(t *testing.T) {
+	m := resolution.NewMutableResolutionProblem("foo")
+	assert.NoError(t, m.ActivateVariable(variables.NewMutableVariable("a", "deppy.var.test", nil)))
+	assert.NoError(t, m.DeactivateVariable("b", "deppy.var.test"))
+
+	vars, err := m.GetVariables()
+	assert.NoError(t, err)
+	assert.Len(t, vars, 1)
+	all, err := m.GetAllVariables()
+	assert.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	activated, err := m.IsActivated("a")
+	assert.NoError(t, err)
+	assert.True(t, activated)
+	activated, err = m.IsActivated("b")
+	assert.NoError(t, err)
+	assert.False(t, activated)
+	_, err = m.IsActivated("c")
+	assert.Error(t, err)
+}
@@ -0,0 +1,55 @@
+package resolution
+
+import (
+  "time"
+)
+
+// buildOptions holds the budgets enforced while building a resolution problem
+// a zero value means the budget is not enforced
+type buildOptions struct {
+  maxVariables       int
+  maxQueueIterations int
+  maxSourceTime      time.Duration
+  maxBuildTime       time.Duration
+}
+
+func (b *buildOptions) apply(options ...BuildOption) *buildOptions {
+  for _, applyOption := range options {
+    applyOption(b)
+  }
+  return b
+}
+
+func defaultBuildOptions() *buildOptions {
+  return &buildOptions{}
+}
+
+type BuildOption func(buildOptions *buildOptions)
+
+// MaxVariables is a Build option that limits the number of variables the problem can hold
+func MaxVariables(n int) BuildOption {
+  return func(buildOptions *buildOptions) {
+    buildOptions.maxVariables = n
+  }
+}
+
+// MaxQueueIterations is a Build option that limits the number of variables taken off the build queue
+func MaxQueueIterations(n int) BuildOption {
+  return func(buildOptions *buildOptions) {
+    buildOptions.maxQueueIterations = n
+  }
+}
+
+// MaxSourceTime is a Build option that limits the time a single Update or Finalize call can take
+func MaxSourceTime(d time.Duration) BuildOption {
+  return func(buildOptions *buildOptions) {
+    buildOptions.maxSourceTime = d
+  }
+}
+
+// MaxBuildTime is a Build option that limits the total time taken to build the problem
+func MaxBuildTime(d time.Duration) BuildOption {
+  return func(buildOptions *buildOptions) {
+    buildOptions.maxBuildTime = d
+  }
+}
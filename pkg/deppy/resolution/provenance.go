@@ -0,0 +1,127 @@
+package resolution
+
+import (
+  "encoding/json"
+  "fmt"
+  "github.com/perdasilva/replee/pkg/deppy"
+  "sort"
+  "sync"
+)
+
+// VariableProvenance summarises where a variable, its properties and its
+// constraints came from.
+type VariableProvenance struct {
+  VariableID  deppy.Identifier                      `json:"variableID"`
+  Provenance  *deppy.Provenance                     `json:"provenance"`
+  Properties  map[string]deppy.Provenance           `json:"properties"`
+  Constraints map[deppy.Identifier]deppy.Provenance `json:"constraints"`
+}
+
+func (p *VariableProvenance) String() string {
+  str, err := json.MarshalIndent(p, "", "  ")
+  if err != nil {
+    return fmt.Sprintf("error marshaling provenance: %s", err)
+  }
+  return string(str)
+}
+
+// provenanceIndex keeps the first provenance recorded for each variable,
+// property and constraint of a problem.
+type provenanceIndex struct {
+  lock        sync.RWMutex
+  variables   map[deppy.Identifier]deppy.Provenance
+  properties  map[deppy.Identifier]map[string]deppy.Provenance
+  constraints map[deppy.Identifier]map[deppy.Identifier]deppy.Provenance
+}
+
+func newProvenanceIndex() *provenanceIndex {
+  return &provenanceIndex{
+    variables:   map[deppy.Identifier]deppy.Provenance{},
+    properties:  map[deppy.Identifier]map[string]deppy.Provenance{},
+    constraints: map[deppy.Identifier]map[deppy.Identifier]deppy.Provenance{},
+  }
+}
+
+// record attributes the variable and any of its properties and constraints that
+// have no provenance yet to the given provenance, and returns what was attributed
+func (p *provenanceIndex) record(v deppy.Variable, provenance deppy.Provenance) []Addition {
+  p.lock.Lock()
+  defer p.lock.Unlock()
+  var added []Addition
+  variableID := v.VariableID()
+  if _, ok := p.variables[variableID]; !ok {
+    p.variables[variableID] = provenance
+    added = append(added, Addition{VariableID: variableID})
+  }
+
+  if _, ok := p.properties[variableID]; !ok {
+    p.properties[variableID] = map[string]deppy.Provenance{}
+  }
+  var keys []string
+  for key := range v.GetProperties() {
+    keys = append(keys, key)
+  }
+  sort.Strings(keys)
+  for _, key := range keys {
+    if _, ok := p.properties[variableID][key]; !ok {
+      p.properties[variableID][key] = provenance
+      added = append(added, Addition{VariableID: variableID, Property: key})
+    }
+  }
+
+  if _, ok := p.constraints[variableID]; !ok {
+    p.constraints[variableID] = map[deppy.Identifier]deppy.Provenance{}
+  }
+  constraintIDs := v.GetConstraintIDs()
+  sort.Slice(constraintIDs, func(i, j int) bool {
+    return constraintIDs[i] < constraintIDs[j]
+  })
+  for _, constraintID := range constraintIDs {
+    if _, ok := p.constraints[variableID][constraintID]; !ok {
+      p.constraints[variableID][constraintID] = provenance
+      added = append(added, Addition{VariableID: variableID, ConstraintID: constraintID})
+    }
+  }
+  return added
+}
+
+func (p *provenanceIndex) variable(variableID deppy.Identifier) (deppy.Provenance, bool) {
+  p.lock.RLock()
+  defer p.lock.RUnlock()
+  provenance, ok := p.variables[variableID]
+  return provenance, ok
+}
+
+func (p *provenanceIndex) property(variableID deppy.Identifier, key string) (deppy.Provenance, bool) {
+  p.lock.RLock()
+  defer p.lock.RUnlock()
+  provenance, ok := p.properties[variableID][key]
+  return provenance, ok
+}
+
+func (p *provenanceIndex) constraint(variableID deppy.Identifier, constraintID deppy.Identifier) (deppy.Provenance, bool) {
+  p.lock.RLock()
+  defer p.lock.RUnlock()
+  provenance, ok := p.constraints[variableID][constraintID]
+  return provenance, ok
+}
+
+func (p *provenanceIndex) summary(variableID deppy.Identifier) *VariableProvenance {
+  p.lock.RLock()
+  defer p.lock.RUnlock()
+  out := &VariableProvenance{
+    VariableID:  variableID,
+    Properties:  map[string]deppy.Provenance{},
+    Constraints: map[deppy.Identifier]deppy.Provenance{},
+  }
+  if provenance, ok := p.variables[variableID]; ok {
+    out.Provenance = &provenance
+  }
+  for key, provenance := range p.properties[variableID] {
+    out.Properties[key] = provenance
+  }
+  for constraintID, provenance := range p.constraints[variableID] {
+    out.Constraints[constraintID] = provenance
+  }
+  return out
+}
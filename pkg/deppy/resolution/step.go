@@ -0,0 +1,73 @@
+package resolution
+
+import (
+  "fmt"
+  "github.com/perdasilva/replee/pkg/deppy"
+  "strings"
+)
+
+// Addition is a variable, property or constraint added to the problem by a variable source.
+// Only VariableID is set when the variable itself was added.
+type Addition struct {
+  VariableID   deppy.Identifier `json:"variableID"`
+  Property     string           `json:"property,omitempty"`
+  ConstraintID deppy.Identifier `json:"constraintID,omitempty"`
+}
+
+func (a Addition) String() string {
+  switch {
+  case a.Property != "":
+    return fmt.Sprintf("property %s of %s", a.Property, a.VariableID)
+  case a.ConstraintID != "":
+    return fmt.Sprintf("constraint %s of %s", a.ConstraintID, a.VariableID)
+  default:
+    return fmt.Sprintf("variable %s", a.VariableID)
+  }
+}
+
+// SourceStep records what a single Update or Finalize call of a variable source did to the problem
+type SourceStep struct {
+  VariableSourceID deppy.Identifier `json:"variableSourceID"`
+  Finalize         bool             `json:"finalize,omitempty"`
+  Added            []Addition       `json:"added,omitempty"`
+  Error            string           `json:"error,omitempty"`
+}
+
+// BuildStep records the processing of a single item of the build queue. Variable is nil
+// for the bootstrap step, which offers the nil variable to the variable sources.
+// Only variable sources that changed the problem or returned an error are listed.
+type BuildStep struct {
+  Iteration int                `json:"iteration"`
+  Variable  deppy.Variable     `json:"variable"`
+  Sources   []*SourceStep      `json:"sources"`
+  Queued    []deppy.Identifier `json:"queued"`
+  Done      bool               `json:"done"`
+}
+
+func (s *BuildStep) String() string {
+  sb := strings.Builder{}
+  if s.Variable == nil {
+    sb.WriteString(fmt.Sprintf("step %d: bootstrap\n", s.Iteration))
+  } else {
+    sb.WriteString(fmt.Sprintf("step %d: %s (%s)\n", s.Iteration, s.Variable.VariableID(), s.Variable.Kind()))
+  }
+  for _, source := range s.Sources {
+    call := "update"
+    if source.Finalize {
+      call = "finalize"
+    }
+    sb.WriteString(fmt.Sprintf("  %s (%s)\n", source.VariableSourceID, call))
+    for _, addition := range source.Added {
+      sb.WriteString(fmt.Sprintf("    + %s\n", addition))
+    }
+    if source.Error != "" {
+      sb.WriteString(fmt.Sprintf("    ! %s\n", source.Error))
+    }
+  }
+  if s.Done {
+    sb.WriteString("done")
+  } else {
+    sb.WriteString(fmt.Sprintf("%d queued", len(s.Queued)))
+  }
+  return sb.String()
+}
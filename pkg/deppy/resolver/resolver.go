@@ -6,7 +6,10 @@ import (
   "errors"
   "fmt"
   "github.com/perdasilva/replee/pkg/deppy"
+  "github.com/perdasilva/replee/pkg/deppy/constraints"
+  "github.com/perdasilva/replee/pkg/deppy/lint"
   "github.com/perdasilva/replee/pkg/deppy/solver"
+  "sort"
 )
 
 // Solution is returned by the Solver when the internal solver executed successfully.
@@ -66,9 +69,24 @@ func (s *Solution) Problem() deppy.ResolutionProblem {
   return s.problem
 }
 
+// KindMissing is the kind of the placeholder variables standing in for variables that constraints refer to but
+// that aren't part of the problem
+const KindMissing = "deppy.variable.missing"
+
+type missingVariablePolicy int
+
+const (
+  // missingVariablesFail fails the solve, with a lint error unless linting is disabled
+  missingVariablesFail missingVariablePolicy = iota
+  missingVariablesUnselectable
+  missingVariablesPlaceholder
+)
+
 type solutionOptions struct {
   addVariablesToSolution bool
   disableOrderPreference bool
+  disableLint            bool
+  missingVariables       missingVariablePolicy
 }
 
 func (s *solutionOptions) apply(options ...Option) *solutionOptions {
@@ -82,6 +100,8 @@ func defaultSolutionOptions() *solutionOptions {
   return &solutionOptions{
     addVariablesToSolution: false,
     disableOrderPreference: false,
+    disableLint:            false,
+    missingVariables:       missingVariablesFail,
   }
 }
 
@@ -101,6 +121,32 @@ func DisableOrderPreference() Option {
   }
 }
 
+// DisableLint is a Solve option that skips linting the problem before solving it
+func DisableLint() Option {
+  return func(solutionOptions *solutionOptions) {
+    solutionOptions.disableLint = true
+  }
+}
+
+// MissingVariablesUnselectable is a Solve option that treats the variables constraints refer to but that aren't part
+// of the problem as prohibited. If that makes the problem unsatisfiable, the NotSatisfiable error of the solution
+// lists the "missing" constraint of the placeholder variable of kind KindMissing standing in for the variable, which
+// reads "<id> is not part of the problem".
+func MissingVariablesUnselectable() Option {
+  return func(solutionOptions *solutionOptions) {
+    solutionOptions.missingVariables = missingVariablesUnselectable
+  }
+}
+
+// MissingVariablesAsPlaceholders is a Solve option that adds an unconstrained placeholder variable of kind KindMissing
+// for each variable constraints refer to but that isn't part of the problem. Placeholders can be selected like any
+// other variable.
+func MissingVariablesAsPlaceholders() Option {
+  return func(solutionOptions *solutionOptions) {
+    solutionOptions.missingVariables = missingVariablesPlaceholder
+  }
+}
+
 // DeppyResolver is a simple solver implementation that takes an entity source group and a constraint aggregator
 // to produce a Solution (or error if no solution can be found)
 type DeppyResolver struct{}
@@ -116,6 +162,20 @@ func (d DeppyResolver) Solve(ctx context.Context, problem deppy.ResolutionProble
   if err != nil {
     return nil, err
   }
+  if solutionOpts.missingVariables != missingVariablesFail {
+    vars = append(vars, placeholders(vars, solutionOpts.missingVariables == missingVariablesUnselectable)...)
+  }
+
+  // lint errors, such as dangling references, would otherwise surface as internal solver errors
+  if !solutionOpts.disableLint {
+    report, err := lint.Lint(&variablesProblem{ResolutionProblem: problem, variables: vars})
+    if err != nil {
+      return nil, err
+    }
+    if report.HasErrors() {
+      return nil, &lint.Error{Report: report}
+    }
+  }
 
   opts := []solver.Option{
     solver.WithInput(vars),
@@ -143,10 +203,149 @@ func (d DeppyResolver) Solve(ctx context.Context, problem deppy.ResolutionProble
   if err != nil {
     unsatError := deppy.NotSatisfiable{}
     errors.As(err, &unsatError)
-    solution.err = unsatError
+    solution.err = withProvenance(problem, unsatError)
   }
 
   solution.problem = problem
 
   return solution, nil
 }
+
+// variablesProblem is a problem with a different set of variables, used to lint the problem along with its placeholders
+type variablesProblem struct {
+  deppy.ResolutionProblem
+  variables []deppy.Variable
+}
+
+func (p *variablesProblem) GetVariables() ([]deppy.Variable, error) {
+  return p.variables, nil
+}
+
+// placeholders returns a variable of kind KindMissing for each variable the constraints of vars refer to that isn't
+// one of vars, prohibited if unselectable is set
+func placeholders(vars []deppy.Variable, unselectable bool) []deppy.Variable {
+  known := map[deppy.Identifier]struct{}{}
+  for _, v := range vars {
+    known[v.VariableID()] = struct{}{}
+  }
+  missing := map[deppy.Identifier]struct{}{}
+  for _, v := range vars {
+    for _, c := range v.Constraints() {
+      var ids []deppy.Identifier
+      switch c := c.(type) {
+      case *constraints.DependencyConstraint:
+        ids = c.Elements()
+      case *constraints.AtMostConstraint:
+        ids = c.Elements()
+      case *constraints.ConflictConstraint:
+        ids = []deppy.Identifier{c.ConflictingVariableID()}
+      }
+      for _, id := range ids {
+        if _, ok := known[id]; !ok {
+          missing[id] = struct{}{}
+        }
+      }
+    }
+  }
+
+  ids := make([]deppy.Identifier, 0, len(missing))
+  for id := range missing {
+    ids = append(ids, id)
+  }
+  sort.Slice(ids, func(i, j int) bool {
+    return ids[i] < ids[j]
+  })
+  placeholders := make([]deppy.Variable, 0, len(ids))
+  for _, id := range ids {
+    v := &placeholder{variableID: id}
+    if unselectable {
+      v.constraints = []deppy.Constraint{&missingConstraint{constraints.Prohibited("missing")}}
+    }
+    placeholders = append(placeholders, v)
+  }
+  return placeholders
+}
+
+var _ deppy.Variable = &placeholder{}
+
+// placeholder stands in for a variable that constraints refer to but that isn't part of the problem
+type placeholder struct {
+  variableID  deppy.Identifier
+  constraints []deppy.Constraint
+}
+
+func (p *placeholder) VariableID() deppy.Identifier {
+  return p.variableID
+}
+
+func (p *placeholder) Constraints() []deppy.Constraint {
+  return p.constraints
+}
+
+func (p *placeholder) GetConstraint(constraintID deppy.Identifier) (deppy.Constraint, bool) {
+  for _, c := range p.constraints {
+    if c.ConstraintID() == constraintID {
+      return c, true
+    }
+  }
+  return nil, false
+}
+
+func (p *placeholder) GetConstraintIDs() []deppy.Identifier {
+  ids := make([]deppy.Identifier, 0, len(p.constraints))
+  for _, c := range p.constraints {
+    ids = append(ids, c.ConstraintID())
+  }
+  return ids
+}
+
+func (p *placeholder) Kind() string {
+  return KindMissing
+}
+
+func (p *placeholder) GetProperty(string) (interface{}, bool) {
+  return nil, false
+}
+
+func (p *placeholder) GetProperties() map[string]interface{} {
+  return map[string]interface{}{}
+}
+
+func (p *placeholder) IsActivated(constraintID deppy.Identifier) (bool, error) {
+  _, ok := p.GetConstraint(constraintID)
+  return ok, nil
+}
+
+func (p *placeholder) MarshalJSON() ([]byte, error) {
+  return json.Marshal(&struct {
+    VariableID deppy.Identifier `json:"variableID"`
+    Kind       string           `json:"kind"`
+  }{
+    VariableID: p.variableID,
+    Kind:       KindMissing,
+  })
+}
+
+// missingConstraint prohibits an unselectable placeholder, saying why in unsatisfiable constraint explanations
+type missingConstraint struct {
+  *constraints.ProhibitedConstraint
+}
+
+func (c *missingConstraint) String(subject deppy.Identifier) string {
+  return fmt.Sprintf("%s is not part of the problem", subject)
+}
+
+// withProvenance annotates the applied constraints with the variable source that added them
+// if the problem keeps track of provenance
+func withProvenance(problem deppy.ResolutionProblem, unsatError deppy.NotSatisfiable) deppy.NotSatisfiable {
+  lookup, ok := problem.(deppy.ProvenanceLookup)
+  if !ok {
+    return unsatError
+  }
+  for i, appliedConstraint := range unsatError {
+    if provenance, ok := lookup.ConstraintProvenance(appliedConstraint.Variable.VariableID(), appliedConstraint.Constraint.ConstraintID()); ok {
+      unsatError[i].Provenance = &provenance
+    }
+  }
+  return unsatError
+}
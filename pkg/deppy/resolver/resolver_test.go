@@ -0,0 +1,60 @@
+package resolver_test
+
+import (
+	"context"
+	"github.com/perdasilva/replee/pkg/deppy"
+	"github.com/perdasilva/replee/pkg/deppy/resolution"
+	. "github.com/perdasilva/replee/pkg/deppy/resolver"
+	"github.com/perdasilva/replee/pkg/deppy/variables"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// missingProblem has a mandatory variable a that depends on b or the missing variable x, and b conflicts with the
+// missing variable y
+func missingProblem(t *testing.T, prohibitB bool) *resolution.MutableResolutionProblem {
+	problem := resolution.NewMutableResolutionProblem("test")
+	a := variables.NewMutableVariable("a", "deppy.var.test", nil)
+	assert.NoError(t, a.AddMandatory("mandatory"))
+	assert.NoError(t, a.AddDependency("dependency", "b", "x"))
+	b := variables.NewMutableVariable("b", "deppy.var.test", nil)
+	assert.NoError(t, b.AddConflict("conflict", "y"))
+	if prohibitB {
+		assert.NoError(t, b.AddProhibited("prohibited"))
+	}
+	for _, v := range []deppy.MutableVariable{a, b} {
+		assert.NoError(t, problem.ActivateVariable(v))
+	}
+	return problem
+}
+
+func TestSolve_MissingVariables(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := NewDeppyResolver().Solve(ctx, missingProblem(t, false))
+	assert.ErrorContains(t, err, "depends on x, which is not part of the problem")
+
+	solution, err := NewDeppyResolver().Solve(ctx, missingProblem(t, false), MissingVariablesUnselectable())
+	assert.NoError(t, err)
+	assert.Empty(t, solution.NotSatisfiable())
+	assert.True(t, solution.IsSelected("b"))
+	assert.False(t, solution.IsSelected("x"))
+
+	solution, err = NewDeppyResolver().Solve(ctx, missingProblem(t, true), MissingVariablesUnselectable())
+	assert.NoError(t, err)
+	var missing []deppy.Identifier
+	for _, applied := range solution.NotSatisfiable() {
+		if applied.Variable.Kind() == KindMissing {
+			missing = append(missing, applied.Variable.VariableID())
+			assert.Equal(t, deppy.Identifier("missing"), applied.Constraint.ConstraintID())
+			assert.Equal(t, "x is not part of the problem", applied.String())
+		}
+	}
+	assert.Equal(t, []deppy.Identifier{"x"}, missing)
+
+	solution, err = NewDeppyResolver().Solve(ctx, missingProblem(t, true), MissingVariablesAsPlaceholders())
+	assert.NoError(t, err)
+	assert.Empty(t, solution.NotSatisfiable())
+	assert.True(t, solution.IsSelected("x"))
+	assert.Equal(t, KindMissing, solution.SelectedVariables()["x"].Kind())
+}
@@ -0,0 +1,74 @@
+package semver
+
+import (
+	"fmt"
+	"github.com/perdasilva/replee/pkg/deppy"
+	"sort"
+)
+
+const (
+	// PackageProperty is the variable property holding the name of the package a variable is a version of
+	PackageProperty = "package"
+	// VersionProperty is the variable property holding the version of the package a variable stands for
+	VersionProperty = "version"
+)
+
+// Candidates returns the identifiers of the variables of the required package whose version is within the
+// required range, newest first. Variables are matched on their package and version properties, variables
+// whose version can't be parsed are ignored. Equal versions are ordered by variable identifier.
+func Candidates(variables []deppy.Variable, requirement Requirement) ([]deppy.Identifier, error) {
+	versionRange, err := requirement.Range()
+	if err != nil {
+		return nil, err
+	}
+
+	type candidate struct {
+		id      deppy.Identifier
+		version *Version
+	}
+	var candidates []candidate
+	for _, v := range variables {
+		if packageName, ok := v.GetProperty(PackageProperty); !ok || fmt.Sprint(packageName) != requirement.PackageName {
+			continue
+		}
+		value, ok := v.GetProperty(VersionProperty)
+		if !ok {
+			continue
+		}
+		version, err := ParseVersion(fmt.Sprint(value))
+		if err != nil {
+			continue
+		}
+		if versionRange.Contains(version) {
+			candidates = append(candidates, candidate{id: v.VariableID(), version: version})
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if c := candidates[i].version.Compare(candidates[j].version); c != 0 {
+			return c > 0
+		}
+		return candidates[i].id < candidates[j].id
+	})
+	ids := make([]deppy.Identifier, len(candidates))
+	for i, c := range candidates {
+		ids[i] = c.id
+	}
+	return ids, nil
+}
+
+// AddDependency adds a dependency constraint to the variable on the variables of the problem that satisfy the
+// requirement (see Candidates), so that the newest versions come first in the constraint's order. A requirement
+// no variable satisfies yields a dependency that can't be satisfied. Candidates are only picked from the variables
+// the problem has when it is called, so variable sources should call it from Finalize.
+func AddDependency(problem deppy.ResolutionProblem, v deppy.MutableVariable, constraintID deppy.Identifier, requirement Requirement) error {
+	variables, err := problem.GetVariables()
+	if err != nil {
+		return err
+	}
+	ids, err := Candidates(variables, requirement)
+	if err != nil {
+		return err
+	}
+	return v.AddDependency(constraintID, ids...)
+}
@@ -0,0 +1,103 @@
+// Package semver parses semantic versions and version ranges, and turns version range requirements
+// into dependency constraints over the variables of a resolution problem.
+package semver
+
+import (
+	"fmt"
+	"github.com/Masterminds/semver/v3"
+	"sort"
+	"strings"
+)
+
+// Version is a semantic version, e.g. 1.2.3 or v1.2.3-alpha.1
+type Version = semver.Version
+
+// ParseVersion parses a semantic version. Missing minor and patch numbers are taken to be 0.
+func ParseVersion(s string) (*Version, error) {
+	return semver.NewVersion(s)
+}
+
+// Compare returns -1, 0 or 1 depending on whether version a is older than, the same as, or newer than version b
+func Compare(a string, b string) (int, error) {
+	va, err := ParseVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := ParseVersion(b)
+	if err != nil {
+		return 0, err
+	}
+	return va.Compare(vb), nil
+}
+
+// SortNewestFirst sorts the versions from newest to oldest
+func SortNewestFirst(versions []*Version) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].GreaterThan(versions[j])
+	})
+}
+
+// Range is a set of versions, e.g. ">=1.2 <2", "~1.2" or "^1.2 || >=3". The empty range contains every version.
+type Range struct {
+	raw         string
+	constraints *semver.Constraints
+}
+
+// ParseRange parses a version range. Comparisons separated by spaces or commas must all hold,
+// and alternatives are separated by ||.
+func ParseRange(s string) (*Range, error) {
+	raw := strings.TrimSpace(s)
+	expression := raw
+	if expression == "" {
+		expression = "*"
+	}
+	constraints, err := semver.NewConstraint(expression)
+	if err != nil {
+		return nil, fmt.Errorf("invalid version range %q: %w", s, err)
+	}
+	return &Range{raw: raw, constraints: constraints}, nil
+}
+
+// Contains returns true if the version is in the range. Pre-release versions are only in ranges
+// that mention a pre-release of the same major, minor and patch version.
+func (r *Range) Contains(v *Version) bool {
+	return r.constraints.Check(v)
+}
+
+func (r *Range) String() string {
+	return r.raw
+}
+
+// Requirement asks for a version of a package within a range
+type Requirement struct {
+	PackageName  string `json:"packageName"`
+	VersionRange string `json:"versionRange,omitempty"`
+}
+
+// ParseRequirement parses a requirement of the form "<package> [<version range>]", e.g. "foo >=1.2 <2"
+func ParseRequirement(s string) (Requirement, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return Requirement{}, fmt.Errorf("empty requirement")
+	}
+	r := Requirement{
+		PackageName:  fields[0],
+		VersionRange: strings.Join(fields[1:], " "),
+	}
+	if _, err := r.Range(); err != nil {
+		return Requirement{}, fmt.Errorf("requirement %q: %w", s, err)
+	}
+	return r, nil
+}
+
+// Range returns the parsed version range of the requirement
+func (r Requirement) Range() (*Range, error) {
+	return ParseRange(r.VersionRange)
+}
+
+func (r Requirement) String() string {
+	if r.VersionRange == "" {
+		return r.PackageName
+	}
+	return fmt.Sprintf("%s %s", r.PackageName, r.VersionRange)
+}
@@ -0,0 +1,80 @@
+package semver_test
+
+import (
+	"context"
+	"github.com/perdasilva/replee/pkg/deppy"
+	"github.com/perdasilva/replee/pkg/deppy/constraints"
+	"github.com/perdasilva/replee/pkg/deppy/resolution"
+	"github.com/perdasilva/replee/pkg/deppy/resolver"
+	. "github.com/perdasilva/replee/pkg/deppy/semver"
+	"github.com/perdasilva/replee/pkg/deppy/variables"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRange(t *testing.T) {
+	tt := []struct {
+		versionRange string
+		version      string
+		contains     bool
+	}{
+		{versionRange: "", version: "0.0.1", contains: true},
+		{versionRange: ">=1.2 <2", version: "1.2.0", contains: true},
+		{versionRange: ">=1.2 <2", version: "1.9.9", contains: true},
+		{versionRange: ">=1.2 <2", version: "2.0.0", contains: false},
+		{versionRange: ">=1.2, <2", version: "1.1.0", contains: false},
+		{versionRange: "^1.2 || >=3", version: "3.1.0", contains: true},
+		{versionRange: "~1.2", version: "1.3.0", contains: false},
+	}
+	for _, tc := range tt {
+		t.Run(tc.versionRange+" "+tc.version, func(t *testing.T) {
+			r, err := ParseRange(tc.versionRange)
+			assert.NoError(t, err)
+			v, err := ParseVersion(tc.version)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.contains, r.Contains(v))
+		})
+	}
+
+	_, err := ParseRange(">=banana")
+	assert.Error(t, err)
+}
+
+func TestParseRequirement(t *testing.T) {
+	r, err := ParseRequirement("foo >=1.2 <2")
+	assert.NoError(t, err)
+	assert.Equal(t, Requirement{PackageName: "foo", VersionRange: ">=1.2 <2"}, r)
+	assert.Equal(t, "foo >=1.2 <2", r.String())
+
+	_, err = ParseRequirement("  ")
+	assert.EqualError(t, err, "empty requirement")
+}
+
+func TestAddDependency(t *testing.T) {
+	ctx := context.Background()
+	problem := resolution.NewMutableResolutionProblem("test")
+	for _, version := range []string{"1.1.0", "1.2.0", "1.10.0", "2.0.0", "not-a-version"} {
+		v := variables.NewMutableVariable(deppy.Identifierf("foo-%s", version), "deppy.var.test", map[string]interface{}{
+			PackageProperty: "foo",
+			VersionProperty: version,
+		})
+		assert.NoError(t, problem.ActivateVariable(v))
+	}
+	assert.NoError(t, problem.ActivateVariable(variables.NewMutableVariable("bar-1.5.0", "deppy.var.test", map[string]interface{}{
+		PackageProperty: "bar",
+		VersionProperty: "1.5.0",
+	})))
+
+	app := variables.NewMutableVariable("app", "deppy.var.test", nil)
+	assert.NoError(t, app.AddMandatory("mandatory"))
+	assert.NoError(t, AddDependency(problem, app, "foo", Requirement{PackageName: "foo", VersionRange: ">=1.2 <2"}))
+	assert.NoError(t, problem.ActivateVariable(app))
+
+	c, _ := app.GetConstraint("foo")
+	assert.Equal(t, []deppy.Identifier{"foo-1.10.0", "foo-1.2.0"}, c.(*constraints.DependencyConstraint).Elements())
+
+	solution, err := resolver.NewDeppyResolver().Solve(ctx, problem)
+	assert.NoError(t, err)
+	assert.True(t, solution.IsSelected("foo-1.10.0"))
+	assert.False(t, solution.IsSelected("foo-1.2.0"))
+}
@@ -0,0 +1,79 @@
+package solver
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/go-air/gini/z"
+	"github.com/perdasilva/replee/pkg/deppy"
+	"io"
+	"sort"
+)
+
+// clauseRecorder collects the clauses added to it
+type clauseRecorder struct {
+	clauses [][]z.Lit
+	clause  []z.Lit
+}
+
+func (r *clauseRecorder) Add(m z.Lit) {
+	if m == z.LitNull {
+		r.clauses = append(r.clauses, r.clause)
+		r.clause = nil
+		return
+	}
+	r.clause = append(r.clause, m)
+}
+
+// WriteDIMACS writes the formula Solve teaches gini for the variables as a DIMACS CNF file. Solve assumes that every
+// constraint holds rather than adding it as a clause, so the constraint literals are written as unit clauses: the
+// formula is satisfiable if, and only if, Solve finds a solution. Order preferences and the minimisation of the number
+// of selected variables aren't part of the formula. Comments map DIMACS variables back to deppy variables,
+//
+//	c variable <dimacs variable> <quoted variable id>
+//
+// and the unit clauses of constraints back to the variable and constraint they come from.
+//
+//	c constraint <dimacs literal> <quoted variable id> <quoted constraint id>
+//
+// The remaining DIMACS variables are the gates of the circuit encoding the constraints.
+func WriteDIMACS(w io.Writer, variables []deppy.Variable) error {
+	lm, err := newLitMapping(variables)
+	if err != nil {
+		return err
+	}
+	recorder := &clauseRecorder{}
+	lm.AddConstraints(recorder)
+	if err := lm.Error(); err != nil {
+		return err
+	}
+
+	constraintLits := make([]z.Lit, 0, len(lm.constraints))
+	for m := range lm.constraints {
+		constraintLits = append(constraintLits, m)
+	}
+	sort.Slice(constraintLits, func(i, j int) bool {
+		return constraintLits[i] < constraintLits[j]
+	})
+
+	out := bufio.NewWriter(w)
+	fmt.Fprintf(out, "c deppy resolution problem with %d variables and %d constraints\n", len(variables), len(constraintLits))
+	for _, v := range variables {
+		fmt.Fprintf(out, "c variable %d %q\n", lm.lits[v.VariableID()].Var(), v.VariableID())
+	}
+	for _, m := range constraintLits {
+		applied := lm.constraints[m]
+		fmt.Fprintf(out, "c constraint %d %q %q\n", m.Dimacs(), applied.Variable.VariableID(), applied.Constraint.ConstraintID())
+	}
+
+	fmt.Fprintf(out, "p cnf %d %d\n", lm.c.Len()-1, len(recorder.clauses)+len(constraintLits))
+	for _, clause := range recorder.clauses {
+		for _, m := range clause {
+			fmt.Fprintf(out, "%d ", m.Dimacs())
+		}
+		fmt.Fprintln(out, "0")
+	}
+	for _, m := range constraintLits {
+		fmt.Fprintf(out, "%d 0\n", m.Dimacs())
+	}
+	return out.Flush()
+}
@@ -142,7 +142,7 @@ func (d *litMapping) Error() error {
 
 // AddConstraints adds the current constraints encoded in the embedded circuit to the
 // solver g
-func (d *litMapping) AddConstraints(g inter.S) {
+func (d *litMapping) AddConstraints(g inter.Adder) {
   d.c.ToCnf(g)
 }
 
@@ -161,6 +161,12 @@ func (h *search) Do(ctx context.Context, anchors []z.Lit) (int, []z.Lit, map[z.L
 	}
 
 	for {
+		// Give up once the context is done, leaving the result unknown.
+		if ctx.Err() != nil {
+			h.result = unknown
+			break
+		}
+
 		// Need to have a definitive result once all choices
 		// have been made to decide whether to end or
 		// backtrack.
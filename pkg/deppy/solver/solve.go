@@ -69,7 +69,7 @@ func (s *solver) Solve(ctx context.Context) (result []deppy.Variable, err error)
 	if outcome != satisfiable && outcome != unsatisfiable {
 		// searcher for solutions in input Order, so that preferences
 		// can be taken into acount (i.e. prefer one catalog to another)
-		outcome, assumptions, aset = (&search{s: s.g, lits: s.litMap, tracer: s.tracer}).Do(context.Background(), assumptions)
+		outcome, assumptions, aset = (&search{s: s.g, lits: s.litMap, tracer: s.tracer}).Do(ctx, assumptions)
 	}
 	switch outcome {
 	case satisfiable:
@@ -92,6 +92,9 @@ func (s *solver) Solve(ctx context.Context) (result []deppy.Variable, err error)
 		s.litMap.AssumeConstraints(s.g)
 		_, s.buffer = s.g.Test(s.buffer)
 		for w := 0; w <= cs.N(); w++ {
+			if ctx.Err() != nil {
+				return nil, ErrIncomplete
+			}
 			s.g.Assume(cs.Leq(w))
 			if s.g.Solve() == satisfiable {
 				return s.litMap.Variables(s.g), nil
@@ -375,6 +375,21 @@ func TestSolve(t *testing.T) {
   }
 }
 
+func TestSolveCancelled(t *testing.T) {
+  s, err := NewSolver(WithInput([]deppy.Variable{
+    variable("a", constraints.Mandatory("a"), constraints.Dependency("b or c", "b", "c")),
+    variable("b"),
+    variable("c"),
+  }))
+  assert.NoError(t, err)
+
+  ctx, cancel := context.WithCancel(context.Background())
+  cancel()
+  installed, err := s.Solve(ctx)
+  assert.Nil(t, installed)
+  assert.ErrorIs(t, err, ErrIncomplete)
+}
+
 func TestDuplicateIdentifier(t *testing.T) {
   _, err := NewSolver(WithInput([]deppy.Variable{
     variable("a"),
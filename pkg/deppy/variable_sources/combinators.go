@@ -0,0 +1,477 @@
+package variable_sources
+
+import (
+  "context"
+  "encoding/json"
+  "errors"
+  "github.com/perdasilva/replee/pkg/deppy"
+  "sync"
+  "time"
+)
+
+// Chain returns a variable source that offers each variable to the given sources in order and
+// stops at the first error. Each source is only offered the variables its own filter accepts.
+func Chain(variableSourceID deppy.Identifier, variableSources ...deppy.VariableSource) deppy.VariableSource {
+  return &compositeVariableSource{
+    variableSourceID: variableSourceID,
+    variableSources:  variableSources,
+  }
+}
+
+// FanOut returns a variable source that offers each variable to all the given sources, even if
+// some of them fail. A fatal error from any source takes precedence over the other errors.
+func FanOut(variableSourceID deppy.Identifier, variableSources ...deppy.VariableSource) deppy.VariableSource {
+  return &compositeVariableSource{
+    variableSourceID: variableSourceID,
+    variableSources:  variableSources,
+    fanOut:           true,
+  }
+}
+
+var _ deppy.VariableSource = &compositeVariableSource{}
+
+type compositeVariableSource struct {
+  variableSourceID deppy.Identifier
+  variableSources  []deppy.VariableSource
+  fanOut           bool
+}
+
+func (c *compositeVariableSource) VariableSourceID() deppy.Identifier {
+  return c.variableSourceID
+}
+
+func (c *compositeVariableSource) VariableFilterFunc() deppy.VarFilterFn {
+  return func(v deppy.Variable) bool {
+    for _, source := range c.variableSources {
+      if accepts(source, v) {
+        return true
+      }
+    }
+    return false
+  }
+}
+
+func (c *compositeVariableSource) Update(ctx context.Context, problem deppy.MutableResolutionProblem, variable deppy.MutableVariable) error {
+  return c.each(func(source deppy.VariableSource) error {
+    if !accepts(source, variable) {
+      return nil
+    }
+    return source.Update(ctx, problem, variable)
+  })
+}
+
+func (c *compositeVariableSource) Finalize(ctx context.Context, problem deppy.MutableResolutionProblem) error {
+  return c.each(func(source deppy.VariableSource) error {
+    return source.Finalize(ctx, problem)
+  })
+}
+
+func (c *compositeVariableSource) each(fn func(source deppy.VariableSource) error) error {
+  var errs []error
+  for _, source := range c.variableSources {
+    err := fn(source)
+    if err == nil {
+      continue
+    }
+    if !c.fanOut {
+      return err
+    }
+    if deppy.IsFatalError(err) {
+      return err
+    }
+    errs = append(errs, err)
+  }
+  return errors.Join(errs...)
+}
+
+// Filter returns a variable source that only offers the given source the variables matching the predicate.
+// The bootstrap (nil) variable is still offered if the source accepts it. Sources without a filter of their
+// own are offered every variable that matches the predicate.
+func Filter(source deppy.VariableSource, predicate deppy.VarFilterFn) deppy.VariableSource {
+  return &filteredVariableSource{
+    VariableSource: source,
+    predicate:      predicate,
+  }
+}
+
+// FilterByKind returns a variable source that only offers the given source variables of the given kinds
+func FilterByKind(source deppy.VariableSource, kinds ...string) deppy.VariableSource {
+  return Filter(source, func(v deppy.Variable) bool {
+    for _, kind := range kinds {
+      if v.Kind() == kind {
+        return true
+      }
+    }
+    return false
+  })
+}
+
+// FilterByProperty returns a variable source that only offers the given source variables that have
+// the property and whose value matches the predicate
+func FilterByProperty(source deppy.VariableSource, key string, predicate func(value interface{}) bool) deppy.VariableSource {
+  return Filter(source, func(v deppy.Variable) bool {
+    value, ok := v.GetProperty(key)
+    return ok && predicate(value)
+  })
+}
+
+type filteredVariableSource struct {
+  deppy.VariableSource
+  predicate deppy.VarFilterFn
+}
+
+func (f *filteredVariableSource) VariableFilterFunc() deppy.VarFilterFn {
+  filter := f.VariableSource.VariableFilterFunc()
+  return func(v deppy.Variable) bool {
+    if v == nil {
+      return accepts(f.VariableSource, nil)
+    }
+    return f.predicate(v) && (filter == nil || filter(v))
+  }
+}
+
+// Rename returns a variable source that renames the variables the given source adds to the problem,
+// together with the variables their constraints refer to. The given source keeps working with the
+// identifiers it knows: the variables it's offered, and those it gets from the problem, are renamed
+// back if they're variables it added, and the constraints it adds to them are renamed.
+func Rename(source deppy.VariableSource, rename func(id deppy.Identifier) deppy.Identifier) deppy.VariableSource {
+  return &renamingVariableSource{
+    VariableSource:   source,
+    variableSourceID: source.VariableSourceID(),
+    rename:           rename,
+    original:         map[deppy.Identifier]deppy.Identifier{},
+  }
+}
+
+// RenameIdentifiers returns a variable source that renames the identifiers found in the mapping
+// and leaves the others untouched
+func RenameIdentifiers(source deppy.VariableSource, mapping map[deppy.Identifier]deppy.Identifier) deppy.VariableSource {
+  return Rename(source, func(id deppy.Identifier) deppy.Identifier {
+    if renamed, ok := mapping[id]; ok {
+      return renamed
+    }
+    return id
+  })
+}
+
+// Prefix returns a variable source that prefixes the identifiers of the variables the given source
+// adds to the problem. The variable source id is prefixed as well, so the same source can be added
+// to a problem under different prefixes.
+func Prefix(source deppy.VariableSource, prefix string) deppy.VariableSource {
+  r := Rename(source, func(id deppy.Identifier) deppy.Identifier {
+    return deppy.Identifierf("%s%s", prefix, id)
+  }).(*renamingVariableSource)
+  r.variableSourceID = deppy.Identifierf("%s%s", prefix, source.VariableSourceID())
+  return r
+}
+
+type renamingVariableSource struct {
+  deppy.VariableSource
+  variableSourceID deppy.Identifier
+  rename           func(id deppy.Identifier) deppy.Identifier
+  lock             sync.Mutex
+  // original maps renamed identifiers back to the identifiers the source knows them by
+  original map[deppy.Identifier]deppy.Identifier
+}
+
+func (r *renamingVariableSource) VariableSourceID() deppy.Identifier {
+  return r.variableSourceID
+}
+
+func (r *renamingVariableSource) VariableFilterFunc() deppy.VarFilterFn {
+  filter := r.VariableSource.VariableFilterFunc()
+  if filter == nil {
+    return nil
+  }
+  return func(v deppy.Variable) bool {
+    if v == nil {
+      return filter(nil)
+    }
+    original, err := copyVariable(v, r.unrenamed)
+    return err == nil && filter(original)
+  }
+}
+
+func (r *renamingVariableSource) Update(ctx context.Context, problem deppy.MutableResolutionProblem, variable deppy.MutableVariable) error {
+  if variable != nil {
+    variable = r.view(variable, r.unrenamed(variable.VariableID()))
+  }
+  return r.VariableSource.Update(ctx, &renamingProblem{MutableResolutionProblem: problem, source: r}, variable)
+}
+
+func (r *renamingVariableSource) Finalize(ctx context.Context, problem deppy.MutableResolutionProblem) error {
+  return r.VariableSource.Finalize(ctx, &renamingProblem{MutableResolutionProblem: problem, source: r})
+}
+
+// renamed renames the identifier, remembering the original
+func (r *renamingVariableSource) renamed(id deppy.Identifier) deppy.Identifier {
+  renamed := r.rename(id)
+  r.lock.Lock()
+  defer r.lock.Unlock()
+  r.original[renamed] = id
+  return renamed
+}
+
+// unrenamed returns the identifier the source knows a renamed identifier by, identifiers the
+// source hasn't renamed are returned as they are
+func (r *renamingVariableSource) unrenamed(id deppy.Identifier) deppy.Identifier {
+  r.lock.Lock()
+  defer r.lock.Unlock()
+  if original, ok := r.original[id]; ok {
+    return original
+  }
+  return id
+}
+
+// view returns the variable of the problem as the source knows it, by the original identifier
+func (r *renamingVariableSource) view(v deppy.MutableVariable, variableID deppy.Identifier) deppy.MutableVariable {
+  return &renamedVariable{MutableVariable: v, variableID: variableID, source: r}
+}
+
+type renamingProblem struct {
+  deppy.MutableResolutionProblem
+  source *renamingVariableSource
+}
+
+func (r *renamingProblem) ActivateVariable(v deppy.MutableVariable) error {
+  if v == nil {
+    return nil
+  }
+  renamed, err := copyVariable(v, r.source.renamed)
+  if err != nil {
+    return err
+  }
+  return r.MutableResolutionProblem.ActivateVariable(renamed)
+}
+
+func (r *renamingProblem) DeactivateVariable(variableID deppy.Identifier, kind string) error {
+  return r.MutableResolutionProblem.DeactivateVariable(r.source.renamed(variableID), kind)
+}
+
+func (r *renamingProblem) GetMutableVariable(variableID deppy.Identifier, kind string) (deppy.MutableVariable, error) {
+  v, err := r.MutableResolutionProblem.GetMutableVariable(r.source.renamed(variableID), kind)
+  if err != nil {
+    return nil, err
+  }
+  return r.source.view(v, variableID), nil
+}
+
+// renamedVariable is a variable of the problem seen by a renamed source: it has the identifier the
+// source knows it by, its constraints refer to the variables by the identifiers the source knows
+// them by, and the constraints the source adds to it are renamed
+type renamedVariable struct {
+  deppy.MutableVariable
+  variableID deppy.Identifier
+  source     *renamingVariableSource
+}
+
+func (v *renamedVariable) VariableID() deppy.Identifier {
+  return v.variableID
+}
+
+func (v *renamedVariable) original() deppy.Variable {
+  original, err := copyVariable(v.MutableVariable, v.source.unrenamed)
+  if err != nil {
+    return v.MutableVariable
+  }
+  return original
+}
+
+func (v *renamedVariable) Constraints() []deppy.Constraint {
+  return v.original().Constraints()
+}
+
+func (v *renamedVariable) GetConstraint(constraintID deppy.Identifier) (deppy.Constraint, bool) {
+  return v.original().GetConstraint(constraintID)
+}
+
+func (v *renamedVariable) Merge(other deppy.Variable) (bool, error) {
+  renamed, err := copyVariable(other, v.source.renamed)
+  if err != nil {
+    return false, err
+  }
+  return v.MutableVariable.Merge(renamed)
+}
+
+func (v *renamedVariable) AddConflict(constraintID, variableID deppy.Identifier) error {
+  return v.MutableVariable.AddConflict(constraintID, v.source.renamed(variableID))
+}
+
+func (v *renamedVariable) AddDependency(constraintID deppy.Identifier, variableIDs ...deppy.Identifier) error {
+  return v.MutableVariable.AddDependency(constraintID, v.renamedAll(variableIDs)...)
+}
+
+func (v *renamedVariable) RemoveDependency(constraintID deppy.Identifier, variableIDs ...deppy.Identifier) error {
+  return v.MutableVariable.RemoveDependency(constraintID, v.renamedAll(variableIDs)...)
+}
+
+func (v *renamedVariable) AddAtMost(constraintID deppy.Identifier, n int, variableIDs ...deppy.Identifier) error {
+  return v.MutableVariable.AddAtMost(constraintID, n, v.renamedAll(variableIDs)...)
+}
+
+func (v *renamedVariable) RemoveAtMost(constraintID deppy.Identifier, variableIDs ...deppy.Identifier) error {
+  return v.MutableVariable.RemoveAtMost(constraintID, v.renamedAll(variableIDs)...)
+}
+
+func (v *renamedVariable) renamedAll(variableIDs []deppy.Identifier) []deppy.Identifier {
+  renamed := make([]deppy.Identifier, len(variableIDs))
+  for i, id := range variableIDs {
+    renamed[i] = v.source.renamed(id)
+  }
+  return renamed
+}
+
+// RateLimit returns a variable source that calls Update on the given source at most n times per second.
+// Waiting for the next call is cut short if the context is done. A non-positive n disables the limit.
+func RateLimit(source deppy.VariableSource, n int) deppy.VariableSource {
+  if n <= 0 {
+    return source
+  }
+  return &rateLimitedVariableSource{
+    VariableSource: source,
+    interval:       time.Second / time.Duration(n),
+  }
+}
+
+type rateLimitedVariableSource struct {
+  deppy.VariableSource
+  interval time.Duration
+  lock     sync.Mutex
+  // next is the earliest time of the next call, calls reserve their time before waiting for it
+  next time.Time
+}
+
+func (r *rateLimitedVariableSource) Update(ctx context.Context, problem deppy.MutableResolutionProblem, variable deppy.MutableVariable) error {
+  r.lock.Lock()
+  at := time.Now()
+  if r.next.After(at) {
+    at = r.next
+  }
+  r.next = at.Add(r.interval)
+  r.lock.Unlock()
+
+  if wait := time.Until(at); wait > 0 {
+    timer := time.NewTimer(wait)
+    defer timer.Stop()
+    select {
+    case <-ctx.Done():
+      return ctx.Err()
+    case <-timer.C:
+    }
+  }
+  return r.VariableSource.Update(ctx, problem, variable)
+}
+
+// Memoize returns a variable source that remembers what the given source activated and deactivated
+// when updated with a variable, and replays it instead of calling the source when later builds offer
+// it the same variable. Changes made to the updated variable itself are remembered too, other in-place
+// changes (e.g. to variables fetched with GetMutableVariable) are not. Finalize is always called.
+func Memoize(source deppy.VariableSource) deppy.VariableSource {
+  return &memoizingVariableSource{
+    VariableSource: source,
+    memo:           map[string][]memoEntry{},
+  }
+}
+
+type memoEntry struct {
+  activate     deppy.MutableVariable
+  deactivateID deppy.Identifier
+  kind         string
+}
+
+type memoizingVariableSource struct {
+  deppy.VariableSource
+  lock sync.Mutex
+  memo map[string][]memoEntry
+}
+
+func (m *memoizingVariableSource) Update(ctx context.Context, problem deppy.MutableResolutionProblem, variable deppy.MutableVariable) error {
+  key, err := memoKey(variable)
+  if err != nil {
+    return err
+  }
+
+  m.lock.Lock()
+  entries, ok := m.memo[key]
+  m.lock.Unlock()
+  if ok {
+    return replay(problem, entries)
+  }
+
+  recorder := &recordingProblem{MutableResolutionProblem: problem}
+  if err := m.VariableSource.Update(ctx, recorder, variable); err != nil {
+    return err
+  }
+  if variable != nil {
+    if err := recorder.record(variable); err != nil {
+      return err
+    }
+  }
+
+  m.lock.Lock()
+  defer m.lock.Unlock()
+  m.memo[key] = recorder.entries
+  return nil
+}
+
+func memoKey(variable deppy.Variable) (string, error) {
+  if variable == nil {
+    return "", nil
+  }
+  key, err := json.Marshal(variable)
+  if err != nil {
+    return "", err
+  }
+  return string(key), nil
+}
+
+func replay(problem deppy.MutableResolutionProblem, entries []memoEntry) error {
+  for _, entry := range entries {
+    if entry.activate == nil {
+      if err := problem.DeactivateVariable(entry.deactivateID, entry.kind); err != nil {
+        return err
+      }
+      continue
+    }
+    v, err := copyVariable(entry.activate, identity)
+    if err != nil {
+      return err
+    }
+    if err := problem.ActivateVariable(v); err != nil {
+      return err
+    }
+  }
+  return nil
+}
+
+type recordingProblem struct {
+  deppy.MutableResolutionProblem
+  entries []memoEntry
+}
+
+func (r *recordingProblem) ActivateVariable(v deppy.MutableVariable) error {
+  if err := r.MutableResolutionProblem.ActivateVariable(v); err != nil {
+    return err
+  }
+  if v == nil {
+    return nil
+  }
+  return r.record(v)
+}
+
+func (r *recordingProblem) DeactivateVariable(variableID deppy.Identifier, kind string) error {
+  if err := r.MutableResolutionProblem.DeactivateVariable(variableID, kind); err != nil {
+    return err
+  }
+  r.entries = append(r.entries, memoEntry{deactivateID: variableID, kind: kind})
+  return nil
+}
+
+func (r *recordingProblem) record(v deppy.Variable) error {
+  c, err := copyVariable(v, identity)
+  if err != nil {
+    return err
+  }
+  r.entries = append(r.entries, memoEntry{activate: c})
+  return nil
+}
@@ -0,0 +1,177 @@
+package variable_sources_test
+
+import (
+  "context"
+  "errors"
+  "github.com/perdasilva/replee/pkg/deppy"
+  "github.com/perdasilva/replee/pkg/deppy/constraints"
+  "github.com/perdasilva/replee/pkg/deppy/resolution"
+  . "github.com/perdasilva/replee/pkg/deppy/variable_sources"
+  "github.com/perdasilva/replee/pkg/deppy/variables"
+  "github.com/stretchr/testify/assert"
+  "sort"
+  "sync"
+  "testing"
+  "time"
+)
+
+func activating(variableSourceID deppy.Identifier, variableID deppy.Identifier, kind string, calls *int) deppy.VariableSource {
+  return NewVariableSourceBuilder(variableSourceID).
+    WithUpdateFn(func(ctx context.Context, problem deppy.MutableResolutionProblem, variable deppy.MutableVariable) error {
+      *calls++
+      v := variables.NewMutableVariable(variableID, kind, map[string]interface{}{"source": string(variableSourceID)})
+      if err := v.AddDependency("dependency", "target"); err != nil {
+        return err
+      }
+      return problem.ActivateVariable(v)
+    }).Build(context.Background())
+}
+
+func failing(variableSourceID deppy.Identifier, err error, calls *int) deppy.VariableSource {
+  return NewVariableSourceBuilder(variableSourceID).
+    WithUpdateFn(func(ctx context.Context, problem deppy.MutableResolutionProblem, variable deppy.MutableVariable) error {
+      *calls++
+      return err
+    }).Build(context.Background())
+}
+
+func TestChainAndFanOut(t *testing.T) {
+  ctx := context.Background()
+  boom := errors.New("boom")
+
+  var calls int
+  problem := resolution.NewMutableResolutionProblem("test")
+  err := Chain("chain", failing("a", boom, &calls), failing("b", boom, &calls)).Update(ctx, problem, nil)
+  assert.Equal(t, boom, err)
+  assert.Equal(t, 1, calls)
+
+  calls = 0
+  err = FanOut("fan-out", failing("a", boom, &calls), failing("b", boom, &calls)).Update(ctx, problem, nil)
+  assert.ErrorIs(t, err, boom)
+  assert.Equal(t, 2, calls)
+
+  calls = 0
+  err = FanOut("fan-out", failing("a", boom, &calls), failing("b", deppy.Fatalf("fatal"), &calls)).Update(ctx, problem, nil)
+  assert.True(t, deppy.IsFatalError(err))
+  assert.Equal(t, 2, calls)
+}
+
+func TestFilterByKind(t *testing.T) {
+  var calls int
+  source := FilterByKind(activating("a", "a", "deppy.var.test", &calls), "deppy.var.wanted")
+  filter := source.VariableFilterFunc()
+  assert.True(t, filter(nil))
+  assert.True(t, filter(variables.NewMutableVariable("wanted", "deppy.var.wanted", nil)))
+  assert.False(t, filter(variables.NewMutableVariable("unwanted", "deppy.var.unwanted", nil)))
+}
+
+func TestPrefix(t *testing.T) {
+  ctx := context.Background()
+  var calls int
+  problem, err := resolution.NewResolutionProblemBuilder("test").
+    WithVariableSources(
+      Prefix(activating("a", "a", "deppy.var.test", &calls), "left/"),
+      Prefix(activating("a", "a", "deppy.var.test", &calls), "right/"),
+    ).Build(ctx)
+  assert.NoError(t, err)
+
+  mp := problem.(deppy.MutableResolutionProblem)
+  for _, prefix := range []string{"left/", "right/"} {
+    v, err := mp.GetMutableVariable(deppy.Identifierf("%sa", prefix), "deppy.var.test")
+    assert.NoError(t, err)
+    c, ok := v.GetConstraint("dependency")
+    assert.True(t, ok)
+    assert.Equal(t, []deppy.Identifier{deppy.Identifierf("%starget", prefix)}, c.(*constraints.DependencyConstraint).Elements())
+  }
+}
+
+func TestPrefix_MutableVariables(t *testing.T) {
+  ctx := context.Background()
+  // the source knows its variables by their original ids, in its filter as well as in updates
+  source := NewVariableSourceBuilder("source").
+    WithVariableFilterFn(func(v deppy.Variable) bool {
+      return v == nil || v.VariableID() == "a"
+    }).
+    WithUpdateFn(func(ctx context.Context, problem deppy.MutableResolutionProblem, variable deppy.MutableVariable) error {
+      if variable == nil {
+        return problem.ActivateVariable(variables.NewMutableVariable("a", "deppy.var.test", map[string]interface{}{"n": 1}))
+      }
+      if err := variable.AddConflict("conflict", "c"); err != nil {
+        return err
+      }
+      v, err := problem.GetMutableVariable("a", "deppy.var.test")
+      if err != nil {
+        return err
+      }
+      if v.VariableID() != "a" {
+        return errors.New("the variable isn't known by its original id")
+      }
+      return v.AddDependency("dependency", "b")
+    }).Build(ctx)
+
+  problem, err := resolution.NewResolutionProblemBuilder("test").
+    WithVariableSources(Prefix(source, "p/")).
+    Build(ctx)
+  assert.NoError(t, err)
+
+  mp := problem.(deppy.MutableResolutionProblem)
+  v, err := mp.GetMutableVariable("p/a", "deppy.var.test")
+  assert.NoError(t, err)
+  c, ok := v.GetConstraint("dependency")
+  assert.True(t, ok)
+  assert.Equal(t, []deppy.Identifier{"p/b"}, c.(*constraints.DependencyConstraint).Elements())
+  c, ok = v.GetConstraint("conflict")
+  assert.True(t, ok)
+  assert.Equal(t, deppy.Identifier("p/c"), c.(*constraints.ConflictConstraint).ConflictingVariableID())
+}
+
+func TestMemoize(t *testing.T) {
+  ctx := context.Background()
+  var calls int
+  source := Memoize(activating("a", "a", "deppy.var.test", &calls))
+
+  for i := 0; i < 3; i++ {
+    problem, err := resolution.NewResolutionProblemBuilder("test").WithVariableSources(source).Build(ctx)
+    assert.NoError(t, err)
+    vars, err := problem.GetVariables()
+    assert.NoError(t, err)
+    assert.Len(t, vars, 1)
+    assert.Equal(t, deppy.Identifier("a"), vars[0].VariableID())
+  }
+  assert.Equal(t, 1, calls)
+}
+
+func TestRateLimit(t *testing.T) {
+  ctx := context.Background()
+  var lock sync.Mutex
+  var calls []time.Time
+  source := RateLimit(NewVariableSourceBuilder("source").
+    WithUpdateFn(func(ctx context.Context, problem deppy.MutableResolutionProblem, variable deppy.MutableVariable) error {
+      lock.Lock()
+      defer lock.Unlock()
+      calls = append(calls, time.Now())
+      return nil
+    }).Build(ctx), 20)
+
+  // concurrent updates each get a time of their own
+  start := time.Now()
+  var wg sync.WaitGroup
+  for i := 0; i < 3; i++ {
+    wg.Add(1)
+    go func() {
+      defer wg.Done()
+      assert.NoError(t, source.Update(ctx, resolution.NewMutableResolutionProblem("test"), nil))
+    }()
+  }
+  wg.Wait()
+  sort.Slice(calls, func(i, j int) bool {
+    return calls[i].Before(calls[j])
+  })
+  assert.Len(t, calls, 3)
+  assert.GreaterOrEqual(t, calls[1].Sub(start), 50*time.Millisecond)
+  assert.GreaterOrEqual(t, calls[2].Sub(start), 100*time.Millisecond)
+
+  canceled, cancel := context.WithCancel(ctx)
+  cancel()
+  assert.ErrorIs(t, source.Update(canceled, resolution.NewMutableResolutionProblem("test"), nil), context.Canceled)
+}
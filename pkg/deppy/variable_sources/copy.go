@@ -0,0 +1,93 @@
+package variable_sources
+
+import (
+  "github.com/perdasilva/replee/pkg/deppy"
+  "github.com/perdasilva/replee/pkg/deppy/constraints"
+  "github.com/perdasilva/replee/pkg/deppy/variables"
+)
+
+func identity(id deppy.Identifier) deppy.Identifier {
+  return id
+}
+
+// copyVariable returns a copy of the variable with its identifier, and the identifiers its constraints
+// refer to, renamed. Only the activated entries of dependency and at most constraints are copied.
+func copyVariable(v deppy.Variable, rename func(id deppy.Identifier) deppy.Identifier) (deppy.MutableVariable, error) {
+  properties := map[string]interface{}{}
+  for key, value := range v.GetProperties() {
+    properties[key] = value
+  }
+  out := variables.NewMutableVariable(rename(v.VariableID()), v.Kind(), properties)
+  for _, constraintID := range v.GetConstraintIDs() {
+    c, _ := v.GetConstraint(constraintID)
+    activated, err := v.IsActivated(constraintID)
+    if err != nil {
+      return nil, err
+    }
+    if err := copyConstraint(out, constraintID, c, activated, rename); err != nil {
+      return nil, err
+    }
+  }
+  return out, nil
+}
+
+func copyConstraint(v deppy.MutableVariable, constraintID deppy.Identifier, c deppy.Constraint, activated bool, rename func(id deppy.Identifier) deppy.Identifier) error {
+  switch c := c.(type) {
+  case *constraints.MandatoryConstraint:
+    if activated {
+      return v.AddMandatory(constraintID)
+    }
+    return v.RemoveMandatory(constraintID)
+  case *constraints.ProhibitedConstraint:
+    if activated {
+      return v.AddProhibited(constraintID)
+    }
+    return v.RemoveProhibited(constraintID)
+  case *constraints.ConflictConstraint:
+    if activated {
+      return v.AddConflict(constraintID, rename(c.ConflictingVariableID()))
+    }
+    if err := v.RemoveConflict(constraintID); err != nil {
+      return err
+    }
+    removed, _ := v.GetConstraint(constraintID)
+    return removed.(*constraints.ConflictConstraint).SetConflictingVariableID(rename(c.ConflictingVariableID()))
+  case *constraints.DependencyConstraint:
+    ids := renameAll(c.Elements(), rename)
+    if activated {
+      return v.AddDependency(constraintID, ids...)
+    }
+    if err := v.RemoveDependency(constraintID); err != nil {
+      return err
+    }
+    removed, _ := v.GetConstraint(constraintID)
+    removed.(*constraints.DependencyConstraint).Activate(ids...)
+    return nil
+  case *constraints.AtMostConstraint:
+    ids := renameAll(c.Elements(), rename)
+    if activated {
+      return v.AddAtMost(constraintID, c.N(), ids...)
+    }
+    if err := v.RemoveAtMost(constraintID); err != nil {
+      return err
+    }
+    if c.N() >= 0 {
+      if err := v.SetAtMostN(constraintID, c.N()); err != nil {
+        return err
+      }
+    }
+    removed, _ := v.GetConstraint(constraintID)
+    removed.(*constraints.AtMostConstraint).Activate(ids...)
+    return nil
+  default:
+    return deppy.Fatalf("cannot copy constraint %s of unknown kind %s", constraintID, c.Kind())
+  }
+}
+
+func renameAll(ids []deppy.Identifier, rename func(id deppy.Identifier) deppy.Identifier) []deppy.Identifier {
+  out := make([]deppy.Identifier, len(ids))
+  for i, id := range ids {
+    out[i] = rename(id)
+  }
+  return out
+}
@@ -10,13 +10,19 @@ type FilterableVariableSource struct {
 }
 
 func (f *FilterableVariableSource) Update(ctx context.Context, problem deppy.MutableResolutionProblem, variable deppy.MutableVariable) error {
-  filter := f.VariableFilterFunc()
-  if (filter == nil && variable == nil) || (filter != nil && filter(variable)) {
+  if accepts(f.VariableSource, variable) {
     return f.VariableSource.Update(ctx, problem, variable)
   }
   return nil
 }
 
+// accepts returns true if the variable source wants to be updated with the variable
+// sources without a filter only take the nil (bootstrap) variable
+func accepts(source deppy.VariableSource, variable deppy.Variable) bool {
+  filter := source.VariableFilterFunc()
+  return (filter == nil && variable == nil) || (filter != nil && filter(variable))
+}
+
 type AtMostOnceVariableSource struct {
   deppy.VariableSource
   successfullyProcessedVars map[deppy.Identifier]struct{}
@@ -0,0 +1,170 @@
+package variable_sources
+
+import (
+  "context"
+  "encoding/json"
+  "fmt"
+  "github.com/perdasilva/replee/pkg/deppy"
+  "github.com/perdasilva/replee/pkg/deppy/constraints"
+  "github.com/perdasilva/replee/pkg/deppy/variables"
+  "gopkg.in/yaml.v3"
+  "os"
+  "strings"
+)
+
+// StaticDocument declares a fixed set of variables and their constraints
+type StaticDocument struct {
+  Variables []StaticVariable `json:"variables"`
+}
+
+type StaticVariable struct {
+  ID          deppy.Identifier       `json:"id"`
+  Kind        string                 `json:"kind"`
+  Properties  map[string]interface{} `json:"properties,omitempty"`
+  Constraints []StaticConstraint     `json:"constraints,omitempty"`
+}
+
+// StaticConstraint declares a constraint on a variable. Kind is either a constraint kind
+// (e.g. deppy.constraint.dependency) or its short name: mandatory, prohibited, conflict,
+// dependency or atMost. Variables lists the dependencies, the at most candidates, or the
+// single conflicting variable. N is only used by atMost.
+type StaticConstraint struct {
+  ID        deppy.Identifier   `json:"id"`
+  Kind      string             `json:"kind"`
+  Variables []deppy.Identifier `json:"variables,omitempty"`
+  N         int                `json:"n,omitempty"`
+}
+
+// ParseStaticDocument parses a JSON or YAML static document
+func ParseStaticDocument(data []byte) (*StaticDocument, error) {
+  // YAML is a superset of JSON, going through JSON makes both formats
+  // yield the same property value types (e.g. float64 for numbers)
+  var raw interface{}
+  if err := yaml.Unmarshal(data, &raw); err != nil {
+    return nil, err
+  }
+  jsonBytes, err := json.Marshal(raw)
+  if err != nil {
+    return nil, err
+  }
+  document := &StaticDocument{}
+  if err := json.Unmarshal(jsonBytes, document); err != nil {
+    return nil, err
+  }
+  return document, nil
+}
+
+// NewStaticVariableSource returns a variable source that activates the variables of the
+// document when it is offered the bootstrap (nil) variable
+func NewStaticVariableSource(variableSourceID deppy.Identifier, document *StaticDocument) (deppy.VariableSource, error) {
+  s := &staticVariableSource{
+    variableSourceID: variableSourceID,
+    document:         document,
+  }
+  // fail early on invalid documents rather than during Build
+  if _, err := s.variables(); err != nil {
+    return nil, err
+  }
+  return s, nil
+}
+
+// NewStaticVariableSourceFromFile reads a JSON or YAML static document from path and returns
+// a static variable source identified by the path
+func NewStaticVariableSourceFromFile(path string) (deppy.VariableSource, error) {
+  data, err := os.ReadFile(path)
+  if err != nil {
+    return nil, err
+  }
+  document, err := ParseStaticDocument(data)
+  if err != nil {
+    return nil, fmt.Errorf("error parsing %s: %w", path, err)
+  }
+  return NewStaticVariableSource(deppy.Identifier(path), document)
+}
+
+var _ deppy.VariableSource = &staticVariableSource{}
+
+type staticVariableSource struct {
+  variableSourceID deppy.Identifier
+  document         *StaticDocument
+}
+
+func (s *staticVariableSource) VariableSourceID() deppy.Identifier {
+  return s.variableSourceID
+}
+
+func (s *staticVariableSource) VariableFilterFunc() deppy.VarFilterFn {
+  return nil
+}
+
+func (s *staticVariableSource) Update(_ context.Context, problem deppy.MutableResolutionProblem, variable deppy.MutableVariable) error {
+  if variable != nil {
+    return nil
+  }
+  // fresh variables on every build, so builds don't share state
+  vars, err := s.variables()
+  if err != nil {
+    return err
+  }
+  for _, v := range vars {
+    if err := problem.ActivateVariable(v); err != nil {
+      return err
+    }
+  }
+  return nil
+}
+
+func (s *staticVariableSource) Finalize(_ context.Context, _ deppy.MutableResolutionProblem) error {
+  return nil
+}
+
+func (s *staticVariableSource) variables() ([]deppy.MutableVariable, error) {
+  vars := make([]deppy.MutableVariable, 0, len(s.document.Variables))
+  for _, spec := range s.document.Variables {
+    if spec.ID == "" {
+      return nil, deppy.Fatalf("variable without id in %s", s.variableSourceID)
+    }
+    properties := map[string]interface{}{}
+    for key, value := range spec.Properties {
+      properties[key] = value
+    }
+    v := variables.NewMutableVariable(spec.ID, spec.Kind, properties)
+    for _, c := range spec.Constraints {
+      if err := addStaticConstraint(v, c); err != nil {
+        return nil, err
+      }
+    }
+    vars = append(vars, v)
+  }
+  return vars, nil
+}
+
+func addStaticConstraint(v deppy.MutableVariable, c StaticConstraint) error {
+  if c.ID == "" {
+    return deppy.Fatalf("constraint without id on variable %s", v.VariableID())
+  }
+  kind := c.Kind
+  if !strings.HasPrefix(kind, "deppy.constraint.") {
+    kind = "deppy.constraint." + strings.ToLower(kind)
+  }
+  switch kind {
+  case constraints.ConstraintKindMandatory:
+    return v.AddMandatory(c.ID)
+  case constraints.ConstraintKindProhibited:
+    return v.AddProhibited(c.ID)
+  case constraints.ConstraintKindConflict:
+    if len(c.Variables) != 1 {
+      return deppy.Fatalf("conflict constraint %s on variable %s must name exactly one variable", c.ID, v.VariableID())
+    }
+    return v.AddConflict(c.ID, c.Variables[0])
+  case constraints.ConstraintKindDependency:
+    return v.AddDependency(c.ID, c.Variables...)
+  case constraints.ConstraintKindAtMost:
+    if c.N < 0 {
+      return deppy.Fatalf("at most constraint %s on variable %s must have n >= 0", c.ID, v.VariableID())
+    }
+    return v.AddAtMost(c.ID, c.N, c.Variables...)
+  default:
+    return deppy.Fatalf("unknown constraint kind %s for constraint %s on variable %s", c.Kind, c.ID, v.VariableID())
+  }
+}
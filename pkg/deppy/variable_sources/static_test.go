@@ -0,0 +1,93 @@
+package variable_sources_test
+
+import (
+  "context"
+  "github.com/perdasilva/replee/pkg/deppy"
+  "github.com/perdasilva/replee/pkg/deppy/constraints"
+  "github.com/perdasilva/replee/pkg/deppy/resolution"
+  "github.com/perdasilva/replee/pkg/deppy/resolver"
+  . "github.com/perdasilva/replee/pkg/deppy/variable_sources"
+  "github.com/stretchr/testify/assert"
+  "testing"
+)
+
+func TestStaticVariableSource(t *testing.T) {
+  tt := []struct {
+    name     string
+    document string
+  }{
+    {
+      name: "yaml",
+      document: `
+variables:
+  - id: a
+    kind: deppy.var.test
+    properties:
+      version: 1
+    constraints:
+      - id: mandatory
+        kind: mandatory
+      - id: dependency
+        kind: dependency
+        variables: [b, c]
+  - id: b
+    kind: deppy.var.test
+    constraints:
+      - id: conflict
+        kind: deppy.constraint.conflict
+        variables: [c]
+  - id: c
+    kind: deppy.var.test
+    constraints:
+      - id: at-most
+        kind: atMost
+        n: 1
+        variables: [b, c]
+`,
+    }, {
+      name: "json",
+      document: `{"variables": [
+  {"id": "a", "kind": "deppy.var.test", "properties": {"version": 1}, "constraints": [
+    {"id": "mandatory", "kind": "mandatory"},
+    {"id": "dependency", "kind": "dependency", "variables": ["b", "c"]}
+  ]},
+  {"id": "b", "kind": "deppy.var.test", "constraints": [{"id": "conflict", "kind": "deppy.constraint.conflict", "variables": ["c"]}]},
+  {"id": "c", "kind": "deppy.var.test", "constraints": [{"id": "at-most", "kind": "atMost", "n": 1, "variables": ["b", "c"]}]}
+]}`,
+    },
+  }
+
+  for _, tc := range tt {
+    t.Run(tc.name, func(t *testing.T) {
+      ctx := context.Background()
+      document, err := ParseStaticDocument([]byte(tc.document))
+      assert.NoError(t, err)
+      source, err := NewStaticVariableSource("static", document)
+      assert.NoError(t, err)
+
+      problem, err := resolution.NewResolutionProblemBuilder("test").WithVariableSources(source).Build(ctx)
+      assert.NoError(t, err)
+
+      a, err := problem.(deppy.MutableResolutionProblem).GetMutableVariable("a", "deppy.var.test")
+      assert.NoError(t, err)
+      version, _ := a.GetProperty("version")
+      assert.Equal(t, float64(1), version)
+      c, _ := a.GetConstraint("dependency")
+      assert.Equal(t, []deppy.Identifier{"b", "c"}, c.(*constraints.DependencyConstraint).Elements())
+
+      solution, err := resolver.NewDeppyResolver().Solve(ctx, problem)
+      assert.NoError(t, err)
+      assert.Empty(t, solution.NotSatisfiable())
+      assert.True(t, solution.IsSelected("a"))
+      assert.True(t, solution.IsSelected("b"))
+      assert.False(t, solution.IsSelected("c"))
+    })
+  }
+}
+
+func TestStaticVariableSource_InvalidDocument(t *testing.T) {
+  document, err := ParseStaticDocument([]byte(`{"variables": [{"id": "a", "kind": "k", "constraints": [{"id": "c", "kind": "unknown"}]}]}`))
+  assert.NoError(t, err)
+  _, err = NewStaticVariableSource("static", document)
+  assert.EqualError(t, err, "unknown constraint kind unknown for constraint c on variable a")
+}
@@ -0,0 +1,127 @@
+package repl
+
+import (
+	"github.com/dop251/goja"
+	"sort"
+	"strings"
+)
+
+// Complete returns the completions of the identifier, or property access chain such as deppy.sources.st, that ends
+// the text, along with the offset in the text where the completed name starts. Completions are the names of the
+// global object and the declared names that are initialized, or the properties of the object the chain leads to,
+// including its prototypes' and, for Go values, the fields and methods named by the field name mapper of the runtime.
+// The chain is walked with name and property lookups only, no function is ever called. The declarations may be nil.
+func Complete(vm *goja.Runtime, declarations *Declarations, text string) (int, []string) {
+	start := len(text)
+	for start > 0 && isIdentifierPart(text[start-1], true) {
+		start--
+	}
+	expression := text[start:]
+	if start > 0 && text[start-1] == ')' || strings.HasPrefix(expression, ".") {
+		return len(text), nil
+	}
+
+	object := vm.GlobalObject()
+	path := strings.Split(expression, ".")
+	partial := path[len(path)-1]
+	if len(path) == 1 {
+		return start, declaredNames(vm, declarations, matchingNames(vm, object, partial), partial)
+	}
+	for i, name := range path[:len(path)-1] {
+		if name == "" {
+			return len(text), nil
+		}
+		var value goja.Value
+		if i == 0 {
+			value = lookup(vm, name)
+		} else {
+			value = object.Get(name)
+		}
+		if value == nil || goja.IsUndefined(value) || goja.IsNull(value) {
+			return len(text), nil
+		}
+		object = value.ToObject(vm)
+	}
+	return len(text) - len(partial), matchingNames(vm, object, partial)
+}
+
+// lookup returns the value of the name in the global scope, or nil if it isn't defined. Names that aren't properties
+// of the global object, such as those declared by let, are evaluated, which is safe for a plain identifier.
+func lookup(vm *goja.Runtime, name string) goja.Value {
+	if value := vm.GlobalObject().Get(name); value != nil {
+		return value
+	}
+	if !isIdentifier(name) {
+		return nil
+	}
+	value, err := vm.RunString(name)
+	if err != nil {
+		return nil
+	}
+	return value
+}
+
+// declaredNames adds the declared names that start with the partial name to the names, leaving out those that
+// aren't initialized, like a declaration whose command threw before reaching it
+func declaredNames(vm *goja.Runtime, declarations *Declarations, names []string, partial string) []string {
+	seen := map[string]struct{}{}
+	for _, name := range names {
+		seen[name] = struct{}{}
+	}
+	for _, name := range declarations.Names() {
+		if _, ok := seen[name]; ok || !strings.HasPrefix(name, partial) || lookup(vm, name) == nil {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// matchingNames returns the names of the properties of the object and its prototypes, enumerable or not,
+// that start with the partial name
+func matchingNames(vm *goja.Runtime, object *goja.Object, partial string) []string {
+	seen := map[string]struct{}{}
+	var names []string
+	for o := object; o != nil; o = o.Prototype() {
+		for _, name := range ownPropertyNames(vm, o) {
+			if _, ok := seen[name]; ok || !strings.HasPrefix(name, partial) || strings.HasPrefix(name, "__") {
+				continue
+			}
+			seen[name] = struct{}{}
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ownPropertyNames returns the names of the own properties of the object, enumerable or not
+func ownPropertyNames(vm *goja.Runtime, object *goja.Object) []string {
+	getOwnPropertyNames, ok := goja.AssertFunction(vm.Get("Object").ToObject(vm).Get("getOwnPropertyNames"))
+	if !ok {
+		return nil
+	}
+	var names []string
+	if value, err := getOwnPropertyNames(goja.Undefined(), object); err == nil {
+		_ = vm.ExportTo(value, &names)
+	}
+	return names
+}
+
+func isIdentifier(name string) bool {
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if !isIdentifierPart(name[i], false) {
+			return false
+		}
+	}
+	return true
+}
+
+func isIdentifierPart(c byte, allowDot bool) bool {
+	return c == '_' || c == '$' || (allowDot && c == '.') ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,70 @@
+package repl
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dop251/goja"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComplete(t *testing.T) {
+	vm := goja.New()
+	vm.SetFieldNameMapper(goja.TagFieldNameMapper("json", true))
+	assert.NoError(t, BootstrapRepleeVM(context.Background(), vm))
+	_, err := vm.RunString(`var problemA = 1, problemB = {size: 2, __hidden: 3}; function f() { return {x: 1} }`)
+	assert.NoError(t, err)
+
+	for _, tt := range []struct {
+		text        string
+		start       int
+		completions []string
+	}{
+		{text: "prob", start: 0, completions: []string{"problemA", "problemB"}},
+		{text: "x = problemB.s", start: 13, completions: []string{"size"}},
+		// properties of the prototypes, not enumerable ones included
+		{text: "problemB.hasOwn", start: 9, completions: []string{"hasOwnProperty"}},
+		{text: "problemB.__", start: 9, completions: nil},
+		{text: "deppy.sources.pre", start: 14, completions: []string{"prefix"}},
+		// functions are never called
+		{text: "f().x", start: 5, completions: nil},
+		{text: "missing.x", start: 9, completions: nil},
+		{text: "problemB..s", start: 11, completions: nil},
+	} {
+		start, completions := Complete(vm, nil, tt.text)
+		assert.Equal(t, tt.start, start, tt.text)
+		assert.Equal(t, tt.completions, completions, tt.text)
+	}
+}
+
+func TestComplete_Declarations(t *testing.T) {
+	vm := goja.New()
+	vm.SetFieldNameMapper(goja.TagFieldNameMapper("json", true))
+	assert.NoError(t, BootstrapRepleeVM(context.Background(), vm))
+	declarations := NewDeclarations()
+	for _, command := range []string{
+		`let lv = deppy.newVariable("a", "k", {}); const lc = {size: 1}`,
+		`class Lk {}`,
+		// the declaration is never initialized, the command throws before reaching it
+		`missing(); let lmissing = 1`,
+	} {
+		declarations.Record(command)
+		_, _ = vm.RunString(command)
+	}
+
+	for _, tt := range []struct {
+		text        string
+		start       int
+		completions []string
+	}{
+		{text: "l", start: 0, completions: []string{"lc", "lv"}},
+		{text: "L", start: 0, completions: []string{"Lk"}},
+		{text: "lv.addD", start: 3, completions: []string{"addDependency"}},
+		{text: "lc.s", start: 3, completions: []string{"size"}},
+		{text: "lmissing.x", start: 10, completions: nil},
+	} {
+		start, completions := Complete(vm, declarations, tt.text)
+		assert.Equal(t, tt.start, start, tt.text)
+		assert.Equal(t, tt.completions, completions, tt.text)
+	}
+}
@@ -0,0 +1,256 @@
+package repl
+
+import (
+	"fmt"
+	"github.com/dop251/goja"
+	"github.com/mattn/go-runewidth"
+	"os"
+	"strings"
+)
+
+// LogLevel is the level of a console call
+type LogLevel int
+
+const (
+	LevelLog LogLevel = iota
+	LevelWarn
+	LevelError
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	}
+	return "log"
+}
+
+// ConsoleFunc receives what the console prints
+type ConsoleFunc func(level LogLevel, display Display)
+
+// StderrConsole prints to stderr, which is where the console prints outside of the UI
+func StderrConsole(level LogLevel, display Display) {
+	_, _ = fmt.Fprintln(os.Stderr, display.String())
+}
+
+var consoleDocs = []Doc{
+	{
+		Name:        "console",
+		Description: "Prints to the output of the tab, also from callbacks running during build and solve.",
+	},
+	{
+		Name:        "console.log",
+		Signature:   "(...values: any[])",
+		Description: "Prints the values separated by spaces. Strings are printed as they are, other values as results are.",
+		Args:        []Arg{{"values", "the values to print"}},
+		Example:     "console.log(\"variables:\", p.getVariables().length)",
+	},
+	{
+		Name:        "console.warn",
+		Signature:   "(...values: any[])",
+		Description: "Prints the values as a warning.",
+		Args:        []Arg{{"values", "the values to print"}},
+	},
+	{
+		Name:        "console.error",
+		Signature:   "(...values: any[])",
+		Description: "Prints the values as an error.",
+		Args:        []Arg{{"values", "the values to print"}},
+	},
+	{
+		Name:      "console.table",
+		Signature: "(data: object | any[], columns?: string[])",
+		Description: "Prints the items of an array, or the properties of an object, as the rows of a table with a " +
+			"column for every property of the items.",
+		Args: []Arg{
+			{"data", "the rows of the table"},
+			{"columns", "the properties to show, all of them by default"},
+		},
+		Example: "console.table([{name: \"a\", version: \"1.0.0\"}, {name: \"b\", version: \"2.0.0\"}])",
+	},
+	{
+		Name:        "print",
+		Signature:   "(...values: any[])",
+		Description: "Same as console.log.",
+		Args:        []Arg{{"values", "the values to print"}},
+	},
+}
+
+// console prints the arguments of its calls rendered by the renderers, as results are
+type console struct {
+	vm        *goja.Runtime
+	renderers *Renderers
+	out       ConsoleFunc
+}
+
+// NewConsoleFunctions returns the console object, whose output goes to out
+func NewConsoleFunctions(vm *goja.Runtime, renderers *Renderers, out ConsoleFunc) map[string]interface{} {
+	c := &console{vm: vm, renderers: renderers, out: out}
+	return map[string]interface{}{
+		"log":   c.printer(LevelLog),
+		"info":  c.printer(LevelLog),
+		"debug": c.printer(LevelLog),
+		"warn":  c.printer(LevelWarn),
+		"error": c.printer(LevelError),
+		"table": c.table,
+	}
+}
+
+func (c *console) printer(level LogLevel) func(call goja.FunctionCall) goja.Value {
+	return func(call goja.FunctionCall) goja.Value {
+		c.print(level, call.Arguments)
+		return goja.Undefined()
+	}
+}
+
+func (c *console) print(level LogLevel, values []goja.Value) {
+	w := c.renderers.newWriter(c.vm, defaultLimits)
+	for i, value := range values {
+		if i > 0 {
+			w.Write(StylePlain, " ")
+		}
+		if s, ok := value.Export().(string); ok {
+			w.Write(StylePlain, s)
+		} else {
+			w.Value(value)
+		}
+	}
+	c.out(level, w.display())
+}
+
+// table prints the rows like Node does, with an index column and a column for every property of the rows. Rows
+// that aren't objects have their value in a Values column, and data that isn't an object is printed as console.log
+// prints it.
+func (c *console) table(call goja.FunctionCall) goja.Value {
+	data := call.Argument(0)
+	object, ok := data.(*goja.Object)
+	if !ok {
+		c.print(LevelLog, []goja.Value{data})
+		return goja.Undefined()
+	}
+	var columns []string
+	if arg := call.Argument(1); !goja.IsUndefined(arg) && !goja.IsNull(arg) {
+		if err := c.vm.ExportTo(arg, &columns); err != nil {
+			panic(c.vm.NewTypeError("console.table: columns must be an array of strings: %v", err))
+		}
+	}
+	indexes := object.Keys()
+	var header []string
+	hasValues := false
+	seen := map[string]struct{}{}
+	rows := make([]*goja.Object, len(indexes))
+	for i, index := range indexes {
+		row, ok := object.Get(index).(*goja.Object)
+		if !ok {
+			hasValues = true
+			continue
+		}
+		rows[i] = row
+		for _, key := range row.Keys() {
+			if _, ok := seen[key]; !ok {
+				seen[key] = struct{}{}
+				header = append(header, key)
+			}
+		}
+	}
+	if columns != nil {
+		header = columns
+	}
+
+	w := c.renderers.newWriter(c.vm, defaultLimits)
+	cell := func(value goja.Value) Line {
+		item := w.nested()
+		// nested objects are summarized, as Node does
+		item.depth = 1
+		item.limits.depth = 1
+		item.Value(value)
+		var line Line
+		for i, l := range item.lines {
+			if i > 0 {
+				line = append(line, Span{Text: " "})
+			}
+			line = append(line, l...)
+		}
+		return line
+	}
+	table := [][]Line{{{{Text: "(index)", Style: StyleType}}}}
+	for _, key := range header {
+		table[0] = append(table[0], Line{{Text: key, Style: StyleType}})
+	}
+	if hasValues {
+		table[0] = append(table[0], Line{{Text: "Values", Style: StyleType}})
+	}
+	for i, index := range indexes {
+		row := []Line{{{Text: index, Style: StyleKey}}}
+		for _, key := range header {
+			if rows[i] == nil {
+				row = append(row, nil)
+				continue
+			}
+			if value := rows[i].Get(key); value != nil {
+				row = append(row, cell(value))
+			} else {
+				row = append(row, nil)
+			}
+		}
+		if hasValues {
+			if rows[i] == nil {
+				row = append(row, cell(object.Get(index)))
+			} else {
+				row = append(row, nil)
+			}
+		}
+		table = append(table, row)
+	}
+	writeTable(w, table)
+	c.out(LevelLog, w.display())
+	return goja.Undefined()
+}
+
+// writeTable writes the cells of the rows in a box, the first row being the header
+func writeTable(w *Writer, table [][]Line) {
+	widths := make([]int, len(table[0]))
+	for _, row := range table {
+		for i, cell := range row {
+			if width := runewidth.StringWidth(Display{cell}.String()); width > widths[i] {
+				widths[i] = width
+			}
+		}
+	}
+	border := func(left, middle, right string) {
+		parts := make([]string, len(widths))
+		for i, width := range widths {
+			parts[i] = strings.Repeat("─", width+2)
+		}
+		w.Write(StyleHint, left+strings.Join(parts, middle)+right).Newline()
+	}
+	border("┌", "┬", "┐")
+	for r, row := range table {
+		for i, cell := range row {
+			w.Write(StyleHint, "│")
+			// cells are centered, as Node does
+			padding := widths[i] - runewidth.StringWidth(Display{cell}.String())
+			w.Write(StylePlain, strings.Repeat(" ", 1+padding/2))
+			for _, span := range cell {
+				w.Write(span.Style, span.Text)
+			}
+			w.Write(StylePlain, strings.Repeat(" ", 1+padding-padding/2))
+		}
+		w.Write(StyleHint, "│").Newline()
+		if r == 0 {
+			border("├", "┼", "┤")
+		}
+	}
+	border("└", "┴", "┘")
+}
+
+// bindConsole sets the global console object and print function
+func bindConsole(vm *goja.Runtime, renderers *Renderers, out ConsoleFunc) error {
+	functions := NewConsoleFunctions(vm, renderers, out)
+	if err := vm.Set("console", functions); err != nil {
+		return err
+	}
+	return vm.Set("print", functions["log"])
+}
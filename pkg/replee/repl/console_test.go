@@ -0,0 +1,78 @@
+package repl
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dop251/goja"
+	"github.com/stretchr/testify/assert"
+)
+
+type consoleLine struct {
+	level LogLevel
+	text  string
+}
+
+func newTestConsole(t *testing.T) (*goja.Runtime, *[]consoleLine) {
+	var lines []consoleLine
+	vm := goja.New()
+	vm.SetFieldNameMapper(goja.TagFieldNameMapper("json", true))
+	assert.NoError(t, BootstrapRepleeVM(context.Background(), vm, WithConsole(func(level LogLevel, display Display) {
+		lines = append(lines, consoleLine{level: level, text: display.String()})
+	})))
+	return vm, &lines
+}
+
+func TestConsole(t *testing.T) {
+	vm, lines := newTestConsole(t)
+	_, err := vm.RunString(`
+		console.log("variables:", 2, [1, "a"]);
+		console.warn("careful");
+		console.error(new Error("boom").message);
+		print("a\nb");
+	`)
+	assert.NoError(t, err)
+	assert.Equal(t, []consoleLine{
+		// strings are printed as they are, other values as results are
+		{LevelLog, `variables: 2 [ 1, "a" ]`},
+		{LevelWarn, "careful"},
+		{LevelError, "boom"},
+		{LevelLog, "a\nb"},
+	}, *lines)
+}
+
+func TestConsole_Table(t *testing.T) {
+	vm, lines := newTestConsole(t)
+	_, err := vm.RunString(`console.table([{name: "a", version: "1.0.0"}, {name: "bb"}, 3])`)
+	assert.NoError(t, err)
+	assert.Equal(t, []consoleLine{{LevelLog, "" +
+		"┌─────────┬──────┬─────────┬────────┐\n" +
+		"│ (index) │ name │ version │ Values │\n" +
+		"├─────────┼──────┼─────────┼────────┤\n" +
+		"│    0    │ \"a\"  │ \"1.0.0\" │        │\n" +
+		"│    1    │ \"bb\" │         │        │\n" +
+		"│    2    │      │         │   3    │\n" +
+		"└─────────┴──────┴─────────┴────────┘"}}, *lines)
+
+	*lines = nil
+	_, err = vm.RunString(`console.table({a: {x: 1}, b: {x: 2, y: 3}}, ["y"])`)
+	assert.NoError(t, err)
+	assert.Equal(t, []consoleLine{{LevelLog, "" +
+		"┌─────────┬───┐\n" +
+		"│ (index) │ y │\n" +
+		"├─────────┼───┤\n" +
+		"│    a    │   │\n" +
+		"│    b    │ 3 │\n" +
+		"└─────────┴───┘"}}, *lines)
+}
+
+func TestConsole_TableNotObject(t *testing.T) {
+	vm, lines := newTestConsole(t)
+	// data that isn't an object is printed as console.log prints it
+	_, err := vm.RunString(`console.table(); console.table("x"); console.table(null); console.table(1, ["a"])`)
+	assert.NoError(t, err)
+	assert.Equal(t, []consoleLine{{LevelLog, "undefined"}, {LevelLog, "x"}, {LevelLog, "null"}, {LevelLog, "1"}}, *lines)
+
+	_, err = vm.RunString(`console.table([{a: 1}], {})`)
+	assert.ErrorContains(t, err, "columns must be an array of strings")
+}
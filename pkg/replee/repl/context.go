@@ -0,0 +1,57 @@
+package repl
+
+import (
+	"context"
+	"sync"
+)
+
+// ContextFunc returns the context of the command running. The bindings call it whenever they build or solve,
+// rather than keeping a context, so that the builders and sources made by a command work in the commands after it.
+type ContextFunc func() context.Context
+
+// StaticContext returns the ContextFunc of runtimes whose commands all run in the same context
+func StaticContext(ctx context.Context) ContextFunc {
+	return func() context.Context {
+		return ctx
+	}
+}
+
+// CommandContexts starts a context for every command run in a runtime, so that interrupting a command cancels its
+// builds and solves without canceling the commands after it. Its Context method is the ContextFunc the bindings of
+// the runtime are bootstrapped with.
+type CommandContexts struct {
+	parent context.Context
+	lock   sync.Mutex
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func NewCommandContexts(parent context.Context) *CommandContexts {
+	c := &CommandContexts{parent: parent}
+	c.ctx, c.cancel = context.WithCancel(parent)
+	return c
+}
+
+// Begin cancels the context of the previous command and returns the context of the next one, along with the
+// function that ends it
+func (c *CommandContexts) Begin() (context.Context, context.CancelFunc) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.cancel()
+	c.ctx, c.cancel = context.WithCancel(c.parent)
+	return c.ctx, c.cancel
+}
+
+// Interrupt cancels the context of the current command
+func (c *CommandContexts) Interrupt() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.cancel()
+}
+
+// Context returns the context of the current command
+func (c *CommandContexts) Context() context.Context {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.ctx
+}
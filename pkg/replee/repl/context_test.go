@@ -0,0 +1,38 @@
+package repl
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommandContexts(t *testing.T) {
+	parent, cancelParent := context.WithCancel(context.Background())
+	c := NewCommandContexts(parent)
+
+	first, end := c.Begin()
+	assert.Equal(t, first, c.Context())
+	// interrupting a command cancels its context
+	c.Interrupt()
+	assert.ErrorIs(t, first.Err(), context.Canceled)
+	end()
+
+	// the next command has a context of its own, and the context of the previous one stays canceled
+	second, end := c.Begin()
+	assert.NoError(t, second.Err())
+	assert.Equal(t, second, c.Context())
+	assert.ErrorIs(t, first.Err(), context.Canceled)
+	end()
+	assert.ErrorIs(t, second.Err(), context.Canceled)
+
+	third, _ := c.Begin()
+	cancelParent()
+	<-third.Done()
+	assert.ErrorIs(t, c.Context().Err(), context.Canceled)
+}
+
+func TestStaticContext(t *testing.T) {
+	ctx := context.WithValue(context.Background(), struct{}{}, "value")
+	assert.Equal(t, ctx, StaticContext(ctx)())
+}
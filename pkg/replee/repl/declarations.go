@@ -0,0 +1,82 @@
+package repl
+
+import (
+	"github.com/dop251/goja/ast"
+	"github.com/dop251/goja/parser"
+	"sort"
+	"sync"
+)
+
+// Declarations keeps the names declared by let, const and class at the top level of the commands run in a runtime.
+// They live in the global scope without being properties of the global object, which lists the other names.
+type Declarations struct {
+	lock  sync.Mutex
+	names map[string]struct{}
+}
+
+func NewDeclarations() *Declarations {
+	return &Declarations{names: map[string]struct{}{}}
+}
+
+// Record adds the names the command declares at its top level. Commands that don't parse declare nothing.
+func (d *Declarations) Record(command string) {
+	program, err := parser.ParseFile(nil, "", command, 0)
+	if err != nil {
+		return
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	for _, statement := range program.Body {
+		switch s := statement.(type) {
+		case *ast.LexicalDeclaration:
+			for _, binding := range s.List {
+				d.addTarget(binding.Target)
+			}
+		case *ast.ClassDeclaration:
+			if s.Class.Name != nil {
+				d.names[s.Class.Name.Name.String()] = struct{}{}
+			}
+		}
+	}
+}
+
+// addTarget adds the names bound by the target of a declaration, walking destructuring patterns
+func (d *Declarations) addTarget(target ast.Expression) {
+	switch t := target.(type) {
+	case *ast.Identifier:
+		d.names[t.Name.String()] = struct{}{}
+	case *ast.AssignExpression:
+		// a target with a default value
+		d.addTarget(t.Left)
+	case *ast.ArrayPattern:
+		for _, element := range t.Elements {
+			d.addTarget(element)
+		}
+		d.addTarget(t.Rest)
+	case *ast.ObjectPattern:
+		for _, property := range t.Properties {
+			switch p := property.(type) {
+			case *ast.PropertyShort:
+				d.names[p.Name.Name.String()] = struct{}{}
+			case *ast.PropertyKeyed:
+				d.addTarget(p.Value)
+			}
+		}
+		d.addTarget(t.Rest)
+	}
+}
+
+// Names returns the names declared so far, sorted
+func (d *Declarations) Names() []string {
+	if d == nil {
+		return nil
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	names := make([]string, 0, len(d.names))
+	for name := range d.names {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,23 @@
+package repl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeclarations(t *testing.T) {
+	declarations := NewDeclarations()
+	declarations.Record(`
+		let a = 1, b;
+		const {c, d: e, f = 2, ...g} = {}, [h, [i], j = 3, ...k] = [];
+		class L {}
+		var global = 1;
+		function f() { let inner = 1 }
+		{ let block = 1 }
+	`)
+	// commands that don't parse declare nothing
+	declarations.Record(`let broken = `)
+	assert.Equal(t, []string{"L", "a", "b", "c", "e", "f", "g", "h", "i", "j", "k"}, declarations.Names())
+	assert.Nil(t, (*Declarations)(nil).Names())
+}
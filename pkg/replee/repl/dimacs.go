@@ -0,0 +1,89 @@
+package repl
+
+import (
+	"bytes"
+	"github.com/perdasilva/replee/pkg/deppy"
+	"github.com/perdasilva/replee/pkg/deppy/dimacs"
+	"github.com/perdasilva/replee/pkg/deppy/resolution"
+	"github.com/perdasilva/replee/pkg/deppy/solver"
+	"os"
+	"strings"
+)
+
+var dimacsDocs = []Doc{
+	{
+		Name:        "deppy.dimacs",
+		Description: "DIMACS CNF export and import.",
+	},
+	{
+		Name:        "deppy.dimacs.export",
+		Signature:   "(problem: ResolutionProblem): string",
+		Description: "Returns the problem in DIMACS CNF.",
+		Args:        []Arg{{"problem", "the problem"}},
+	},
+	{
+		Name:        "deppy.dimacs.write",
+		Signature:   "(problem: ResolutionProblem, path: string)",
+		Description: "Writes the problem in DIMACS CNF to a file.",
+		Args: []Arg{
+			{"problem", "the problem"},
+			{"path", "the path of the file"},
+		},
+	},
+	{
+		Name:        "deppy.dimacs.parse",
+		Signature:   "(cnf: string, resolutionProblemID: string): MutableResolutionProblem",
+		Description: "Parses a problem in DIMACS CNF.",
+		Args: []Arg{
+			{"cnf", "the problem in DIMACS CNF"},
+			{"resolutionProblemID", "id of the problem"},
+		},
+		Example: "deppy.dimacs.parse(\"p cnf 2 1\\n1 -2 0\", \"p\")",
+	},
+	{
+		Name:        "deppy.dimacs.load",
+		Signature:   "(path: string): MutableResolutionProblem",
+		Description: "Reads a problem in DIMACS CNF from a file, identified by the path.",
+		Args:        []Arg{{"path", "the path of the file"}},
+	},
+}
+
+// NewDIMACSFunctions returns the DIMACS CNF export and import exposed as deppy.dimacs
+func NewDIMACSFunctions() map[string]interface{} {
+	export := func(problem deppy.ResolutionProblem) (string, error) {
+		vars, err := problem.GetVariables()
+		if err != nil {
+			return "", err
+		}
+		buf := &bytes.Buffer{}
+		if err := solver.WriteDIMACS(buf, vars); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}
+
+	return map[string]interface{}{
+		"export": export,
+		"write": func(problem deppy.ResolutionProblem, path string) error {
+			cnf, err := export(problem)
+			if err != nil {
+				return err
+			}
+			return os.WriteFile(path, []byte(cnf), 0o644)
+		},
+		"parse": func(cnf string, resolutionProblemID deppy.Identifier) (*resolution.MutableResolutionProblem, error) {
+			f, err := dimacs.Parse(strings.NewReader(cnf))
+			if err != nil {
+				return nil, err
+			}
+			return f.Problem(resolutionProblemID)
+		},
+		"load": func(path string) (*resolution.MutableResolutionProblem, error) {
+			f, err := dimacs.Load(path)
+			if err != nil {
+				return nil, err
+			}
+			return f.Problem(deppy.Identifier(path))
+		},
+	}
+}
@@ -0,0 +1,605 @@
+package repl
+
+import (
+	"fmt"
+	"github.com/dop251/goja"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Style is the role of a span of a rendered result, which the UI gives a color
+type Style int
+
+const (
+	StylePlain Style = iota
+	StyleNumber
+	StyleString
+	// StyleLiteral is the style of booleans, null and undefined
+	StyleLiteral
+	StyleKey
+	// StyleType is the style of type names and headings
+	StyleType
+	// StyleIdentifier is the style of the ids of problems, variables and constraints
+	StyleIdentifier
+	StyleSelected
+	StyleError
+	// StyleHint is the style of the notes on what was left out
+	StyleHint
+)
+
+// Span is a piece of a line of a rendered result
+type Span struct {
+	Text  string
+	Style Style
+}
+
+type Line []Span
+
+// Display is a rendered result
+type Display []Line
+
+// String returns the text of the display, without styles
+func (d Display) String() string {
+	lines := make([]string, len(d))
+	for i, line := range d {
+		sb := strings.Builder{}
+		for _, span := range line {
+			sb.WriteString(span.Text)
+		}
+		lines[i] = sb.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Renderer renders a Go value of the type it's registered for
+type Renderer func(w *Writer, value interface{})
+
+// limits bound how much of a result is rendered, zero means no limit
+type limits struct {
+	// items is the number of items of a collection
+	items int
+	// depth is the depth of nested JavaScript objects and arrays
+	depth int
+	// lines is the number of lines of the whole result
+	lines int
+}
+
+var defaultLimits = limits{items: 20, depth: 2, lines: 200}
+
+// expandedDepth is the depth nested JavaScript objects are rendered to by expand, which guards against deep graphs
+const expandedDepth = 10
+
+// inlineWidth is the width up to which arrays and objects are rendered on a single line
+const inlineWidth = 72
+
+// expandHint is appended to the notes on what was left out of a result
+const expandHint = "expand() shows all"
+
+// Renderers renders results with the renderer registered for the Go type of their value, or else for an interface
+// the value implements, falling back to the String method of Go values and a Node-like format for JavaScript values
+type Renderers struct {
+	types      map[reflect.Type]Renderer
+	interfaces []interfaceRenderer
+}
+
+type interfaceRenderer struct {
+	t        reflect.Type
+	renderer Renderer
+}
+
+// expanded wraps the value passed to expand, to render it without limits
+type expanded struct {
+	value goja.Value
+}
+
+var displayDocs = []Doc{
+	{
+		Name:      "expand",
+		Signature: "(value?: any): any",
+		Description: "Shows the value in full, where results are otherwise cut short with a note on what was left out. " +
+			"Defaults to the last result, _.",
+		Args:    []Arg{{"value", "the value to show"}},
+		Example: "deppy.solve(p)\nexpand()",
+	},
+	{
+		Name:        "_",
+		Description: "The last result.",
+	},
+}
+
+func NewRenderers() *Renderers {
+	r := &Renderers{types: map[reflect.Type]Renderer{}}
+	r.Register(reflect.TypeOf(expanded{}), func(w *Writer, value interface{}) {
+		w.limits = limits{depth: expandedDepth}
+		w.Value(value.(expanded).value)
+	})
+	r.Register(reflect.TypeOf(&goja.Promise{}), renderPromise)
+	registerDeppyRenderers(r)
+	return r
+}
+
+// renderPromise renders promises like Node does, with the value they're settled with
+func renderPromise(w *Writer, value interface{}) {
+	promise := value.(*goja.Promise)
+	w.Write(StyleType, "Promise ").Write(StylePlain, "{ ")
+	switch promise.State() {
+	case goja.PromiseStatePending:
+		w.Write(StyleHint, "<pending>")
+	case goja.PromiseStateRejected:
+		w.Write(StyleError, "<rejected> ")
+		w.Value(promise.Result())
+	default:
+		w.Value(promise.Result())
+	}
+	w.Write(StylePlain, " }")
+}
+
+// Register sets the renderer of the values of the type. Interface types are checked in the reverse order of
+// their registration, after the concrete types.
+func (r *Renderers) Register(t reflect.Type, renderer Renderer) *Renderers {
+	if t.Kind() == reflect.Interface {
+		r.interfaces = append([]interfaceRenderer{{t: t, renderer: renderer}}, r.interfaces...)
+		return r
+	}
+	r.types[t] = renderer
+	return r
+}
+
+func (r *Renderers) lookup(t reflect.Type) (Renderer, bool) {
+	if renderer, ok := r.types[t]; ok {
+		return renderer, true
+	}
+	for _, i := range r.interfaces {
+		if t.Implements(i.t) {
+			return i.renderer, true
+		}
+	}
+	return nil, false
+}
+
+// Render renders the value, truncated with a hint to expand it if it's too long. Strings are rendered as they are,
+// rather than quoted as they are when nested in other values.
+func (r *Renderers) Render(vm *goja.Runtime, value goja.Value) Display {
+	if value != nil && !goja.IsNull(value) && !goja.IsUndefined(value) {
+		if s, ok := value.Export().(string); ok {
+			w := r.newWriter(vm, defaultLimits)
+			w.Write(StylePlain, s)
+			return w.display()
+		}
+	}
+	w := r.newWriter(vm, defaultLimits)
+	w.Value(value)
+	return w.display()
+}
+
+// RenderError renders an error thrown by a command, or the reason of the rejection of the promise it returned. Go
+// errors are rendered with their renderer.
+func (r *Renderers) RenderError(vm *goja.Runtime, err error) Display {
+	w := r.newWriter(vm, defaultLimits)
+	switch e := err.(type) {
+	case *goja.Exception:
+		if goErr, ok := goError(e.Value()); ok {
+			w.Value(goErr)
+			return w.display()
+		}
+	case *Rejected:
+		w.Write(StyleError, "Uncaught (in promise) ")
+		if goErr, ok := goError(e.Reason); ok {
+			w.Value(goErr)
+		} else if object, ok := e.Reason.(*goja.Object); ok && object.ClassName() == "Error" {
+			w.Write(StyleError, object.String())
+		} else {
+			w.Value(e.Reason)
+		}
+		return w.display()
+	}
+	w.Write(StyleError, err.Error())
+	return w.display()
+}
+
+// goError returns the Go error a thrown value holds, goja wraps them in a GoError holding them as its value
+func goError(value goja.Value) (error, bool) {
+	object, ok := value.(*goja.Object)
+	if !ok {
+		return nil, false
+	}
+	if v := object.Get("value"); v != nil {
+		if err, ok := v.Export().(error); ok {
+			return err, true
+		}
+	}
+	err, ok := object.Export().(error)
+	return err, ok
+}
+
+func (r *Renderers) newWriter(vm *goja.Runtime, l limits) *Writer {
+	return &Writer{vm: vm, renderers: r, limits: l, seen: map[*goja.Object]struct{}{}}
+}
+
+// bind sets the global expand function, which renders its argument, or the last result, without limits
+func (r *Renderers) bind(vm *goja.Runtime) error {
+	return vm.Set("expand", func(call goja.FunctionCall) goja.Value {
+		value := call.Argument(0)
+		if len(call.Arguments) == 0 {
+			value = vm.Get("_")
+		}
+		return vm.ToValue(expanded{value: value})
+	})
+}
+
+// IsExpansion returns true if the value is the result of expand, which callers keeping the last result as _ skip
+func IsExpansion(value goja.Value) bool {
+	if value == nil {
+		return false
+	}
+	_, ok := value.Export().(expanded)
+	return ok
+}
+
+// Writer builds the lines of a display, indenting the lines of nested values
+type Writer struct {
+	vm        *goja.Runtime
+	renderers *Renderers
+	limits    limits
+	lines     Display
+	indent    int
+	depth     int
+	// open is set while the last line can be written to
+	open bool
+	seen map[*goja.Object]struct{}
+}
+
+// Write adds the text to the current line, starting new lines at its line breaks
+func (w *Writer) Write(style Style, text string) *Writer {
+	for i, part := range strings.Split(text, "\n") {
+		if i > 0 {
+			w.Newline()
+		}
+		if part == "" {
+			continue
+		}
+		if !w.open {
+			w.lines = append(w.lines, Line{})
+			if w.indent > 0 {
+				w.lines[len(w.lines)-1] = append(w.lines[len(w.lines)-1], Span{Text: strings.Repeat("  ", w.indent)})
+			}
+			w.open = true
+		}
+		w.lines[len(w.lines)-1] = append(w.lines[len(w.lines)-1], Span{Text: part, Style: style})
+	}
+	return w
+}
+
+// Newline ends the current line
+func (w *Writer) Newline() *Writer {
+	if !w.open {
+		w.lines = append(w.lines, Line{})
+	}
+	w.open = false
+	return w
+}
+
+// Indented writes the lines fn writes one level further in
+func (w *Writer) Indented(fn func()) {
+	w.indent++
+	defer func() {
+		w.indent--
+	}()
+	fn()
+}
+
+// Limit returns the number of items of a collection of n items to render
+func (w *Writer) Limit(n int) int {
+	if w.limits.items > 0 && n > w.limits.items {
+		return w.limits.items
+	}
+	return n
+}
+
+// More notes that the last n items of a collection were left out
+func (w *Writer) More(n int) {
+	if n > 0 {
+		w.Write(StyleHint, fmt.Sprintf("… %d more, %s", n, expandHint))
+	}
+}
+
+// Value renders a nested Go or JavaScript value at the current position
+func (w *Writer) Value(value interface{}) {
+	v, ok := value.(goja.Value)
+	if !ok {
+		v = w.vm.ToValue(value)
+	}
+	if v == nil || goja.IsUndefined(v) {
+		w.Write(StyleLiteral, "undefined")
+		return
+	}
+	if goja.IsNull(v) {
+		w.Write(StyleLiteral, "null")
+		return
+	}
+	exported := v.Export()
+	if exported != nil {
+		if renderer, ok := w.renderers.lookup(reflect.TypeOf(exported)); ok {
+			renderer(w, exported)
+			return
+		}
+	}
+	switch e := exported.(type) {
+	case string:
+		w.Write(StyleString, strconv.Quote(e))
+		return
+	case bool:
+		w.Write(StyleLiteral, strconv.FormatBool(e))
+		return
+	case int64:
+		w.Write(StyleNumber, strconv.FormatInt(e, 10))
+		return
+	case float64:
+		w.Write(StyleNumber, formatNumber(e))
+		return
+	case reflect.Value:
+		// deppy.id is exported as the reflect.Value of the function, whose String method only names its type
+	case fmt.Stringer:
+		w.Write(StylePlain, e.String())
+		return
+	}
+	w.inspect(v.ToObject(w.vm), exported)
+}
+
+// Embed adds the display at the current position, indenting its lines past the first one
+func (w *Writer) Embed(d Display) {
+	for i, line := range d {
+		if i > 0 {
+			w.Newline()
+		}
+		for _, span := range line {
+			w.Write(span.Style, span.Text)
+		}
+	}
+}
+
+// nested returns a writer for a value nested in the one being written, which is one level deeper
+func (w *Writer) nested() *Writer {
+	return &Writer{vm: w.vm, renderers: w.renderers, limits: w.limits, depth: w.depth + 1, seen: w.seen}
+}
+
+func (w *Writer) display() Display {
+	lines := w.lines
+	if w.limits.lines > 0 && len(lines) > w.limits.lines {
+		more := len(lines) - w.limits.lines
+		lines = append(lines[:w.limits.lines:w.limits.lines], Line{{
+			Text:  fmt.Sprintf("… %d more lines, %s", more, expandHint),
+			Style: StyleHint,
+		}})
+	}
+	return lines
+}
+
+func formatNumber(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// inspect renders objects and arrays like Node does, on a single line if they're short enough
+func (w *Writer) inspect(object *goja.Object, exported interface{}) {
+	if t, ok := goFuncType(exported); ok {
+		// the names of Go functions are their full names in Go, their signatures tell more
+		w.Write(StyleType, fmt.Sprintf("[Function: %s]", t))
+		return
+	}
+	if _, ok := goja.AssertFunction(object); ok {
+		name := object.Get("name")
+		if name == nil || name.String() == "" {
+			w.Write(StyleType, "[Function (anonymous)]")
+		} else {
+			w.Write(StyleType, fmt.Sprintf("[Function: %s]", name.String()))
+		}
+		return
+	}
+	isArray := object.ClassName() == "Array"
+	if rv := reflect.ValueOf(exported); exported != nil && (rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array) {
+		isArray = true
+	}
+	if _, ok := w.seen[object]; ok {
+		w.Write(StyleType, "[Circular]")
+		return
+	}
+	if w.limits.depth > 0 && w.depth > w.limits.depth {
+		if isArray {
+			w.Write(StyleType, "[Array]")
+		} else {
+			w.Write(StyleType, "[Object]")
+		}
+		return
+	}
+	w.seen[object] = struct{}{}
+	defer delete(w.seen, object)
+
+	var items []Display
+	var more int
+	if isArray {
+		items, more = w.inspectArray(object)
+	} else {
+		items, more = w.inspectObject(object)
+	}
+
+	open, close := "{", "}"
+	if isArray {
+		open, close = "[", "]"
+	}
+	prefix := goTypeName(exported)
+	if prefix != "" {
+		w.Write(StyleType, prefix+" ")
+	}
+	if len(items) == 0 && more == 0 {
+		w.Write(StylePlain, open+close)
+		return
+	}
+	if fitsInline(items, more) {
+		w.Write(StylePlain, open+" ")
+		for i, item := range items {
+			if i > 0 {
+				w.Write(StylePlain, ", ")
+			}
+			w.Embed(item)
+		}
+		if more > 0 {
+			if len(items) > 0 {
+				w.Write(StylePlain, ", ")
+			}
+			w.More(more)
+		}
+		w.Write(StylePlain, " "+close)
+		return
+	}
+	w.Write(StylePlain, open).Newline()
+	if isArray && singleLines(items) {
+		w.Indented(func() {
+			w.pack(items, more)
+		})
+		w.Write(StylePlain, close)
+		return
+	}
+	w.Indented(func() {
+		for i, item := range items {
+			w.Embed(item)
+			if i < len(items)-1 || more > 0 {
+				w.Write(StylePlain, ",")
+			}
+			w.Newline()
+		}
+		if more > 0 {
+			w.More(more)
+			w.Newline()
+		}
+	})
+	w.Write(StylePlain, close)
+}
+
+func (w *Writer) inspectArray(object *goja.Object) ([]Display, int) {
+	length := int(object.Get("length").ToInteger())
+	n := w.Limit(length)
+	items := make([]Display, 0, n)
+	for i := 0; i < n; i++ {
+		item := w.nested()
+		item.Value(object.Get(strconv.Itoa(i)))
+		items = append(items, item.lines)
+	}
+	return items, length - n
+}
+
+func (w *Writer) inspectObject(object *goja.Object) ([]Display, int) {
+	keys := object.Keys()
+	if _, ok := object.Export().(map[string]interface{}); ok {
+		// Go maps have no order, sort their keys so that results don't change between runs
+		sort.Strings(keys)
+	}
+	n := w.Limit(len(keys))
+	items := make([]Display, 0, n)
+	for _, key := range keys[:n] {
+		item := w.nested()
+		item.Write(StyleKey, formatKey(key))
+		item.Write(StylePlain, ": ")
+		item.Value(object.Get(key))
+		items = append(items, item.lines)
+	}
+	return items, len(keys) - n
+}
+
+// goFuncType returns the type of Go functions, which may be exported as the reflect.Value of the function
+func goFuncType(exported interface{}) (reflect.Type, bool) {
+	rv, ok := exported.(reflect.Value)
+	if !ok {
+		rv = reflect.ValueOf(exported)
+	}
+	if !rv.IsValid() || rv.Kind() != reflect.Func {
+		return nil, false
+	}
+	return rv.Type(), true
+}
+
+// goTypeName returns the name of the type of Go structs rendered as objects, which aren't plain JavaScript objects
+func goTypeName(exported interface{}) string {
+	if exported == nil {
+		return ""
+	}
+	t := reflect.TypeOf(exported)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return ""
+	}
+	return t.String()
+}
+
+// pack writes single line items as many to a line as fit in the inline width, as Node does for arrays of numbers
+func (w *Writer) pack(items []Display, more int) {
+	width := 0
+	for i, item := range items {
+		itemWidth := len(item.String()) + 2
+		switch {
+		case width > 0 && width+itemWidth > inlineWidth:
+			w.Newline()
+			width = 0
+		case width > 0:
+			w.Write(StylePlain, " ")
+		}
+		w.Embed(item)
+		if i < len(items)-1 || more > 0 {
+			w.Write(StylePlain, ",")
+		}
+		width += itemWidth
+	}
+	w.Newline()
+	if more > 0 {
+		w.More(more)
+		w.Newline()
+	}
+}
+
+func singleLines(items []Display) bool {
+	for _, item := range items {
+		if len(item) > 1 {
+			return false
+		}
+	}
+	return true
+}
+
+func fitsInline(items []Display, more int) bool {
+	width := 4
+	for _, item := range items {
+		if len(item) > 1 {
+			return false
+		}
+		width += len(item.String()) + 2
+	}
+	if more > 0 {
+		width += len(expandHint) + 12
+	}
+	return width <= inlineWidth
+}
+
+// formatKey quotes keys that aren't identifiers
+func formatKey(key string) string {
+	if key == "" {
+		return "''"
+	}
+	for i := 0; i < len(key); i++ {
+		if !isIdentifierPart(key[i], false) || i == 0 && key[i] >= '0' && key[i] <= '9' {
+			return strconv.Quote(key)
+		}
+	}
+	return key
+}
@@ -0,0 +1,42 @@
+package repl
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dop251/goja"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender(t *testing.T) {
+	vm := goja.New()
+	vm.SetFieldNameMapper(goja.TagFieldNameMapper("json", true))
+	assert.NoError(t, BootstrapRepleeVM(context.Background(), vm))
+	renderers := NewRenderers()
+
+	for _, tt := range []struct {
+		command string
+		display string
+	}{
+		// strings are shown as they are at the top level
+		{command: `"a\nb"`, display: "a\nb"},
+		{command: `[1, "a", true, null, undefined]`, display: `[ 1, "a", true, null, undefined ]`},
+		{command: `1 / 0`, display: "Infinity"},
+		{command: `({a: 1, b: {c: {d: {e: 1}}}})`, display: "{ a: 1, b: { c: { d: [Object] } } }"},
+		{command: `var o = {}; o.self = o; o`, display: "{ self: [Circular] }"},
+		{
+			command: `Array.from({length: 25}, (_, i) => i)`,
+			display: "[\n  0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19,\n  … 5 more, expand() shows all\n]",
+		},
+		{
+			command: `expand(Array.from({length: 25}, (_, i) => i))`,
+			display: "[\n  0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19,\n  20, 21, 22, 23, 24\n]",
+		},
+		// Go values are shown with the renderer of their type
+		{command: `deppy.newVariable("a", "package", {x: 1})`, display: "Variable a package\n  x: 1"},
+	} {
+		value, err := vm.RunString(tt.command)
+		assert.NoError(t, err, tt.command)
+		assert.Equal(t, tt.display, renderers.Render(vm, value).String(), tt.command)
+	}
+}
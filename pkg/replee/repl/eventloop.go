@@ -0,0 +1,205 @@
+package repl
+
+import (
+	"context"
+	"fmt"
+	"github.com/dop251/goja"
+	"sync"
+	"time"
+)
+
+var eventLoopDocs = []Doc{
+	{
+		Name:        "setTimeout",
+		Signature:   "(fn: () => void, ms?: number): number",
+		Description: "Calls the function after the delay, unless the timer is cleared. The command waits for it.",
+		Args: []Arg{
+			{"fn", "the function to call"},
+			{"ms", "the delay in milliseconds"},
+		},
+		Example: "new Promise(resolve => setTimeout(resolve, 1000)).then(() => print(\"a second later\"))",
+	},
+	{
+		Name:        "clearTimeout",
+		Signature:   "(timer: number)",
+		Description: "Cancels a timer set with setTimeout.",
+		Args:        []Arg{{"timer", "the timer setTimeout returned"}},
+	},
+}
+
+// EventLoop settles the promises of the asynchronous bindings on the goroutine running the runtime, which isn't
+// safe to use from other goroutines. Go work started by the bindings runs on goroutines of its own and queues the
+// callback settling its promise when it's done. Commands run with Run, which waits for the work they started.
+type EventLoop struct {
+	vm   *goja.Runtime
+	lock sync.Mutex
+	jobs []func() error
+	wake chan struct{}
+	// pending counts the callbacks that are queued, or that work in progress will queue
+	pending int
+	// generation is the number of the command running, callbacks queued for earlier commands are dropped
+	generation int
+	timers     map[int64]*time.Timer
+	nextTimer  int64
+}
+
+func NewEventLoop(vm *goja.Runtime) *EventLoop {
+	return &EventLoop{
+		vm:     vm,
+		wake:   make(chan struct{}, 1),
+		timers: map[int64]*time.Timer{},
+	}
+}
+
+// Rejected is the error of a command whose promise was rejected
+type Rejected struct {
+	Reason goja.Value
+}
+
+func (e *Rejected) Error() string {
+	return fmt.Sprintf("Uncaught (in promise) %s", e.Reason.String())
+}
+
+// Run runs the command and then the callbacks it queues, until no work is pending. If the command returns a promise,
+// Run returns the value it's fulfilled with, or a Rejected error, as if the command was awaited. The work left when
+// the context is done is abandoned, along with the timers.
+func (l *EventLoop) Run(ctx context.Context, command func() (goja.Value, error)) (goja.Value, error) {
+	l.lock.Lock()
+	l.generation++
+	l.jobs = nil
+	l.lock.Unlock()
+	l.pending = 0
+	defer l.clearTimers()
+
+	value, err := command()
+	for err == nil && l.pending > 0 {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("interrupted while waiting for asynchronous work: %w", ctx.Err())
+		case <-l.wake:
+		}
+		for _, job := range l.take() {
+			l.pending--
+			if err = job(); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil || value == nil {
+		return value, err
+	}
+	if promise, ok := value.Export().(*goja.Promise); ok {
+		switch promise.State() {
+		case goja.PromiseStateFulfilled:
+			return promise.Result(), nil
+		case goja.PromiseStateRejected:
+			return nil, &Rejected{Reason: promise.Result()}
+		}
+	}
+	return value, nil
+}
+
+func (l *EventLoop) take() []func() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	jobs := l.jobs
+	l.jobs = nil
+	return jobs
+}
+
+// reserve counts a callback as pending and returns the function that queues it, which can be called from any
+// goroutine, once. An error returned by the callback ends the command with it.
+func (l *EventLoop) reserve() func(job func() error) {
+	l.pending++
+	l.lock.Lock()
+	generation := l.generation
+	l.lock.Unlock()
+	return func(job func() error) {
+		l.lock.Lock()
+		defer l.lock.Unlock()
+		if l.generation != generation {
+			return
+		}
+		l.jobs = append(l.jobs, job)
+		select {
+		case l.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// schedule queues the job to run after the callbacks already queued, letting them interleave with long work that's
+// split up into jobs
+func (l *EventLoop) schedule(job func() error) {
+	l.reserve()(job)
+}
+
+// async returns a promise settled with the result of the work, which runs on a goroutine of its own and mustn't
+// use the runtime. Then is called on the loop before the promise is settled, if the work succeeds.
+func (l *EventLoop) async(work func() (interface{}, error), then func(result interface{})) *goja.Promise {
+	promise, resolve, reject := l.vm.NewPromise()
+	queue := l.reserve()
+	go func() {
+		var result interface{}
+		var err error
+		func() {
+			// a panic off the loop would take the application down, it rejects the promise instead
+			defer func() {
+				if r := recover(); r != nil {
+					err = fmt.Errorf("panic: %v", r)
+				}
+			}()
+			result, err = work()
+		}()
+		queue(func() error {
+			if err != nil {
+				reject(err)
+				return nil
+			}
+			if then != nil {
+				then(result)
+			}
+			resolve(result)
+			return nil
+		})
+	}()
+	return promise
+}
+
+// bind sets the global setTimeout and clearTimeout functions
+func (l *EventLoop) bind(vm *goja.Runtime) error {
+	if err := vm.Set("setTimeout", func(fn goja.Callable, ms int64, args ...goja.Value) int64 {
+		l.nextTimer++
+		id := l.nextTimer
+		queue := l.reserve()
+		l.timers[id] = time.AfterFunc(time.Duration(ms)*time.Millisecond, func() {
+			queue(func() error {
+				if _, ok := l.timers[id]; !ok {
+					return nil
+				}
+				delete(l.timers, id)
+				_, err := fn(goja.Undefined(), args...)
+				return err
+			})
+		})
+		return id
+	}); err != nil {
+		return err
+	}
+	return vm.Set("clearTimeout", func(id int64) {
+		if timer, ok := l.timers[id]; ok {
+			delete(l.timers, id)
+			if timer.Stop() {
+				// the timer won't queue its callback, which is no longer pending
+				l.pending--
+			}
+		}
+	})
+}
+
+func (l *EventLoop) clearTimers() {
+	for id, timer := range l.timers {
+		timer.Stop()
+		delete(l.timers, id)
+	}
+}
@@ -0,0 +1,122 @@
+package repl
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dop251/goja"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestLoop(t *testing.T) (*goja.Runtime, *EventLoop) {
+	vm := goja.New()
+	loop := NewEventLoop(vm)
+	assert.NoError(t, loop.bind(vm))
+	return vm, loop
+}
+
+func run(vm *goja.Runtime, loop *EventLoop, ctx context.Context, command string) (goja.Value, error) {
+	return loop.Run(ctx, func() (goja.Value, error) {
+		return vm.RunString(command)
+	})
+}
+
+func TestEventLoop_Timers(t *testing.T) {
+	vm, loop := newTestLoop(t)
+	value, err := run(vm, loop, context.Background(), `
+		var calls = [];
+		setTimeout(() => calls.push("b"), 20);
+		setTimeout(() => calls.push("a"), 1);
+		new Promise(resolve => setTimeout(() => resolve(calls), 40));
+	`)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"a", "b"}, value.Export())
+	assert.Equal(t, 0, loop.pending)
+}
+
+func TestEventLoop_ClearTimeout(t *testing.T) {
+	vm, loop := newTestLoop(t)
+	start := time.Now()
+	value, err := run(vm, loop, context.Background(), `
+		var called = false;
+		var timer = setTimeout(() => called = true, 10000);
+		clearTimeout(timer);
+		clearTimeout(timer);
+		called;
+	`)
+	assert.NoError(t, err)
+	assert.Equal(t, false, value.Export())
+	// the cleared timer isn't waited for
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, 0, loop.pending)
+	assert.Empty(t, loop.timers)
+}
+
+func TestEventLoop_TimerError(t *testing.T) {
+	vm, loop := newTestLoop(t)
+	_, err := run(vm, loop, context.Background(), `setTimeout(() => { throw new Error("boom") }, 1)`)
+	assert.ErrorContains(t, err, "boom")
+}
+
+func TestEventLoop_Rejected(t *testing.T) {
+	vm, loop := newTestLoop(t)
+	_, err := run(vm, loop, context.Background(), `new Promise((resolve, reject) => setTimeout(() => reject("nope"), 1))`)
+	var rejected *Rejected
+	assert.True(t, errors.As(err, &rejected))
+	assert.Equal(t, "nope", rejected.Reason.Export())
+	assert.EqualError(t, err, "Uncaught (in promise) nope")
+}
+
+func TestEventLoop_Async(t *testing.T) {
+	vm, loop := newTestLoop(t)
+	assert.NoError(t, vm.Set("work", func(fail bool) *goja.Promise {
+		return loop.async(func() (interface{}, error) {
+			if fail {
+				return nil, errors.New("failed")
+			}
+			return 42, nil
+		}, nil)
+	}))
+	value, err := run(vm, loop, context.Background(), `work(false).then(n => n + 1)`)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 43, value.Export())
+
+	_, err = run(vm, loop, context.Background(), `work(true)`)
+	assert.ErrorContains(t, err, "failed")
+}
+
+func TestEventLoop_Interrupted(t *testing.T) {
+	vm, loop := newTestLoop(t)
+	release := make(chan struct{})
+	queued := make(chan struct{})
+	called := false
+	assert.NoError(t, vm.Set("work", func() *goja.Promise {
+		return loop.async(func() (interface{}, error) {
+			<-release
+			return nil, nil
+		}, func(interface{}) {
+			called = true
+			close(queued)
+		})
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := run(vm, loop, ctx, `work()`)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// the next command runs while the work of the interrupted one is still going, its callback is dropped
+	value, err := run(vm, loop, context.Background(), `new Promise(resolve => setTimeout(() => resolve("next"), 1))`)
+	assert.NoError(t, err)
+	assert.Equal(t, "next", value.Export())
+	close(release)
+	select {
+	case <-queued:
+		t.Fatal("the callback of the interrupted command ran")
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.False(t, called)
+	assert.Empty(t, loop.take())
+}
@@ -0,0 +1,13 @@
+//go:build wasm
+
+package repl
+
+import (
+	"errors"
+	"github.com/perdasilva/replee/pkg/deppy"
+)
+
+// sourceFromFile fails in the browser, which has no file system to load the source from
+func sourceFromFile(string) (deppy.VariableSource, error) {
+	return nil, errors.New("deppy.sources.fromFile is not supported in the browser, there are no files to load")
+}
@@ -0,0 +1,13 @@
+//go:build !wasm
+
+package repl
+
+import (
+	"github.com/perdasilva/replee/pkg/deppy"
+	"github.com/perdasilva/replee/pkg/deppy/variable_sources"
+)
+
+// sourceFromFile loads the static variable source declared in the file
+func sourceFromFile(path string) (deppy.VariableSource, error) {
+	return variable_sources.NewStaticVariableSourceFromFile(path)
+}
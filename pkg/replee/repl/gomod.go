@@ -0,0 +1,68 @@
+package repl
+
+import (
+	"github.com/perdasilva/replee/pkg/deppy"
+	"github.com/perdasilva/replee/pkg/deppy/gomod"
+)
+
+var gomodDocs = []Doc{
+	{
+		Name:        "deppy.gomod",
+		Description: "Go module graph importer.",
+	},
+	{
+		Name:        "deppy.gomod.loadModGraph",
+		Signature:   "(path: string): Graph",
+		Description: "Reads a file holding the output of `go mod graph`.",
+		Args:        []Arg{{"path", "the path of the file"}},
+		Example:     "g = deppy.gomod.loadModGraph(\"modgraph.txt\")",
+	},
+	{
+		Name:      "deppy.gomod.loadGoMod",
+		Signature: "(goModPath: string, goSumPath: string, modCache: string): Graph",
+		Description: "Reads the graph of a go.mod and go.sum. The requirements of the modules listed in go.sum are " +
+			"read from the module cache download directory, if given.",
+		Args: []Arg{
+			{"goModPath", "the path of go.mod"},
+			{"goSumPath", "the path of go.sum"},
+			{"modCache", "the module cache download directory, e.g. $GOMODCACHE/cache/download, or \"\""},
+		},
+		Example: "g = deppy.gomod.loadGoMod(\"go.mod\", \"go.sum\", \"\")",
+	},
+	{
+		Name:        "deppy.gomod.mvs",
+		Signature:   "(graph: Graph): Module[]",
+		Description: "Returns the build list chosen by minimal version selection.",
+		Args:        []Arg{{"graph", "the module graph"}},
+	},
+	{
+		Name:      "deppy.gomod.source",
+		Signature: "(variableSourceID: string, graph: Graph, preferMinimal: boolean): VariableSource",
+		Description: "Returns a variable source with a variable for each module version of the graph, depending on " +
+			"the versions of the modules it requires.",
+		Args: []Arg{
+			{"variableSourceID", "id of the variable source"},
+			{"graph", "the module graph"},
+			{"preferMinimal", "prefers the oldest versions that satisfy the requirements rather than the newest"},
+		},
+		Example: "s = deppy.gomod.source(\"mods\", g, true)",
+	},
+}
+
+// NewGoModFunctions returns the Go module graph importer exposed as deppy.gomod
+func NewGoModFunctions(ctx ContextFunc) map[string]interface{} {
+	return map[string]interface{}{
+		"loadModGraph": gomod.LoadModGraph,
+		"loadGoMod":    gomod.LoadGoMod,
+		"mvs": func(graph *gomod.Graph) []gomod.Module {
+			return graph.MVS()
+		},
+		"source": func(variableSourceID deppy.Identifier, graph *gomod.Graph, preferMinimal bool) *VariableSourceWithContext {
+			var opts []gomod.Option
+			if preferMinimal {
+				opts = append(opts, gomod.PreferMinimal())
+			}
+			return NewVariableSourceWithContext(ctx, gomod.NewGraphVariableSource(variableSourceID, graph, opts...))
+		},
+	}
+}
@@ -0,0 +1,278 @@
+package repl
+
+import (
+	"fmt"
+	"github.com/dop251/goja"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// helpWidth is the width descriptions are wrapped to
+const helpWidth = 100
+
+// Arg documents an argument of a binding
+type Arg struct {
+	Name        string
+	Description string
+}
+
+// Doc documents a binding of the REPL. Name is the path of the binding from the global object, e.g.
+// deppy.sources.chain, and the signature is given in TypeScript notation, without the name.
+type Doc struct {
+	Name        string
+	Signature   string
+	Description string
+	Args        []Arg
+	Example     string
+}
+
+// Summary returns the first sentence of the description
+func (d Doc) Summary() string {
+	summary := d.Description
+	if i := strings.Index(summary, ". "); i >= 0 {
+		summary = summary[:i]
+	}
+	return strings.TrimSuffix(summary, ".")
+}
+
+func (d Doc) String() string {
+	sb := &strings.Builder{}
+	sb.WriteString(d.Name + d.Signature + "\n")
+	if d.Description != "" {
+		sb.WriteString("\n" + indent(wrap(d.Description, helpWidth)) + "\n")
+	}
+	if len(d.Args) > 0 {
+		sb.WriteString("\nArguments:\n")
+		w := tabwriter.NewWriter(sb, 0, 0, 2, ' ', 0)
+		for _, arg := range d.Args {
+			_, _ = fmt.Fprintf(w, "  %s\t%s\n", arg.Name, arg.Description)
+		}
+		_ = w.Flush()
+	}
+	if d.Example != "" {
+		sb.WriteString("\nExample:\n" + indent(d.Example) + "\n")
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// wrap breaks the text into lines of at most width characters, unless a word is longer
+func wrap(text string, width int) string {
+	sb := &strings.Builder{}
+	lineLength := 0
+	for _, word := range strings.Fields(text) {
+		if lineLength > 0 && lineLength+1+len(word) > width {
+			sb.WriteString("\n")
+			lineLength = 0
+		} else if lineLength > 0 {
+			sb.WriteString(" ")
+			lineLength++
+		}
+		sb.WriteString(word)
+		lineLength += len(word)
+	}
+	return sb.String()
+}
+
+func indent(text string) string {
+	return "  " + strings.ReplaceAll(text, "\n", "\n  ")
+}
+
+// Help is the registry of the documentation of the REPL bindings, which help() prints
+type Help struct {
+	docs map[string]Doc
+	vm   *goja.Runtime
+}
+
+func NewHelp() *Help {
+	return &Help{docs: map[string]Doc{}}
+}
+
+// Register adds the documentation of bindings, replacing any documentation already registered under the same names
+func (h *Help) Register(docs ...Doc) *Help {
+	for _, doc := range docs {
+		h.docs[doc.Name] = doc
+	}
+	return h
+}
+
+// Get returns the documentation of the binding with the given name
+func (h *Help) Get(name string) (Doc, bool) {
+	doc, ok := h.docs[name]
+	return doc, ok
+}
+
+// Docs returns the documentation of all the bindings, sorted by name
+func (h *Help) Docs() []Doc {
+	docs := make([]Doc, 0, len(h.docs))
+	for _, doc := range h.docs {
+		docs = append(docs, doc)
+	}
+	sort.Slice(docs, func(i, j int) bool {
+		return docs[i].Name < docs[j].Name
+	})
+	return docs
+}
+
+// bind makes the registry look up the bindings of the runtime and sets the global help function
+func (h *Help) bind(vm *goja.Runtime) error {
+	h.vm = vm
+	return vm.Set("help", h.help)
+}
+
+// help prints the overview without arguments, otherwise the documentation of the binding given by value or by name
+func (h *Help) help(call goja.FunctionCall) goja.Value {
+	arg := call.Argument(0)
+	if goja.IsUndefined(arg) {
+		return h.vm.ToValue(h.Overview())
+	}
+	if name, ok := arg.Export().(string); ok {
+		return h.vm.ToValue(h.Lookup(name))
+	}
+	if name, ok := h.nameOf(arg); ok {
+		return h.vm.ToValue(h.Lookup(name))
+	}
+	return h.vm.ToValue(h.describe(arg))
+}
+
+// Overview lists the global bindings and the bindings of the deppy object
+func (h *Help) Overview() string {
+	sb := &strings.Builder{}
+	sb.WriteString("help(name) or help(value) shows the documentation of a binding, F1 browses it.\n\n")
+	var globals []string
+	for name := range h.docs {
+		if !strings.Contains(name, ".") && name != "deppy" {
+			globals = append(globals, name)
+		}
+	}
+	sort.Strings(globals)
+	h.writeMembers(sb, "", globals)
+	sb.WriteString("\n")
+	h.writeMembers(sb, "deppy.", h.memberNames("deppy"))
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// Lookup returns the documentation of the binding with the given name, or with the given name in the deppy object,
+// followed by the list of its members if it's an object
+func (h *Help) Lookup(name string) string {
+	doc, ok := h.docs[name]
+	if !ok {
+		if doc, ok = h.docs["deppy."+name]; ok {
+			name = doc.Name
+		}
+	}
+	members := h.memberNames(name)
+	if !ok && members == nil {
+		return fmt.Sprintf("no help for %s, help() lists the bindings", name)
+	}
+	sb := &strings.Builder{}
+	if ok {
+		sb.WriteString(doc.String() + "\n")
+	}
+	if len(members) > 0 {
+		if ok {
+			sb.WriteString("\n")
+		}
+		h.writeMembers(sb, name+".", members)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// writeMembers lists the names with their summaries, flagging the bindings that aren't documented
+func (h *Help) writeMembers(sb *strings.Builder, prefix string, names []string) {
+	w := tabwriter.NewWriter(sb, 0, 0, 2, ' ', 0)
+	for _, name := range names {
+		summary := "(undocumented)"
+		if doc, ok := h.docs[prefix+name]; ok {
+			summary = doc.Summary()
+		}
+		_, _ = fmt.Fprintf(w, "  %s%s\t%s\n", prefix, name, summary)
+	}
+	_ = w.Flush()
+}
+
+// memberNames returns the sorted names of the bindings of the Go map the name leads to, or nil if it isn't one.
+// Members are read from the runtime rather than from the registry, so that undocumented bindings are listed too.
+func (h *Help) memberNames(name string) []string {
+	value := h.resolve(name)
+	if value == nil {
+		return nil
+	}
+	members, ok := value.Export().(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	names := make([]string, 0, len(members))
+	for member := range members {
+		names = append(names, member)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// resolve returns the value of the binding with the given name, or nil if there is none
+func (h *Help) resolve(name string) goja.Value {
+	if h.vm == nil {
+		return nil
+	}
+	var value goja.Value = h.vm.GlobalObject()
+	for _, part := range strings.Split(name, ".") {
+		if value == nil || goja.IsUndefined(value) || goja.IsNull(value) {
+			return nil
+		}
+		value = value.ToObject(h.vm).Get(part)
+	}
+	if value == nil || goja.IsUndefined(value) || goja.IsNull(value) {
+		return nil
+	}
+	return value
+}
+
+// nameOf returns the name of the documented binding holding the value. The runtime wraps Go values anew on every
+// property access, so values are compared by the Go function or map they wrap.
+func (h *Help) nameOf(value goja.Value) (string, bool) {
+	key, ok := identity(value.Export())
+	if !ok {
+		return "", false
+	}
+	for name := range h.docs {
+		if bound := h.resolve(name); bound != nil {
+			if boundKey, ok := identity(bound.Export()); ok && boundKey == key {
+				return name, true
+			}
+		}
+	}
+	return "", false
+}
+
+func identity(value interface{}) (uintptr, bool) {
+	v, ok := value.(reflect.Value)
+	if !ok {
+		v = reflect.ValueOf(value)
+	}
+	switch v.Kind() {
+	case reflect.Func, reflect.Map:
+		return v.Pointer(), true
+	}
+	return 0, false
+}
+
+// describe lists the properties of a value that isn't a binding, e.g. the methods of the variables and problems
+// returned by the bindings
+func (h *Help) describe(value goja.Value) string {
+	if goja.IsNull(value) {
+		return "no help for null"
+	}
+	object := value.ToObject(h.vm)
+	names := ownPropertyNames(h.vm, object)
+	sort.Strings(names)
+	typeName := object.ClassName()
+	if exported := value.Export(); exported != nil {
+		typeName = reflect.TypeOf(exported).String()
+	}
+	if len(names) == 0 {
+		return fmt.Sprintf("%s has no properties", typeName)
+	}
+	return fmt.Sprintf("%s has the properties:\n  %s", typeName, strings.Join(names, "\n  "))
+}
@@ -0,0 +1,63 @@
+package repl
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dop251/goja"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrap(t *testing.T) {
+	assert.Equal(t, "a bb\nccc\nd", wrap("a bb ccc d", 4))
+	assert.Equal(t, "a\nlongword\nb", wrap("a longword b", 4))
+	assert.Equal(t, "", wrap("  ", 4))
+}
+
+func TestDoc(t *testing.T) {
+	doc := Doc{
+		Name:        "deppy.f",
+		Signature:   "(a: string): number",
+		Description: "Does things. More about them.",
+		Args:        []Arg{{Name: "a", Description: "the thing"}},
+		Example:     "deppy.f(\"a\")",
+	}
+	assert.Equal(t, "Does things", doc.Summary())
+	assert.Equal(t, `deppy.f(a: string): number
+
+  Does things. More about them.
+
+Arguments:
+  a  the thing
+
+Example:
+  deppy.f("a")`, doc.String())
+}
+
+func TestHelp(t *testing.T) {
+	vm := goja.New()
+	vm.SetFieldNameMapper(goja.TagFieldNameMapper("json", true))
+	help := NewHelp()
+	assert.NoError(t, BootstrapRepleeVM(context.Background(), vm, WithHelp(help)))
+
+	doc, ok := help.Get("deppy.solve")
+	assert.True(t, ok)
+	// bindings are found by name, by their name in the deppy object and by value
+	for _, command := range []string{`help("deppy.solve")`, `help("solve")`, `help(deppy.solve)`} {
+		value, err := vm.RunString(command)
+		assert.NoError(t, err)
+		assert.Equal(t, doc.String(), value.Export(), command)
+	}
+
+	// objects are followed by their members, undocumented ones included
+	value, err := vm.RunString(`deppy.opts.undocumented = 1; help("deppy.opts")`)
+	assert.NoError(t, err)
+	assert.Contains(t, value.Export(), "deppy.opts.disableLint")
+	assert.Regexp(t, `deppy\.opts\.undocumented +\(undocumented\)`, value.Export())
+
+	assert.Contains(t, help.Overview(), "deppy.newVariable")
+	assert.Equal(t, "no help for missing, help() lists the bindings", help.Lookup("missing"))
+	value, err = vm.RunString(`help({a: 1, b: 2})`)
+	assert.NoError(t, err)
+	assert.Equal(t, "map[string]interface {} has the properties:\n  a\n  b", value.Export())
+}
@@ -0,0 +1,92 @@
+package repl
+
+import (
+	"github.com/perdasilva/replee/pkg/deppy"
+	"github.com/perdasilva/replee/pkg/deppy/render"
+	"github.com/perdasilva/replee/pkg/deppy/resolver"
+	"os"
+)
+
+var renderDocs = []Doc{
+	{
+		Name:        "deppy.render",
+		Description: "Graphviz DOT and Mermaid renderers. The selection or the conflict set of the solution, if given, is highlighted.",
+	},
+	{
+		Name:        "deppy.render.dot",
+		Signature:   "(problem: ResolutionProblem, solution?: Solution): string",
+		Description: "Renders the problem in Graphviz DOT.",
+		Args: []Arg{
+			{"problem", "the problem"},
+			{"solution", "a solution of the problem"},
+		},
+		Example: "deppy.render.dot(p, deppy.solve(p))",
+	},
+	{
+		Name:        "deppy.render.mermaid",
+		Signature:   "(problem: ResolutionProblem, solution?: Solution): string",
+		Description: "Renders the problem as a Mermaid flowchart.",
+		Args: []Arg{
+			{"problem", "the problem"},
+			{"solution", "a solution of the problem"},
+		},
+	},
+	{
+		Name:        "deppy.render.writeDot",
+		Signature:   "(problem: ResolutionProblem, path: string, solution?: Solution)",
+		Description: "Writes the problem in Graphviz DOT to a file.",
+		Args: []Arg{
+			{"problem", "the problem"},
+			{"path", "the path of the file"},
+			{"solution", "a solution of the problem"},
+		},
+		Example: "deppy.render.writeDot(p, \"p.dot\")",
+	},
+	{
+		Name:        "deppy.render.writeMermaid",
+		Signature:   "(problem: ResolutionProblem, path: string, solution?: Solution)",
+		Description: "Writes the problem as a Mermaid flowchart to a file.",
+		Args: []Arg{
+			{"problem", "the problem"},
+			{"path", "the path of the file"},
+			{"solution", "a solution of the problem"},
+		},
+	},
+}
+
+// NewRenderFunctions returns the Graphviz DOT and Mermaid renderers exposed as deppy.render. Each takes
+// an optional solution, whose selection or conflict set is highlighted.
+func NewRenderFunctions() map[string]interface{} {
+	renderOptions := func(solution []*resolver.Solution) []render.Option {
+		var opts []render.Option
+		if len(solution) > 0 && solution[0] != nil {
+			opts = append(opts, render.WithSolution(solution[0]))
+		}
+		return opts
+	}
+	dot := func(problem deppy.ResolutionProblem, solution ...*resolver.Solution) (string, error) {
+		return render.DOT(problem, renderOptions(solution)...)
+	}
+	mermaid := func(problem deppy.ResolutionProblem, solution ...*resolver.Solution) (string, error) {
+		return render.Mermaid(problem, renderOptions(solution)...)
+	}
+	write := func(path string, text string, err error) error {
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(path, []byte(text), 0o644)
+	}
+
+	return map[string]interface{}{
+		"dot":     dot,
+		"mermaid": mermaid,
+		"writeDot": func(problem deppy.ResolutionProblem, path string, solution ...*resolver.Solution) error {
+			text, err := dot(problem, solution...)
+			return write(path, text, err)
+		},
+		"writeMermaid": func(problem deppy.ResolutionProblem, path string, solution ...*resolver.Solution) error {
+			text, err := mermaid(problem, solution...)
+			return write(path, text, err)
+		},
+	}
+}
@@ -0,0 +1,226 @@
+package repl
+
+import (
+	"fmt"
+	"github.com/perdasilva/replee/pkg/deppy"
+	"github.com/perdasilva/replee/pkg/deppy/resolution"
+	"github.com/perdasilva/replee/pkg/deppy/resolver"
+	"reflect"
+	"sort"
+)
+
+// registerDeppyRenderers registers the renderers of solutions, problems, variables, constraints, build steps and errors
+func registerDeppyRenderers(r *Renderers) {
+	r.Register(reflect.TypeOf((*error)(nil)).Elem(), renderError)
+	r.Register(reflect.TypeOf((*deppy.Constraint)(nil)).Elem(), renderConstraint)
+	r.Register(reflect.TypeOf((*deppy.Variable)(nil)).Elem(), renderVariable)
+	r.Register(reflect.TypeOf((*deppy.ResolutionProblem)(nil)).Elem(), renderProblem)
+	r.Register(reflect.TypeOf(deppy.AppliedConstraint{}), renderAppliedConstraint)
+	r.Register(reflect.TypeOf(deppy.NotSatisfiable{}), renderNotSatisfiable)
+	r.Register(reflect.TypeOf(&resolver.Solution{}), renderSolution)
+	r.Register(reflect.TypeOf(&resolution.BuildStep{}), renderBuildStep)
+}
+
+func renderError(w *Writer, value interface{}) {
+	w.Write(StyleError, value.(error).Error())
+}
+
+func renderSolution(w *Writer, value interface{}) {
+	solution := value.(*resolver.Solution)
+	if solution == nil {
+		w.Write(StyleLiteral, "null")
+		return
+	}
+	if conflicts := solution.NotSatisfiable(); len(conflicts) > 0 {
+		w.Write(StyleType, "Solution ").Write(StyleError, "not satisfiable").Newline()
+		w.Indented(func() {
+			renderConflicts(w, conflicts)
+		})
+		return
+	}
+	selected := sortedIDs(solution.SelectedVariables())
+	w.Write(StyleType, "Solution ").Write(StyleHint, fmt.Sprintf("(%d selected)", len(selected)))
+	w.Indented(func() {
+		n := w.Limit(len(selected))
+		for _, id := range selected[:n] {
+			w.Newline().Write(StyleSelected, id.String())
+		}
+		if n < len(selected) {
+			w.Newline().More(len(selected) - n)
+		}
+	})
+}
+
+// renderBuildStep writes what every variable source added to the problem during the step, and the queue it left
+func renderBuildStep(w *Writer, value interface{}) {
+	step := value.(*resolution.BuildStep)
+	if step == nil {
+		w.Write(StyleLiteral, "null")
+		return
+	}
+	w.Write(StyleType, fmt.Sprintf("BuildStep %d ", step.Iteration))
+	if step.Variable == nil {
+		w.Write(StyleHint, "bootstrap")
+	} else {
+		w.Write(StyleIdentifier, step.Variable.VariableID().String())
+		if step.Variable.Kind() != "" {
+			w.Write(StyleHint, " "+step.Variable.Kind())
+		}
+	}
+	w.Indented(func() {
+		for _, source := range step.Sources {
+			call := "update"
+			if source.Finalize {
+				call = "finalize"
+			}
+			w.Newline().Write(StyleIdentifier, source.VariableSourceID.String()).Write(StyleHint, " "+call)
+			w.Indented(func() {
+				n := w.Limit(len(source.Added))
+				for _, addition := range source.Added[:n] {
+					w.Newline().Write(StyleSelected, "+ ").Write(StylePlain, addition.String())
+				}
+				if n < len(source.Added) {
+					w.Newline().More(len(source.Added) - n)
+				}
+				if source.Error != "" {
+					w.Newline().Write(StyleError, "! "+source.Error)
+				}
+			})
+		}
+		if step.Done {
+			w.Newline().Write(StyleHint, "done")
+			return
+		}
+		w.Newline().Write(StyleKey, "queued").Write(StylePlain, ": ")
+		n := w.Limit(len(step.Queued))
+		for i, id := range step.Queued[:n] {
+			if i > 0 {
+				w.Write(StylePlain, ", ")
+			}
+			w.Write(StyleIdentifier, id.String())
+		}
+		if n < len(step.Queued) {
+			w.Write(StylePlain, ", ").More(len(step.Queued) - n)
+		}
+	})
+}
+
+func renderProblem(w *Writer, value interface{}) {
+	problem := value.(deppy.ResolutionProblem)
+	w.Write(StyleType, "ResolutionProblem ").Write(StyleIdentifier, problem.ResolutionProblemID().String())
+	variables, err := problem.GetVariables()
+	if err != nil {
+		w.Newline().Write(StyleError, err.Error())
+		return
+	}
+	w.Write(StyleHint, fmt.Sprintf(" (%d variables)", len(variables)))
+	w.Indented(func() {
+		n := w.Limit(len(variables))
+		for _, v := range variables[:n] {
+			w.Newline().Write(StyleIdentifier, v.VariableID().String())
+			if v.Kind() != "" {
+				w.Write(StyleHint, " "+v.Kind())
+			}
+			w.Indented(func() {
+				for _, c := range v.Constraints() {
+					w.Newline().Write(StylePlain, c.String(v.VariableID()))
+				}
+			})
+		}
+		if n < len(variables) {
+			w.Newline().More(len(variables) - n)
+		}
+	})
+}
+
+func renderVariable(w *Writer, value interface{}) {
+	v := value.(deppy.Variable)
+	w.Write(StyleType, "Variable ").Write(StyleIdentifier, v.VariableID().String())
+	if v.Kind() != "" {
+		w.Write(StyleHint, " "+v.Kind())
+	}
+	w.Indented(func() {
+		properties := v.GetProperties()
+		keys := make([]string, 0, len(properties))
+		for key := range properties {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			w.Newline().Write(StyleKey, formatKey(key)).Write(StylePlain, ": ")
+			w.Value(properties[key])
+		}
+		constraints := v.Constraints()
+		if len(constraints) == 0 {
+			return
+		}
+		w.Newline().Write(StyleKey, "constraints").Write(StylePlain, ":")
+		w.Indented(func() {
+			for _, c := range constraints {
+				w.Newline().Write(StylePlain, c.String(v.VariableID()))
+				if activated, err := v.IsActivated(c.ConstraintID()); err == nil && !activated {
+					w.Write(StyleHint, " (deactivated)")
+				}
+			}
+		})
+	})
+}
+
+func renderConstraint(w *Writer, value interface{}) {
+	c := value.(deppy.Constraint)
+	w.Write(StyleType, "Constraint ").Write(StyleIdentifier, c.ConstraintID().String())
+	if c.Kind() != "" {
+		w.Write(StyleHint, " "+c.Kind())
+	}
+	if order := c.Order(); len(order) > 0 {
+		w.Newline().Indented(func() {
+			w.Write(StyleKey, "order").Write(StylePlain, ": ")
+			n := w.Limit(len(order))
+			for i, id := range order[:n] {
+				if i > 0 {
+					w.Write(StylePlain, ", ")
+				}
+				w.Write(StyleIdentifier, id.String())
+			}
+			if n < len(order) {
+				w.Write(StylePlain, ", ").More(len(order) - n)
+			}
+		})
+	}
+}
+
+func renderAppliedConstraint(w *Writer, value interface{}) {
+	w.Write(StylePlain, value.(deppy.AppliedConstraint).String())
+}
+
+func renderNotSatisfiable(w *Writer, value interface{}) {
+	w.Write(StyleError, "constraints not satisfiable").Newline()
+	w.Indented(func() {
+		renderConflicts(w, value.(deppy.NotSatisfiable))
+	})
+}
+
+// renderConflicts writes the applied constraints of a conflict set a line each
+func renderConflicts(w *Writer, conflicts deppy.NotSatisfiable) {
+	n := w.Limit(len(conflicts))
+	for i, a := range conflicts[:n] {
+		if i > 0 {
+			w.Newline()
+		}
+		w.Write(StyleError, "✗ ").Write(StylePlain, a.String())
+	}
+	if n < len(conflicts) {
+		w.Newline().More(len(conflicts) - n)
+	}
+}
+
+func sortedIDs(variables map[deppy.Identifier]deppy.Variable) []deppy.Identifier {
+	ids := make([]deppy.Identifier, 0, len(variables))
+	for id := range variables {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+	return ids
+}
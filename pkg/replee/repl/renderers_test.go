@@ -0,0 +1,34 @@
+package repl
+
+import (
+	"testing"
+
+	"github.com/dop251/goja"
+	"github.com/perdasilva/replee/pkg/deppy"
+	"github.com/perdasilva/replee/pkg/deppy/resolution"
+	"github.com/perdasilva/replee/pkg/deppy/variables"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderBuildStep(t *testing.T) {
+	vm := goja.New()
+	step := &resolution.BuildStep{
+		Iteration: 2,
+		Variable:  variables.NewMutableVariable("a", "package", nil),
+		Sources: []*resolution.SourceStep{
+			{VariableSourceID: "s", Added: []resolution.Addition{{VariableID: "b"}, {VariableID: "a", ConstraintID: "c"}}},
+			{VariableSourceID: "t", Finalize: true, Error: "failed"},
+		},
+		Queued: []deppy.Identifier{"b", "c"},
+	}
+	assert.Equal(t, "BuildStep 2 a package\n"+
+		"  s update\n"+
+		"    + variable b\n"+
+		"    + constraint c of a\n"+
+		"  t finalize\n"+
+		"    ! failed\n"+
+		"  queued: b, c", NewRenderers().Render(vm, vm.ToValue(step)).String())
+
+	step = &resolution.BuildStep{Iteration: 1, Done: true}
+	assert.Equal(t, "BuildStep 1 bootstrap\n  done", NewRenderers().Render(vm, vm.ToValue(step)).String())
+}
@@ -2,31 +2,327 @@ package repl
 
 import (
 	"context"
+	"errors"
 	"github.com/dop251/goja"
 	"github.com/perdasilva/replee/pkg/deppy"
+	"github.com/perdasilva/replee/pkg/deppy/lint"
 	"github.com/perdasilva/replee/pkg/deppy/resolution"
 	"github.com/perdasilva/replee/pkg/deppy/resolver"
 	"github.com/perdasilva/replee/pkg/deppy/variables"
 	"reflect"
+	"time"
 )
 
-func BootstrapRepleeVM(ctx context.Context, vm *goja.Runtime) error {
+type BootstrapOption func(*bootstrapOptions)
+
+type bootstrapOptions struct {
+	onSolve   []func(problem deppy.ResolutionProblem, solution *resolver.Solution)
+	help      *Help
+	renderers *Renderers
+	console   ConsoleFunc
+	loop      *EventLoop
+	ctx       ContextFunc
+}
+
+// OnSolve registers a function called with the problem and the solution after every deppy.solve
+func OnSolve(fn func(problem deppy.ResolutionProblem, solution *resolver.Solution)) BootstrapOption {
+	return func(o *bootstrapOptions) {
+		o.onSolve = append(o.onSolve, fn)
+	}
+}
+
+// WithHelp registers the documentation of the bindings in the given registry rather than in a new one, so that
+// the caller can document its own bindings and browse the documentation
+func WithHelp(help *Help) BootstrapOption {
+	return func(o *bootstrapOptions) {
+		o.help = help
+	}
+}
+
+// WithConsole routes the output of console and print to fn rather than to stderr
+func WithConsole(fn ConsoleFunc) BootstrapOption {
+	return func(o *bootstrapOptions) {
+		o.console = fn
+	}
+}
+
+// WithEventLoop sets the event loop the asynchronous bindings settle their promises on, which the caller runs
+// commands with. Without it, promises are settled by an event loop of their own that nothing runs.
+func WithEventLoop(loop *EventLoop) BootstrapOption {
+	return func(o *bootstrapOptions) {
+		o.loop = loop
+	}
+}
+
+// WithCommandContexts has the bindings build and solve in the context of the command running, as fn returns it,
+// rather than in the context the runtime is bootstrapped with
+func WithCommandContexts(fn ContextFunc) BootstrapOption {
+	return func(o *bootstrapOptions) {
+		o.ctx = fn
+	}
+}
+
+// WithRenderers binds expand to the given renderers rather than to new ones, so that the caller can render results
+// with renderers of its own
+func WithRenderers(renderers *Renderers) BootstrapOption {
+	return func(o *bootstrapOptions) {
+		o.renderers = renderers
+	}
+}
+
+var deppyDocs = []Doc{
+	{
+		Name:        "help",
+		Signature:   "(binding?: any | string): string",
+		Description: "Shows the documentation of a binding, given by value or by name. Without arguments, lists the bindings.",
+		Args:        []Arg{{"binding", "a binding, e.g. deppy.newVariable, or its name, e.g. \"sources.chain\""}},
+		Example:     "help(deppy.newVariable)\nhelp(\"semver\")",
+	},
+	{
+		Name:        "deppy",
+		Description: "The deppy API: problems, variables, variable sources and the resolver.",
+	},
+	{
+		Name:        "deppy.newProblem",
+		Signature:   "(resolutionProblemID: string): MutableResolutionProblem",
+		Description: "Creates an empty resolution problem. Variables are added with activateVariable.",
+		Args:        []Arg{{"resolutionProblemID", "id of the problem"}},
+		Example:     "p = deppy.newProblem(\"p\")\np.activateVariable(deppy.newVariable(\"a\", \"package\", {}))",
+	},
+	{
+		Name:      "deppy.newVariable",
+		Signature: "(variableID: string, kind: string, properties: object): MutableVariable",
+		Description: "Creates a variable. Constraints are added with addMandatory, addProhibited, addConflict, " +
+			"addDependency and addAtMost, each taking the id of the constraint first.",
+		Args: []Arg{
+			{"variableID", "id of the variable"},
+			{"kind", "kind of the variable, e.g. package"},
+			{"properties", "properties of the variable"},
+		},
+		Example: "v = deppy.newVariable(\"a\", \"package\", {version: \"1.0.0\"})\nv.addMandatory(\"required\")\nv.addDependency(\"needs-b\", \"b-1\", \"b-2\")",
+	},
+	{
+		Name:      "deppy.newResolutionProblemBuilder",
+		Signature: "(resolutionProblemID: string): ResolutionProblemBuilder",
+		Description: "Creates a builder that builds a problem out of variable sources. Add the sources with " +
+			"withVariableSources and options with withBuildOptions, then build the problem, or step through the build. " +
+			"step() takes the next variable off the build queue, offers it to every source and returns the step, " +
+			"which shows what each source added and what's left in the queue. peek() returns the variable the next " +
+			"step takes, null for the first step, queue() the variables waiting and problem() the problem built so " +
+			"far. breakOnVariable(id) and breakOnKind(kind) set breakpoints, clearBreakpoints() removes them, and " +
+			"resume() steps until the next variable to take hits a breakpoint or the build is done. Stepping after " +
+			"the build is done starts it over. buildAsync builds a step at a time alongside other asynchronous work " +
+			"and returns a promise of the problem, calling the functions it's given with every step.",
+		Args: []Arg{{"resolutionProblemID", "id of the problem"}},
+		Example: "b = deppy.newResolutionProblemBuilder(\"p\").withVariableSources(source)\np = b.build()\n\n" +
+			"// step through the build instead, stopping at packages\nb.breakOnKind(\"package\")\nb.step()\nb.resume()\n" +
+			"b.peek()\nb.queue()\nb.problem()",
+	},
+	{
+		Name:      "deppy.newVariableSourceBuilder",
+		Signature: "(variableSourceID: string): VariableSourceBuilder",
+		Description: "Creates a builder of variable sources written in JavaScript. withUpdateFn sets the function called " +
+			"with the problem and each variable taken off the build queue, null for the first call. withFinalizeFn sets " +
+			"the function called with the problem once the queue is empty, and withVariableFilterFn the predicate " +
+			"choosing the variables the source is called with. Functions return an error message, or nothing.",
+		Args: []Arg{{"variableSourceID", "id of the variable source"}},
+		Example: "s = deppy.newVariableSourceBuilder(\"s\").withUpdateFn(function(problem, variable) {\n" +
+			"  if (variable == null) problem.activateVariable(deppy.newVariable(\"a\", \"package\", {}))\n" +
+			"}).build()",
+	},
+	{
+		Name:      "deppy.solve",
+		Signature: "(problem: MutableResolutionProblem, ...options: Option[]): Solution",
+		Description: "Solves the problem. The problem is linted first, unless deppy.opts.disableLint is given. The " +
+			"solution holds the selected variables, or the conflict set if the problem can't be satisfied.",
+		Args: []Arg{
+			{"problem", "the problem to solve"},
+			{"options", "options from deppy.opts"},
+		},
+		Example: "deppy.solve(p, deppy.opts.addAllVariablesToSolution())",
+	},
+	{
+		Name:      "deppy.solveAsync",
+		Signature: "(problem: MutableResolutionProblem, ...options: Option[]): Promise<Solution>",
+		Description: "Solves the problem in the background and returns a promise of the solution, so that several " +
+			"problems can be solved at once. A command returning a promise shows what it settles with.",
+		Args: []Arg{
+			{"problem", "the problem to solve"},
+			{"options", "options from deppy.opts"},
+		},
+		Example: "Promise.all([deppy.solveAsync(p1), deppy.solveAsync(p2)])\n" +
+			"(async () => {\n  const p = await b.buildAsync(step => print(step.iteration))\n  return deppy.solveAsync(p)\n})()",
+	},
+	{
+		Name:        "deppy.lint",
+		Signature:   "(problem: ResolutionProblem): Report",
+		Description: "Checks the problem for mistakes, such as constraints referring to variables that aren't part of it.",
+		Args:        []Arg{{"problem", "the problem to check"}},
+		Example:     "deppy.lint(p)",
+	},
+	{
+		Name:        "deppy.ctx",
+		Signature:   "(): Context",
+		Description: "Returns the context of the command running, which is canceled when the command is interrupted.",
+	},
+	{
+		Name:        "deppy.id",
+		Signature:   "(format: string, ...args: any[]): string",
+		Description: "Formats an identifier.",
+		Args: []Arg{
+			{"format", "a Go format string"},
+			{"args", "the values to format"},
+		},
+		Example: "deppy.id(\"%s-%d\", \"a\", 1)",
+	},
+	{
+		Name:        "deppy.opts",
+		Description: "Options of deppy.solve.",
+	},
+	{
+		Name:        "deppy.opts.addAllVariablesToSolution",
+		Signature:   "(): Option",
+		Description: "Includes all the variables considered in the solution.",
+	},
+	{
+		Name:        "deppy.opts.disableOrderPreference",
+		Signature:   "(): Option",
+		Description: "Doesn't prefer the variables listed first in dependencies.",
+	},
+	{
+		Name:        "deppy.opts.disableLint",
+		Signature:   "(): Option",
+		Description: "Skips linting the problem before solving it.",
+	},
+	{
+		Name:      "deppy.opts.missingVariablesUnselectable",
+		Signature: "(): Option",
+		Description: "Treats the variables constraints refer to but that aren't part of the problem as prohibited. " +
+			"The conflict set names them as \"<id> is not part of the problem\".",
+	},
+	{
+		Name:        "deppy.opts.missingVariablesAsPlaceholders",
+		Signature:   "(): Option",
+		Description: "Adds an unconstrained placeholder for each variable constraints refer to but that isn't part of the problem.",
+	},
+	{
+		Name:        "deppy.buildOpts",
+		Description: "Options of the withBuildOptions method of resolution problem builders.",
+	},
+	{
+		Name:        "deppy.buildOpts.maxVariables",
+		Signature:   "(n: number): BuildOption",
+		Description: "Limits the number of variables the problem can hold.",
+		Args:        []Arg{{"n", "the maximum number of variables"}},
+	},
+	{
+		Name:        "deppy.buildOpts.maxQueueIterations",
+		Signature:   "(n: number): BuildOption",
+		Description: "Limits the number of variables taken off the build queue.",
+		Args:        []Arg{{"n", "the maximum number of iterations"}},
+	},
+	{
+		Name:        "deppy.buildOpts.maxSourceTime",
+		Signature:   "(ms: number): BuildOption",
+		Description: "Limits the time a single call to a variable source can take.",
+		Args:        []Arg{{"ms", "the time budget in milliseconds, zero disables it"}},
+	},
+	{
+		Name:        "deppy.buildOpts.maxBuildTime",
+		Signature:   "(ms: number): BuildOption",
+		Description: "Limits the total time taken to build the problem.",
+		Args:        []Arg{{"ms", "the time budget in milliseconds, zero disables it"}},
+		Example:     "deppy.newResolutionProblemBuilder(\"p\").withBuildOptions(deppy.buildOpts.maxBuildTime(5000))",
+	},
+}
+
+func BootstrapRepleeVM(ctx context.Context, vm *goja.Runtime, opts ...BootstrapOption) error {
+	o := &bootstrapOptions{help: NewHelp(), renderers: NewRenderers(), console: StderrConsole, ctx: StaticContext(ctx)}
+	for _, opt := range opts {
+		opt(o)
+	}
+	o.help.Register(deppyDocs...).
+		Register(sourcesDocs...).
+		Register(semverDocs...).
+		Register(gomodDocs...).
+		Register(dimacsDocs...).
+		Register(renderDocs...).
+		Register(displayDocs...).
+		Register(consoleDocs...).
+		Register(eventLoopDocs...)
+	if err := o.help.bind(vm); err != nil {
+		return err
+	}
+	if err := o.renderers.bind(vm); err != nil {
+		return err
+	}
+	if err := bindConsole(vm, o.renderers, o.console); err != nil {
+		return err
+	}
+	if o.loop == nil {
+		o.loop = NewEventLoop(vm)
+	}
+	if err := o.loop.bind(vm); err != nil {
+		return err
+	}
 	s := resolver.NewDeppyResolver()
 	solveWrapper := func(p *resolution.MutableResolutionProblem, options ...resolver.Option) (*resolver.Solution, error) {
-		return s.Solve(ctx, p, options...)
+		solution, err := s.Solve(o.ctx(), p, options...)
+		if err != nil {
+			return nil, err
+		}
+		for _, fn := range o.onSolve {
+			fn(p, solution)
+		}
+		return solution, nil
+	}
+	// solveAsync solves off the event loop, the solver doesn't call back into the runtime
+	solveAsync := func(p *resolution.MutableResolutionProblem, options ...resolver.Option) (*goja.Promise, error) {
+		if p == nil {
+			return nil, errors.New("no problem to solve")
+		}
+		ctx := o.ctx()
+		return o.loop.async(func() (interface{}, error) {
+			return s.Solve(ctx, p, options...)
+		}, func(result interface{}) {
+			for _, fn := range o.onSolve {
+				fn(p, result.(*resolver.Solution))
+			}
+		}), nil
 	}
 
 	return vm.Set("deppy", map[string]interface{}{
-		"newResolutionProblemBuilder": NewResolutionProblemBuilderWithCtx(ctx),
+		"newResolutionProblemBuilder": NewResolutionProblemBuilderWithLoop(o.ctx, o.loop),
 		"newProblem":                  resolution.NewMutableResolutionProblem,
 		"newVariable":                 variables.NewMutableVariable,
 		"solve":                       solveWrapper,
-		"ctx":                         context.Background,
+		"solveAsync":                  solveAsync,
+		"lint":                        lint.Lint,
+		"ctx":                         o.ctx,
 		"id":                          reflect.ValueOf(deppy.Identifierf),
-		"newVariableSourceBuilder":    NewVariableSourceBuilder(ctx, vm),
+		"newVariableSourceBuilder":    NewVariableSourceBuilder(o.ctx, vm),
+		"sources":                     NewVariableSourceCombinators(o.ctx),
+		"semver":                      NewSemverFunctions(),
+		"gomod":                       NewGoModFunctions(o.ctx),
+		"dimacs":                      NewDIMACSFunctions(),
+		"render":                      NewRenderFunctions(),
 		"opts": map[string]interface{}{
-			"addAllVariablesToSolution": resolver.AddAllVariablesToSolution,
-			"disableOrderPreference":    resolver.DisableOrderPreference,
+			"addAllVariablesToSolution":      resolver.AddAllVariablesToSolution,
+			"disableOrderPreference":         resolver.DisableOrderPreference,
+			"disableLint":                    resolver.DisableLint,
+			"missingVariablesUnselectable":   resolver.MissingVariablesUnselectable,
+			"missingVariablesAsPlaceholders": resolver.MissingVariablesAsPlaceholders,
+		},
+		// time budgets are given in milliseconds, zero disables a budget
+		"buildOpts": map[string]interface{}{
+			"maxVariables":       resolution.MaxVariables,
+			"maxQueueIterations": resolution.MaxQueueIterations,
+			"maxSourceTime": func(ms int64) resolution.BuildOption {
+				return resolution.MaxSourceTime(time.Duration(ms) * time.Millisecond)
+			},
+			"maxBuildTime": func(ms int64) resolution.BuildOption {
+				return resolution.MaxBuildTime(time.Duration(ms) * time.Millisecond)
+			},
 		},
 	})
 }
@@ -2,28 +2,155 @@ package repl
 
 import (
 	"context"
+	"errors"
+	"github.com/dop251/goja"
 	"github.com/perdasilva/replee/pkg/deppy"
 	"github.com/perdasilva/replee/pkg/deppy/resolution"
+	"time"
 )
 
+// defaultBuildOptions keep runaway variable sources typed into the REPL from building forever
+var defaultBuildOptions = []resolution.BuildOption{
+	resolution.MaxVariables(100000),
+	resolution.MaxQueueIterations(1000000),
+	resolution.MaxSourceTime(30 * time.Second),
+	resolution.MaxBuildTime(2 * time.Minute),
+}
+
 type ResolutionProblemBuilder struct {
-	ctx     context.Context
-	builder resolution.ResolutionProblemBuilder
+	ctx         ContextFunc
+	loop        *EventLoop
+	builder     resolution.ResolutionProblemBuilder
+	breakpoints []breakpoint
+}
+
+// breakpoint matches variables by id or by kind
+type breakpoint struct {
+	variableID deppy.Identifier
+	kind       string
+}
+
+func (b breakpoint) matches(v deppy.Variable) bool {
+	if v == nil {
+		return false
+	}
+	if b.variableID != "" {
+		return v.VariableID() == b.variableID
+	}
+	return v.Kind() == b.kind
 }
 
 func NewResolutionProblemBuilderWithCtx(ctx context.Context) func(variableSourceID deppy.Identifier) *ResolutionProblemBuilder {
+	return NewResolutionProblemBuilderWithLoop(StaticContext(ctx), nil)
+}
+
+// NewResolutionProblemBuilderWithLoop returns builders that can build asynchronously on the event loop, in the
+// context ctx returns when they're asked to
+func NewResolutionProblemBuilderWithLoop(ctx ContextFunc, loop *EventLoop) func(variableSourceID deppy.Identifier) *ResolutionProblemBuilder {
 	return func(problemID deppy.Identifier) *ResolutionProblemBuilder {
 		return &ResolutionProblemBuilder{
 			ctx:     ctx,
-			builder: resolution.NewResolutionProblemBuilder(problemID),
+			loop:    loop,
+			builder: resolution.NewResolutionProblemBuilder(problemID).WithBuildOptions(defaultBuildOptions...),
 		}
 	}
 }
-func (r *ResolutionProblemBuilder) WithVariableSources(variableSources ...deppy.VariableSource) *ResolutionProblemBuilder {
-	r.builder.WithVariableSources(variableSources...)
+
+// WithVariableSources accepts variable sources built in the REPL as well as plain deppy.VariableSources
+func (r *ResolutionProblemBuilder) WithVariableSources(variableSources ...interface{}) (*ResolutionProblemBuilder, error) {
+	vs, err := toVariableSources(variableSources)
+	if err != nil {
+		return nil, err
+	}
+	r.builder.WithVariableSources(vs...)
+	return r, nil
+}
+
+func (r *ResolutionProblemBuilder) WithBuildOptions(options ...resolution.BuildOption) *ResolutionProblemBuilder {
+	r.builder.WithBuildOptions(options...)
 	return r
 }
 
 func (r *ResolutionProblemBuilder) Build() (deppy.ResolutionProblem, error) {
-	return r.builder.Build(r.ctx)
+	return r.builder.Build(r.ctx())
+}
+
+// BuildAsync builds the problem a step at a time on the event loop, so that other asynchronous work goes on in the
+// meantime, and returns a promise of the problem. The functions are called after every step, to report progress.
+func (r *ResolutionProblemBuilder) BuildAsync(onStep ...func(step *resolution.BuildStep) error) (*goja.Promise, error) {
+	if r.loop == nil {
+		return nil, errors.New("the builder has no event loop to build on")
+	}
+	promise, resolve, reject := r.loop.vm.NewPromise()
+	ctx := r.ctx()
+	var next func() error
+	next = func() error {
+		step, err := r.builder.Step(ctx)
+		for i := 0; err == nil && i < len(onStep); i++ {
+			err = onStep[i](step)
+		}
+		switch {
+		case err != nil:
+			reject(err)
+		case step.Done:
+			resolve(r.builder.Problem())
+		default:
+			r.loop.schedule(next)
+		}
+		return nil
+	}
+	r.loop.schedule(next)
+	return promise, nil
+}
+
+func (r *ResolutionProblemBuilder) Step() (*resolution.BuildStep, error) {
+	return r.builder.Step(r.ctx())
+}
+
+func (r *ResolutionProblemBuilder) Peek() deppy.Variable {
+	return r.builder.Peek()
+}
+
+func (r *ResolutionProblemBuilder) Queue() []deppy.Variable {
+	return r.builder.Queue()
+}
+
+func (r *ResolutionProblemBuilder) Problem() deppy.MutableResolutionProblem {
+	return r.builder.Problem()
+}
+
+func (r *ResolutionProblemBuilder) BreakOnVariable(variableID deppy.Identifier) *ResolutionProblemBuilder {
+	r.breakpoints = append(r.breakpoints, breakpoint{variableID: variableID})
+	return r
+}
+
+func (r *ResolutionProblemBuilder) BreakOnKind(kind string) *ResolutionProblemBuilder {
+	r.breakpoints = append(r.breakpoints, breakpoint{kind: kind})
+	return r
+}
+
+func (r *ResolutionProblemBuilder) ClearBreakpoints() *ResolutionProblemBuilder {
+	r.breakpoints = nil
+	return r
+}
+
+// Resume steps through the build until the next variable to be processed hits a breakpoint
+// or the build completes, and returns the last step taken
+func (r *ResolutionProblemBuilder) Resume() (*resolution.BuildStep, error) {
+	ctx := r.ctx()
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		step, err := r.builder.Step(ctx)
+		if err != nil || step.Done {
+			return step, err
+		}
+		next := r.builder.Peek()
+		for _, b := range r.breakpoints {
+			if b.matches(next) {
+				return step, nil
+			}
+		}
+	}
 }
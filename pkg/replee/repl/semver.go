@@ -0,0 +1,137 @@
+package repl
+
+import (
+	"github.com/perdasilva/replee/pkg/deppy"
+	"github.com/perdasilva/replee/pkg/deppy/semver"
+)
+
+var semverDocs = []Doc{
+	{
+		Name:        "deppy.semver",
+		Description: "Version and version range helpers. Requirements are given as strings, e.g. \"foo >=1.2 <2\".",
+	},
+	{
+		Name:        "deppy.semver.parseVersion",
+		Signature:   "(version: string): Version",
+		Description: "Parses a semantic version.",
+		Args:        []Arg{{"version", "the version, e.g. 1.2.3"}},
+	},
+	{
+		Name:        "deppy.semver.parseRange",
+		Signature:   "(versionRange: string): Range",
+		Description: "Parses a version range. Comparisons separated by spaces or commas must all hold, and alternatives are separated by ||.",
+		Args:        []Arg{{"versionRange", "the range, e.g. \">=1.2 <2 || 3.x\""}},
+	},
+	{
+		Name:        "deppy.semver.parseRequirement",
+		Signature:   "(requirement: string): Requirement",
+		Description: "Parses a requirement of the form \"<package> [<version range>]\".",
+		Args:        []Arg{{"requirement", "the requirement, e.g. \"foo >=1.2 <2\""}},
+	},
+	{
+		Name:        "deppy.semver.compare",
+		Signature:   "(a: string, b: string): number",
+		Description: "Returns -1, 0 or 1 depending on whether version a is older than, the same as, or newer than version b.",
+		Args: []Arg{
+			{"a", "a version"},
+			{"b", "another version"},
+		},
+		Example: "deppy.semver.compare(\"1.2.0\", \"1.10.0\")",
+	},
+	{
+		Name:        "deppy.semver.satisfies",
+		Signature:   "(version: string, versionRange: string): boolean",
+		Description: "Returns true if the version is in the range.",
+		Args: []Arg{
+			{"version", "the version"},
+			{"versionRange", "the range"},
+		},
+		Example: "deppy.semver.satisfies(\"1.4.0\", \">=1.2 <2\")",
+	},
+	{
+		Name:        "deppy.semver.sortNewestFirst",
+		Signature:   "(...versions: string[]): string[]",
+		Description: "Sorts the versions, newest first.",
+		Args:        []Arg{{"versions", "the versions to sort"}},
+		Example:     "deppy.semver.sortNewestFirst(\"1.0.0\", \"2.0.0\", \"1.5.0\")",
+	},
+	{
+		Name:        "deppy.semver.candidates",
+		Signature:   "(problem: ResolutionProblem, requirement: string): string[]",
+		Description: "Returns the ids of the variables of the problem whose package and version properties match the requirement, newest first.",
+		Args: []Arg{
+			{"problem", "the problem"},
+			{"requirement", "the requirement, e.g. \"foo >=1.2 <2\""},
+		},
+		Example: "deppy.semver.candidates(p, \"foo ^1\")",
+	},
+	{
+		Name:      "deppy.semver.addDependency",
+		Signature: "(problem: ResolutionProblem, variable: MutableVariable, constraintID: string, requirement: string)",
+		Description: "Adds a dependency constraint to the variable on the variables of the problem matching the " +
+			"requirement, newest first.",
+		Args: []Arg{
+			{"problem", "the problem holding the candidates"},
+			{"variable", "the dependent variable"},
+			{"constraintID", "id of the dependency constraint"},
+			{"requirement", "the requirement, e.g. \"foo >=1.2 <2\""},
+		},
+		Example: "deppy.semver.addDependency(p, v, \"needs-foo\", \"foo ^1\")",
+	},
+}
+
+// NewSemverFunctions returns the version and version range helpers exposed as deppy.semver.
+// Requirements are given as strings, e.g. "foo >=1.2 <2".
+func NewSemverFunctions() map[string]interface{} {
+	return map[string]interface{}{
+		"parseVersion":     semver.ParseVersion,
+		"parseRange":       semver.ParseRange,
+		"parseRequirement": semver.ParseRequirement,
+		"compare":          semver.Compare,
+		"satisfies": func(version string, versionRange string) (bool, error) {
+			v, err := semver.ParseVersion(version)
+			if err != nil {
+				return false, err
+			}
+			r, err := semver.ParseRange(versionRange)
+			if err != nil {
+				return false, err
+			}
+			return r.Contains(v), nil
+		},
+		"sortNewestFirst": func(versions ...string) ([]string, error) {
+			parsed := make([]*semver.Version, len(versions))
+			for i, version := range versions {
+				v, err := semver.ParseVersion(version)
+				if err != nil {
+					return nil, err
+				}
+				parsed[i] = v
+			}
+			semver.SortNewestFirst(parsed)
+			out := make([]string, len(parsed))
+			for i, v := range parsed {
+				out[i] = v.Original()
+			}
+			return out, nil
+		},
+		"candidates": func(problem deppy.ResolutionProblem, requirement string) ([]deppy.Identifier, error) {
+			r, err := semver.ParseRequirement(requirement)
+			if err != nil {
+				return nil, err
+			}
+			vars, err := problem.GetVariables()
+			if err != nil {
+				return nil, err
+			}
+			return semver.Candidates(vars, r)
+		},
+		"addDependency": func(problem deppy.ResolutionProblem, v deppy.MutableVariable, constraintID deppy.Identifier, requirement string) error {
+			r, err := semver.ParseRequirement(requirement)
+			if err != nil {
+				return err
+			}
+			return semver.AddDependency(problem, v, constraintID, r)
+		},
+	}
+}
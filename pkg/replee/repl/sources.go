@@ -0,0 +1,257 @@
+package repl
+
+import (
+	"fmt"
+	"github.com/perdasilva/replee/pkg/deppy"
+	"github.com/perdasilva/replee/pkg/deppy/catalog"
+	"github.com/perdasilva/replee/pkg/deppy/debian"
+	"github.com/perdasilva/replee/pkg/deppy/semver"
+	"github.com/perdasilva/replee/pkg/deppy/variable_sources"
+)
+
+// toVariableSource unwraps variable sources built in the REPL and passes plain deppy.VariableSources through
+func toVariableSource(variableSource interface{}) (deppy.VariableSource, error) {
+	switch vs := variableSource.(type) {
+	case *VariableSourceWithContext:
+		return vs.variableSource, nil
+	case deppy.VariableSource:
+		return vs, nil
+	default:
+		return nil, fmt.Errorf("%T is not a variable source", variableSource)
+	}
+}
+
+func toVariableSources(variableSources []interface{}) ([]deppy.VariableSource, error) {
+	out := make([]deppy.VariableSource, len(variableSources))
+	for i, variableSource := range variableSources {
+		vs, err := toVariableSource(variableSource)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = vs
+	}
+	return out, nil
+}
+
+var sourcesDocs = []Doc{
+	{
+		Name:        "deppy.sources",
+		Description: "Variable source combinators and loaders. Combinators accept the sources built in the REPL.",
+	},
+	{
+		Name:      "deppy.sources.chain",
+		Signature: "(variableSourceID: string, ...sources: VariableSource[]): VariableSource",
+		Description: "Offers each variable to the sources in order and stops at the first error. Each source is only " +
+			"offered the variables its own filter accepts.",
+		Args: []Arg{
+			{"variableSourceID", "id of the chained source"},
+			{"sources", "the sources to chain"},
+		},
+		Example: "deppy.sources.chain(\"all\", catalog, overrides)",
+	},
+	{
+		Name:        "deppy.sources.fanOut",
+		Signature:   "(variableSourceID: string, ...sources: VariableSource[]): VariableSource",
+		Description: "Offers each variable to all the sources, even if some of them fail.",
+		Args: []Arg{
+			{"variableSourceID", "id of the combined source"},
+			{"sources", "the sources to combine"},
+		},
+		Example: "deppy.sources.fanOut(\"all\", a, b)",
+	},
+	{
+		Name:        "deppy.sources.filter",
+		Signature:   "(source: VariableSource, predicate: (variable: Variable) => boolean): VariableSource",
+		Description: "Only offers the source the variables matching the predicate.",
+		Args: []Arg{
+			{"source", "the filtered source"},
+			{"predicate", "returns true for the variables to offer"},
+		},
+		Example: "deppy.sources.filter(s, function(v) { return v.kind() == \"package\" })",
+	},
+	{
+		Name:        "deppy.sources.filterByKind",
+		Signature:   "(source: VariableSource, ...kinds: string[]): VariableSource",
+		Description: "Only offers the source the variables of the given kinds.",
+		Args: []Arg{
+			{"source", "the filtered source"},
+			{"kinds", "the kinds of the variables to offer"},
+		},
+		Example: "deppy.sources.filterByKind(s, \"package\")",
+	},
+	{
+		Name:        "deppy.sources.filterByProperty",
+		Signature:   "(source: VariableSource, key: string, predicate: (value: any) => boolean): VariableSource",
+		Description: "Only offers the source the variables that have the property and whose value matches the predicate.",
+		Args: []Arg{
+			{"source", "the filtered source"},
+			{"key", "the name of the property"},
+			{"predicate", "returns true for the values of the variables to offer"},
+		},
+		Example: "deppy.sources.filterByProperty(s, \"arch\", function(arch) { return arch == \"amd64\" })",
+	},
+	{
+		Name:        "deppy.sources.rename",
+		Signature:   "(source: VariableSource, mapping: object): VariableSource",
+		Description: "Renames the identifiers found in the mapping and leaves the others untouched.",
+		Args: []Arg{
+			{"source", "the renamed source"},
+			{"mapping", "the new identifiers by old identifier"},
+		},
+		Example: "deppy.sources.rename(s, {\"a\": \"b\"})",
+	},
+	{
+		Name:      "deppy.sources.prefix",
+		Signature: "(source: VariableSource, prefix: string): VariableSource",
+		Description: "Prefixes the identifiers of the variables the source adds, and the id of the source, so that the " +
+			"same source can be added to a problem under different prefixes.",
+		Args: []Arg{
+			{"source", "the prefixed source"},
+			{"prefix", "the prefix of the identifiers"},
+		},
+		Example: "deppy.sources.prefix(s, \"staging/\")",
+	},
+	{
+		Name:        "deppy.sources.rateLimit",
+		Signature:   "(source: VariableSource, callsPerSecond: number): VariableSource",
+		Description: "Calls the source at most the given number of times per second. A non-positive number disables the limit.",
+		Args: []Arg{
+			{"source", "the limited source"},
+			{"callsPerSecond", "the maximum number of calls per second"},
+		},
+		Example: "deppy.sources.rateLimit(s, 10)",
+	},
+	{
+		Name:      "deppy.sources.memoize",
+		Signature: "(source: VariableSource): VariableSource",
+		Description: "Remembers what the source does when offered a variable, and replays it instead of calling the " +
+			"source when later builds offer it the same variable.",
+		Args:    []Arg{{"source", "the memoized source"}},
+		Example: "s = deppy.sources.memoize(slowSource)",
+	},
+	{
+		Name:      "deppy.sources.fromFile",
+		Signature: "(path: string): VariableSource",
+		Description: "Loads a JSON or YAML document declaring variables and their constraints, under a variables list " +
+			"of {id, kind, properties, constraints}. Constraints are {id, kind, variables, n}, where kind is mandatory, " +
+			"prohibited, conflict, dependency or atMost. Not supported in the browser.",
+		Args:    []Arg{{"path", "the path of the document"}},
+		Example: "deppy.sources.fromFile(\"problem.yaml\")",
+	},
+	{
+		Name:      "deppy.sources.fromCatalog",
+		Signature: "(path: string, ...requirements: string[]): VariableSource",
+		Description: "Loads a file based catalog of bundles and packages, and requires the packages matching the " +
+			"requirements.",
+		Args: []Arg{
+			{"path", "the path of the catalog"},
+			{"requirements", "the required packages, e.g. \"foo >=1.2 <2\""},
+		},
+		Example: "deppy.sources.fromCatalog(\"catalog.json\", \"etcd >=0.9\")",
+	},
+	{
+		Name:        "deppy.sources.fromDebianPackages",
+		Signature:   "(path: string, install: string): VariableSource",
+		Description: "Loads a Debian Packages index and installs the packages given like a Depends field.",
+		Args: []Arg{
+			{"path", "the path of the Packages index"},
+			{"install", "the packages to install, e.g. \"mutt, libc6 (>= 2.34)\""},
+		},
+		Example: "deppy.sources.fromDebianPackages(\"Packages\", \"mutt\")",
+	},
+}
+
+// NewVariableSourceCombinators returns the variable source combinators exposed as deppy.sources
+func NewVariableSourceCombinators(ctx ContextFunc) map[string]interface{} {
+	wrap := func(combinator func(source deppy.VariableSource) deppy.VariableSource) func(source interface{}) (*VariableSourceWithContext, error) {
+		return func(source interface{}) (*VariableSourceWithContext, error) {
+			vs, err := toVariableSource(source)
+			if err != nil {
+				return nil, err
+			}
+			return NewVariableSourceWithContext(ctx, combinator(vs)), nil
+		}
+	}
+
+	return map[string]interface{}{
+		"chain": func(variableSourceID deppy.Identifier, sources ...interface{}) (*VariableSourceWithContext, error) {
+			vs, err := toVariableSources(sources)
+			if err != nil {
+				return nil, err
+			}
+			return NewVariableSourceWithContext(ctx, variable_sources.Chain(variableSourceID, vs...)), nil
+		},
+		"fanOut": func(variableSourceID deppy.Identifier, sources ...interface{}) (*VariableSourceWithContext, error) {
+			vs, err := toVariableSources(sources)
+			if err != nil {
+				return nil, err
+			}
+			return NewVariableSourceWithContext(ctx, variable_sources.FanOut(variableSourceID, vs...)), nil
+		},
+		"filter": func(source interface{}, predicate func(v deppy.Variable) bool) (*VariableSourceWithContext, error) {
+			return wrap(func(vs deppy.VariableSource) deppy.VariableSource {
+				return variable_sources.Filter(vs, predicate)
+			})(source)
+		},
+		"filterByKind": func(source interface{}, kinds ...string) (*VariableSourceWithContext, error) {
+			return wrap(func(vs deppy.VariableSource) deppy.VariableSource {
+				return variable_sources.FilterByKind(vs, kinds...)
+			})(source)
+		},
+		"filterByProperty": func(source interface{}, key string, predicate func(value interface{}) bool) (*VariableSourceWithContext, error) {
+			return wrap(func(vs deppy.VariableSource) deppy.VariableSource {
+				return variable_sources.FilterByProperty(vs, key, predicate)
+			})(source)
+		},
+		"rename": func(source interface{}, mapping map[deppy.Identifier]deppy.Identifier) (*VariableSourceWithContext, error) {
+			return wrap(func(vs deppy.VariableSource) deppy.VariableSource {
+				return variable_sources.RenameIdentifiers(vs, mapping)
+			})(source)
+		},
+		"prefix": func(source interface{}, prefix string) (*VariableSourceWithContext, error) {
+			return wrap(func(vs deppy.VariableSource) deppy.VariableSource {
+				return variable_sources.Prefix(vs, prefix)
+			})(source)
+		},
+		"rateLimit": func(source interface{}, callsPerSecond int) (*VariableSourceWithContext, error) {
+			return wrap(func(vs deppy.VariableSource) deppy.VariableSource {
+				return variable_sources.RateLimit(vs, callsPerSecond)
+			})(source)
+		},
+		"memoize": wrap(variable_sources.Memoize),
+		"fromFile": func(path string) (*VariableSourceWithContext, error) {
+			vs, err := sourceFromFile(path)
+			if err != nil {
+				return nil, err
+			}
+			return NewVariableSourceWithContext(ctx, vs), nil
+		},
+		"fromCatalog": func(path string, requirements ...string) (*VariableSourceWithContext, error) {
+			reqs := make([]semver.Requirement, 0, len(requirements))
+			for _, requirement := range requirements {
+				r, err := semver.ParseRequirement(requirement)
+				if err != nil {
+					return nil, err
+				}
+				reqs = append(reqs, r)
+			}
+			vs, err := catalog.NewCatalogVariableSourceFromPath(path, reqs...)
+			if err != nil {
+				return nil, err
+			}
+			return NewVariableSourceWithContext(ctx, vs), nil
+		},
+		// install is given like a Depends field, e.g. "mutt, libc6 (>= 2.34)"
+		"fromDebianPackages": func(path string, install string) (*VariableSourceWithContext, error) {
+			relations, err := debian.ParseInstallRequest(install)
+			if err != nil {
+				return nil, err
+			}
+			vs, err := debian.NewPackagesVariableSourceFromFile(path, relations...)
+			if err != nil {
+				return nil, err
+			}
+			return NewVariableSourceWithContext(ctx, vs), nil
+		},
+	}
+}
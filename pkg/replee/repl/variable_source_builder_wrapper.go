@@ -10,11 +10,11 @@ import (
 
 type VariableSourceBuilder struct {
   builder variable_sources.VariableSourceBuilder
-  ctx     context.Context
+  ctx     ContextFunc
   vm      *goja.Runtime
 }
 
-func NewVariableSourceBuilder(ctx context.Context, vm *goja.Runtime) func(variableSourceID deppy.Identifier) *VariableSourceBuilder {
+func NewVariableSourceBuilder(ctx ContextFunc, vm *goja.Runtime) func(variableSourceID deppy.Identifier) *VariableSourceBuilder {
   return func(variableSourceID deppy.Identifier) *VariableSourceBuilder {
     return &VariableSourceBuilder{
       ctx:     ctx,
@@ -101,6 +101,6 @@ func (v *VariableSourceBuilder) WithVariableFilterFn(call goja.FunctionCall) goj
 }
 
 func (v *VariableSourceBuilder) Build() *VariableSourceWithContext {
-  vs := v.builder.Build(v.ctx)
+  vs := v.builder.Build(v.ctx())
   return NewVariableSourceWithContext(v.ctx, vs)
 }
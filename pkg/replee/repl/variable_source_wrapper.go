@@ -1,16 +1,15 @@
 package repl
 
 import (
-  "context"
   "github.com/perdasilva/replee/pkg/deppy"
 )
 
 type VariableSourceWithContext struct {
   variableSource deppy.VariableSource
-  ctx            context.Context
+  ctx            ContextFunc
 }
 
-func NewVariableSourceWithContext(ctx context.Context, variableSource deppy.VariableSource) *VariableSourceWithContext {
+func NewVariableSourceWithContext(ctx ContextFunc, variableSource deppy.VariableSource) *VariableSourceWithContext {
   return &VariableSourceWithContext{
     variableSource: variableSource,
     ctx:            ctx,
@@ -26,9 +25,9 @@ func (v *VariableSourceWithContext) VariableFilterFunc() deppy.VarFilterFn {
 }
 
 func (v *VariableSourceWithContext) Update(resolution deppy.MutableResolutionProblem, nextVariable deppy.MutableVariable) error {
-  return v.variableSource.Update(v.ctx, resolution, nextVariable)
+  return v.variableSource.Update(v.ctx(), resolution, nextVariable)
 }
 
 func (v *VariableSourceWithContext) Finalize(resolution deppy.MutableResolutionProblem) error {
-  return v.variableSource.Finalize(v.ctx, resolution)
+  return v.variableSource.Finalize(v.ctx(), resolution)
 }
@@ -0,0 +1,158 @@
+package terminal
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"strings"
+)
+
+// maxCompletionRows is the number of candidates the completion popup shows at once
+const maxCompletionRows = 10
+
+// Completer returns the candidates completing the name that ends the text, along with the offset in the text where
+// the name starts
+type Completer func(text string) (int, []string)
+
+// completion is the state of the completion popup, which lists the candidates replacing the text of the input
+// between start and end
+type completion struct {
+	candidates []string
+	selected   int
+	start      int
+	end        int
+}
+
+// SetCompleter sets the function that completes the input on Tab
+func (r *RepleeTerminal) SetCompleter(completer Completer) *RepleeTerminal {
+	r.completer = completer
+	return r
+}
+
+// complete completes the name before the cursor: a single candidate is inserted, otherwise the prefix shared by the
+// candidates is inserted and the popup lists them. It returns false if there is no name to complete, so that Tab
+// can be handled as usual.
+func (r *RepleeTerminal) complete() bool {
+	if r.completer == nil || r.inputField.HasSelection() {
+		return false
+	}
+	_, _, cursor := r.inputField.GetSelection()
+	text := r.inputField.GetText()[:cursor]
+	if text == "" || !isCompletable(text[len(text)-1]) {
+		return false
+	}
+	start, candidates := r.completer(text)
+	switch len(candidates) {
+	case 0:
+		return true
+	case 1:
+		r.inputField.Replace(start, cursor, candidates[0])
+		return true
+	}
+	prefix := commonPrefix(candidates)
+	r.inputField.Replace(start, cursor, prefix)
+	r.completion = &completion{
+		candidates: candidates,
+		start:      start,
+		end:        start + len(prefix),
+	}
+	return true
+}
+
+// handleCompletionKey handles the keys pushed while the popup is shown: Tab, Down and Up move through the
+// candidates, Enter accepts the selected one, Escape closes the popup and any other key closes it before being
+// handled as usual
+func (r *RepleeTerminal) handleCompletionKey(event *tcell.EventKey) *tcell.EventKey {
+	c := r.completion
+	switch event.Key() {
+	case tcell.KeyTab, tcell.KeyDown:
+		c.selected = (c.selected + 1) % len(c.candidates)
+		return nil
+	case tcell.KeyBacktab, tcell.KeyUp:
+		c.selected = (c.selected + len(c.candidates) - 1) % len(c.candidates)
+		return nil
+	case tcell.KeyEnter:
+		r.inputField.Replace(c.start, c.end, c.candidates[c.selected])
+		r.completion = nil
+		return nil
+	case tcell.KeyEscape:
+		r.completion = nil
+		return nil
+	}
+	r.completion = nil
+	return r.handleKeyPush(event)
+}
+
+// drawCompletion draws the popup under the cursor, or above it if there isn't enough room below
+func (r *RepleeTerminal) drawCompletion(screen tcell.Screen) {
+	c := r.completion
+	if c == nil {
+		return
+	}
+	x, y, _, height := r.inputField.GetInnerRect()
+	_, _, row, column := r.inputField.GetCursor()
+	rowOffset, columnOffset := r.inputField.GetOffset()
+	labelWidth := tview.TaggedStringWidth(r.inputField.GetLabel())
+	partialWidth := tview.TaggedStringWidth(tview.Escape(r.inputField.GetText()[c.start:c.end]))
+	x += labelWidth + column - columnOffset - partialWidth
+	y += row - rowOffset
+	_, screenHeight := screen.Size()
+	if y < 0 || y >= screenHeight || row-rowOffset >= height {
+		return
+	}
+
+	rows := len(c.candidates)
+	if rows > maxCompletionRows {
+		rows = maxCompletionRows
+	}
+	width := 0
+	for _, candidate := range c.candidates {
+		if w := len([]rune(candidate)); w > width {
+			width = w
+		}
+	}
+	width += 2
+	top := y + 1
+	if top+rows > screenHeight {
+		top = y - rows
+	}
+	if top < 0 {
+		top = 0
+	}
+
+	first := 0
+	if c.selected >= rows {
+		first = c.selected - rows + 1
+	}
+	style := tcell.StyleDefault.Background(tview.Styles.MoreContrastBackgroundColor).Foreground(tview.Styles.PrimaryTextColor)
+	for i := 0; i < rows; i++ {
+		index := first + i
+		lineStyle := style
+		if index == c.selected {
+			lineStyle = style.Reverse(true)
+		}
+		text := []rune(" " + c.candidates[index])
+		for dx := 0; dx < width; dx++ {
+			ch := ' '
+			if dx < len(text) {
+				ch = text[dx]
+			}
+			screen.SetContent(x+dx, top+i, ch, nil, lineStyle)
+		}
+	}
+}
+
+// isCompletable returns true if the character can end a name or a property access
+func isCompletable(c byte) bool {
+	return c == '_' || c == '$' || c == '.' ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+func commonPrefix(candidates []string) string {
+	prefix := candidates[0]
+	for _, candidate := range candidates[1:] {
+		for !strings.HasPrefix(candidate, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
@@ -0,0 +1,347 @@
+package terminal
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gdamore/tcell/v2"
+	"github.com/perdasilva/replee/pkg/deppy"
+	"github.com/perdasilva/replee/pkg/deppy/constraints"
+	"github.com/perdasilva/replee/pkg/deppy/resolver"
+	"github.com/rivo/tview"
+	"sort"
+	"strings"
+)
+
+// GraphView is a pane to explore a resolution problem: a tree of its variables, where expanding a variable
+// shows its constraints and expanding a constraint shows the variables it refers to, next to the details of
+// the current node
+type GraphView struct {
+	*tview.Flex
+	tree        *tview.TreeView
+	details     *tview.TextView
+	problem     deppy.ResolutionProblem
+	solution    *resolver.Solution
+	variables   map[deppy.Identifier]deppy.Variable
+	activated   map[deppy.Identifier]bool
+	conflictSet map[deppy.Identifier]map[deppy.Identifier]struct{}
+	done        func()
+}
+
+// constraintRef is the reference of the tree nodes of constraints
+type constraintRef struct {
+	variableID deppy.Identifier
+	constraint deppy.Constraint
+}
+
+func NewGraphView() *GraphView {
+	g := &GraphView{
+		Flex:    tview.NewFlex(),
+		tree:    tview.NewTreeView(),
+		details: tview.NewTextView().SetDynamicColors(true).SetWrap(true),
+		done:    func() {},
+	}
+	g.tree.SetBorder(true).SetTitle(" problem ")
+	g.details.SetBorder(true).SetTitle(" details ")
+	g.tree.SetChangedFunc(g.showDetails)
+	g.tree.SetSelectedFunc(g.toggle)
+	g.tree.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEscape {
+			g.done()
+		}
+	})
+	g.AddItem(g.tree, 0, 1, true)
+	g.AddItem(g.details, 0, 1, false)
+	g.SetProblem(nil, nil)
+	return g
+}
+
+// SetDoneFunc sets the function called when the user leaves the pane with Escape
+func (g *GraphView) SetDoneFunc(fn func()) *GraphView {
+	g.done = fn
+	return g
+}
+
+// SetProblem shows the problem, with the selection or the conflict set of the solution if there is one
+func (g *GraphView) SetProblem(problem deppy.ResolutionProblem, solution *resolver.Solution) *GraphView {
+	g.problem = problem
+	g.solution = solution
+	g.variables = map[deppy.Identifier]deppy.Variable{}
+	g.activated = map[deppy.Identifier]bool{}
+	g.conflictSet = map[deppy.Identifier]map[deppy.Identifier]struct{}{}
+
+	if problem == nil {
+		root := tview.NewTreeNode("no problem, use view(problem) to show one").SetColor(tcell.ColorGray)
+		g.tree.SetRoot(root).SetCurrentNode(root)
+		g.details.SetText("")
+		return g
+	}
+
+	activated, err := problem.GetVariables()
+	if err != nil {
+		root := tview.NewTreeNode(err.Error()).SetColor(tcell.ColorRed)
+		g.tree.SetRoot(root).SetCurrentNode(root)
+		return g
+	}
+	all := activated
+	if p, ok := problem.(interface {
+		GetAllVariables() ([]deppy.Variable, error)
+	}); ok {
+		if all, err = p.GetAllVariables(); err != nil {
+			all = activated
+		}
+	}
+	for _, v := range all {
+		g.variables[v.VariableID()] = v
+	}
+	for _, v := range activated {
+		g.activated[v.VariableID()] = true
+	}
+	if solution != nil {
+		for _, applied := range solution.NotSatisfiable() {
+			id := applied.Variable.VariableID()
+			if _, ok := g.conflictSet[id]; !ok {
+				g.conflictSet[id] = map[deppy.Identifier]struct{}{}
+			}
+			g.conflictSet[id][applied.Constraint.ConstraintID()] = struct{}{}
+		}
+	}
+
+	ids := make([]deppy.Identifier, 0, len(g.variables))
+	for id := range g.variables {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+	root := tview.NewTreeNode(fmt.Sprintf("%s (%d variables)", problem.ResolutionProblemID(), len(ids))).SetColor(tcell.ColorYellow)
+	for _, id := range ids {
+		root.AddChild(g.variableNode(id))
+	}
+	g.tree.SetRoot(root).SetCurrentNode(root)
+	g.showDetails(root)
+	return g
+}
+
+func (g *GraphView) variableNode(id deppy.Identifier) *tview.TreeNode {
+	text := string(id)
+	if v, ok := g.variables[id]; ok {
+		text = fmt.Sprintf("%s (%s)", id, v.Kind())
+	}
+	return tview.NewTreeNode(text).SetReference(id).SetColor(g.variableColor(id)).SetExpanded(false)
+}
+
+func (g *GraphView) variableColor(id deppy.Identifier) tcell.Color {
+	switch {
+	case g.variables[id] == nil:
+		return tcell.ColorRed
+	case len(g.conflictSet[id]) > 0:
+		return tcell.ColorRed
+	case !g.activated[id]:
+		return tcell.ColorGray
+	case g.satisfiable() && g.solution.IsSelected(id):
+		return tcell.ColorGreen
+	case g.satisfiable():
+		return tcell.ColorGray
+	}
+	return tcell.ColorWhite
+}
+
+func (g *GraphView) satisfiable() bool {
+	return g.solution != nil && len(g.solution.NotSatisfiable()) == 0
+}
+
+// toggle expands or collapses a node, adding its children the first time it's expanded
+func (g *GraphView) toggle(node *tview.TreeNode) {
+	if node == g.tree.GetRoot() {
+		return
+	}
+	if node.IsExpanded() {
+		node.Collapse()
+		return
+	}
+	if len(node.GetChildren()) == 0 {
+		switch ref := node.GetReference().(type) {
+		case deppy.Identifier:
+			g.addConstraintNodes(node, ref)
+		case constraintRef:
+			for _, id := range referencedIDs(ref.constraint) {
+				node.AddChild(g.variableNode(id))
+			}
+		}
+	}
+	node.Expand()
+}
+
+func (g *GraphView) addConstraintNodes(node *tview.TreeNode, id deppy.Identifier) {
+	v, ok := g.variables[id]
+	if !ok {
+		return
+	}
+	constraintIDs := v.GetConstraintIDs()
+	sort.Slice(constraintIDs, func(i, j int) bool {
+		return constraintIDs[i] < constraintIDs[j]
+	})
+	for _, constraintID := range constraintIDs {
+		c, ok := v.GetConstraint(constraintID)
+		if !ok {
+			continue
+		}
+		color := tcell.ColorLightBlue
+		if activated, _ := v.IsActivated(constraintID); !activated {
+			color = tcell.ColorGray
+		}
+		if _, ok := g.conflictSet[id][constraintID]; ok {
+			color = tcell.ColorRed
+		}
+		node.AddChild(tview.NewTreeNode(c.String(id)).
+			SetReference(constraintRef{variableID: id, constraint: c}).
+			SetColor(color).
+			SetExpanded(false))
+	}
+}
+
+// referencedIDs returns the variables the constraint refers to
+func referencedIDs(c deppy.Constraint) []deppy.Identifier {
+	switch c := c.(type) {
+	case *constraints.DependencyConstraint:
+		return c.Elements()
+	case *constraints.AtMostConstraint:
+		return c.Elements()
+	case *constraints.ConflictConstraint:
+		return []deppy.Identifier{c.ConflictingVariableID()}
+	}
+	return nil
+}
+
+func (g *GraphView) showDetails(node *tview.TreeNode) {
+	if node == nil {
+		return
+	}
+	b := &strings.Builder{}
+	switch ref := node.GetReference().(type) {
+	case deppy.Identifier:
+		g.writeVariableDetails(b, ref)
+	case constraintRef:
+		g.writeConstraintDetails(b, ref)
+	default:
+		if g.problem != nil {
+			fmt.Fprintf(b, "[yellow]problem[-]     %s\n", tview.Escape(string(g.problem.ResolutionProblemID())))
+			fmt.Fprintf(b, "[yellow]variables[-]   %d (%d activated)\n", len(g.variables), len(g.activated))
+			fmt.Fprintf(b, "[yellow]last solve[-]  %s\n", g.solveStatus())
+			b.WriteString("\nenter expands a node, escape goes back to the repl")
+		}
+	}
+	g.details.SetText(b.String()).ScrollToBeginning()
+}
+
+func (g *GraphView) solveStatus() string {
+	switch {
+	case g.solution == nil:
+		return "none"
+	case g.satisfiable():
+		return fmt.Sprintf("[green]%d variables selected[-]", len(g.solution.SelectedVariables()))
+	}
+	return fmt.Sprintf("[red]not satisfiable, %d constraints in the conflict set[-]", len(g.solution.NotSatisfiable()))
+}
+
+func (g *GraphView) writeVariableDetails(b *strings.Builder, id deppy.Identifier) {
+	fmt.Fprintf(b, "[yellow]variable[-]    %s\n", tview.Escape(string(id)))
+	v, ok := g.variables[id]
+	if !ok {
+		b.WriteString("[red]missing: constraints refer to it but it isn't part of the problem[-]\n")
+		return
+	}
+	fmt.Fprintf(b, "[yellow]kind[-]        %s\n", tview.Escape(v.Kind()))
+	if g.activated[id] {
+		b.WriteString("[yellow]activation[-]  activated\n")
+	} else {
+		b.WriteString("[yellow]activation[-]  [gray]deactivated[-]\n")
+	}
+	switch {
+	case g.solution == nil:
+		b.WriteString("[yellow]last solve[-]  none\n")
+	case len(g.conflictSet[id]) > 0:
+		b.WriteString("[yellow]last solve[-]  [red]in the conflict set[-]\n")
+	case !g.satisfiable():
+		b.WriteString("[yellow]last solve[-]  not satisfiable\n")
+	case g.solution.IsSelected(id):
+		b.WriteString("[yellow]last solve[-]  [green]selected[-]\n")
+	default:
+		b.WriteString("[yellow]last solve[-]  not selected\n")
+	}
+	if lookup, ok := g.problem.(deppy.ProvenanceLookup); ok {
+		if provenance, ok := lookup.VariableProvenance(id); ok {
+			fmt.Fprintf(b, "[yellow]provenance[-]  %s\n", tview.Escape(provenance.String()))
+		}
+	}
+
+	properties := v.GetProperties()
+	keys := make([]string, 0, len(properties))
+	for key := range properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	b.WriteString("\n[yellow]properties[-]\n")
+	if len(keys) == 0 {
+		b.WriteString("  none\n")
+	}
+	for _, key := range keys {
+		value, err := json.Marshal(properties[key])
+		if err != nil {
+			value = []byte(fmt.Sprintf("%v", properties[key]))
+		}
+		fmt.Fprintf(b, "  %s: %s\n", tview.Escape(key), tview.Escape(string(value)))
+	}
+
+	constraintIDs := v.GetConstraintIDs()
+	sort.Slice(constraintIDs, func(i, j int) bool {
+		return constraintIDs[i] < constraintIDs[j]
+	})
+	b.WriteString("\n[yellow]constraints[-]\n")
+	if len(constraintIDs) == 0 {
+		b.WriteString("  none\n")
+	}
+	for _, constraintID := range constraintIDs {
+		c, ok := v.GetConstraint(constraintID)
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(b, "  %s%s\n", tview.Escape(c.String(id)), g.constraintMarks(id, constraintID))
+	}
+}
+
+func (g *GraphView) writeConstraintDetails(b *strings.Builder, ref constraintRef) {
+	fmt.Fprintf(b, "[yellow]constraint[-]  %s\n", tview.Escape(string(ref.constraint.ConstraintID())))
+	fmt.Fprintf(b, "[yellow]variable[-]    %s\n", tview.Escape(string(ref.variableID)))
+	fmt.Fprintf(b, "\n%s%s\n", tview.Escape(ref.constraint.String(ref.variableID)), g.constraintMarks(ref.variableID, ref.constraint.ConstraintID()))
+	if lookup, ok := g.problem.(deppy.ProvenanceLookup); ok {
+		if provenance, ok := lookup.ConstraintProvenance(ref.variableID, ref.constraint.ConstraintID()); ok {
+			fmt.Fprintf(b, "\n[yellow]provenance[-]  %s\n", tview.Escape(provenance.String()))
+		}
+	}
+	if ids := referencedIDs(ref.constraint); len(ids) > 0 {
+		b.WriteString("\n[yellow]refers to[-]\n")
+		for _, id := range ids {
+			status := ""
+			if _, ok := g.variables[id]; !ok {
+				status = " [red](missing)[-]"
+			} else if g.satisfiable() && g.solution.IsSelected(id) {
+				status = " [green](selected)[-]"
+			}
+			fmt.Fprintf(b, "  %s%s\n", tview.Escape(string(id)), status)
+		}
+	}
+}
+
+func (g *GraphView) constraintMarks(variableID, constraintID deppy.Identifier) string {
+	marks := ""
+	if v, ok := g.variables[variableID]; ok {
+		if activated, _ := v.IsActivated(constraintID); !activated {
+			marks += " [gray](deactivated)[-]"
+		}
+	}
+	if _, ok := g.conflictSet[variableID][constraintID]; ok {
+		marks += " [red](conflict set)[-]"
+	}
+	return marks
+}
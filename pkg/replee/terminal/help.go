@@ -0,0 +1,87 @@
+package terminal
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// HelpTopic is a page of the help browser
+type HelpTopic struct {
+	Name string
+	Text string
+}
+
+// HelpView is a pane to browse the documentation of the REPL: a list of topics next to the text of the current one
+type HelpView struct {
+	*tview.Flex
+	list   *tview.List
+	text   *tview.TextView
+	topics []HelpTopic
+	done   func()
+}
+
+func NewHelpView() *HelpView {
+	h := &HelpView{
+		Flex: tview.NewFlex(),
+		list: tview.NewList().ShowSecondaryText(false).SetHighlightFullLine(true),
+		text: tview.NewTextView().SetWrap(true).SetWordWrap(true),
+		done: func() {},
+	}
+	h.list.SetBorder(true).SetTitle(" help ")
+	h.text.SetBorder(true)
+	h.list.SetChangedFunc(func(index int, _ string, _ string, _ rune) {
+		h.showTopic(index)
+	})
+	h.list.SetDoneFunc(func() {
+		h.done()
+	})
+	h.list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		// page through long topics without leaving the list
+		switch event.Key() {
+		case tcell.KeyPgUp, tcell.KeyPgDn:
+			h.text.InputHandler()(event, nil)
+			return nil
+		}
+		return event
+	})
+	h.AddItem(h.list, 0, 1, true)
+	h.AddItem(h.text, 0, 2, false)
+	return h
+}
+
+// SetDoneFunc sets the function called when the user leaves the pane with Escape
+func (h *HelpView) SetDoneFunc(fn func()) *HelpView {
+	h.done = fn
+	return h
+}
+
+// SetTopics replaces the topics, keeping the current one if it's still there
+func (h *HelpView) SetTopics(topics []HelpTopic) *HelpView {
+	current := ""
+	if index := h.list.GetCurrentItem(); index >= 0 && index < len(h.topics) {
+		current = h.topics[index].Name
+	}
+	h.topics = topics
+	h.list.Clear()
+	selected := 0
+	for i, topic := range topics {
+		if topic.Name == current {
+			selected = i
+		}
+		h.list.AddItem(topic.Name, "", 0, nil)
+	}
+	h.list.SetCurrentItem(selected)
+	h.showTopic(selected)
+	return h
+}
+
+func (h *HelpView) showTopic(index int) {
+	if index < 0 || index >= len(h.topics) {
+		h.text.SetTitle("")
+		h.text.SetText("")
+		return
+	}
+	topic := h.topics[index]
+	h.text.SetTitle(" " + topic.Name + " ")
+	h.text.SetText(topic.Text).ScrollToBeginning()
+}
@@ -0,0 +1,39 @@
+//go:build wasm
+
+package terminal
+
+import "syscall/js"
+
+const historyStorageKey = "replee.history"
+
+// localStorageHistoryStorage keeps the history in the browser's localStorage
+type localStorageHistoryStorage struct {
+	storage js.Value
+}
+
+func newHistoryStorage() historyStorage {
+	return &localStorageHistoryStorage{storage: js.Global().Get("localStorage")}
+}
+
+func (s *localStorageHistoryStorage) Load() ([]string, error) {
+	if s.storage.IsUndefined() || s.storage.IsNull() {
+		return nil, nil
+	}
+	item := s.storage.Call("getItem", historyStorageKey)
+	if item.IsNull() || item.IsUndefined() {
+		return nil, nil
+	}
+	return decodeHistory([]byte(item.String()))
+}
+
+func (s *localStorageHistoryStorage) Save(commands []string) error {
+	if s.storage.IsUndefined() || s.storage.IsNull() {
+		return nil
+	}
+	data, err := encodeHistory(commands)
+	if err != nil {
+		return err
+	}
+	s.storage.Call("setItem", historyStorageKey, string(data))
+	return nil
+}
@@ -0,0 +1,50 @@
+//go:build !wasm
+
+package terminal
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// fileHistoryStorage keeps the history in replee/history under the user's config directory
+type fileHistoryStorage struct {
+	path string
+}
+
+func newHistoryStorage() historyStorage {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return &fileHistoryStorage{}
+	}
+	return &fileHistoryStorage{path: filepath.Join(dir, "replee", "history")}
+}
+
+func (s *fileHistoryStorage) Load() ([]string, error) {
+	if s.path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return decodeHistory(data)
+}
+
+func (s *fileHistoryStorage) Save(commands []string) error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := encodeHistory(commands)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0o600)
+}
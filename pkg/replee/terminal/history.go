@@ -0,0 +1,169 @@
+package terminal
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"strings"
+	"sync"
+)
+
+// maxHistory is the number of commands kept in the persisted history
+const maxHistory = 1000
+
+// historyStorage persists the command history between sessions
+type historyStorage interface {
+	Load() ([]string, error)
+	Save(commands []string) error
+}
+
+// SharedHistory is the persisted history of the terminals of the application, one per tab. Each terminal has a
+// command history of its own: it starts with the commands of the previous sessions, as they were when the first
+// terminal was created, and gets the commands run in the terminal, not those run in the other tabs. The persisted
+// history gets the commands of all the terminals, for the sessions to come.
+type SharedHistory struct {
+	storage  historyStorage
+	once     sync.Once
+	commands []string
+}
+
+func NewSharedHistory() *SharedHistory {
+	return newSharedHistory(newHistoryStorage())
+}
+
+func newSharedHistory(storage historyStorage) *SharedHistory {
+	return &SharedHistory{storage: storage}
+}
+
+// load returns the command history a new terminal starts with
+func (h *SharedHistory) load() []string {
+	h.once.Do(func() {
+		h.commands, _ = h.storage.Load()
+	})
+	return append([]string(nil), h.commands...)
+}
+
+func encodeHistory(commands []string) ([]byte, error) {
+	return json.Marshal(commands)
+}
+
+func decodeHistory(data []byte) ([]string, error) {
+	var commands []string
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return nil, nil
+	}
+	if err := json.Unmarshal(data, &commands); err != nil {
+		return nil, err
+	}
+	if len(commands) > maxHistory {
+		commands = commands[len(commands)-maxHistory:]
+	}
+	return commands, nil
+}
+
+// appendHistory adds the command to the end of the history, dropping its earlier occurrences
+// and the oldest commands beyond maxHistory
+func appendHistory(history []string, command string) []string {
+	out := make([]string, 0, len(history)+1)
+	for _, c := range history {
+		if c != command {
+			out = append(out, c)
+		}
+	}
+	out = append(out, command)
+	if len(out) > maxHistory {
+		out = out[len(out)-maxHistory:]
+	}
+	return out
+}
+
+// searchHistory returns the index of the most recent command before the given index that contains the query, or -1
+func searchHistory(history []string, query string, before int) int {
+	if before > len(history) {
+		before = len(history)
+	}
+	for i := before - 1; i >= 0; i-- {
+		if strings.Contains(history[i], query) {
+			return i
+		}
+	}
+	return -1
+}
+
+// saveHistory adds the command to the persisted history. The history is read back first, as the other terminals
+// add their commands to it too, and replaced with the history of this terminal if it can't be.
+func (r *RepleeTerminal) saveHistory(command string) {
+	stored, err := r.history.Load()
+	if err != nil {
+		_ = r.history.Save(r.commandHistory)
+		return
+	}
+	_ = r.history.Save(appendHistory(stored, command))
+}
+
+const searchPrompt = "[yellow](reverse-i-search)`%s': "
+const failedSearchPrompt = "[red](failed reverse-i-search)`%s': "
+
+func (r *RepleeTerminal) startSearch() {
+	r.searching = true
+	r.searchQuery = ""
+	r.searchIndex = len(r.commandHistory)
+	r.curCommand = r.inputField.GetText()
+	r.showSearch(true)
+}
+
+// handleSearchKey handles the keys pushed during a reverse incremental search: typing refines the query, Ctrl-R
+// looks for an older match, Escape or Ctrl-G cancel the search and any other key accepts the match before being
+// handled as usual
+func (r *RepleeTerminal) handleSearchKey(event *tcell.EventKey) *tcell.EventKey {
+	switch event.Key() {
+	case tcell.KeyCtrlR:
+		r.search(r.searchIndex)
+		return nil
+	case tcell.KeyRune:
+		r.searchQuery += string(event.Rune())
+		r.search(r.searchIndex + 1)
+		return nil
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if r.searchQuery != "" {
+			runes := []rune(r.searchQuery)
+			r.searchQuery = string(runes[:len(runes)-1])
+			r.search(len(r.commandHistory))
+		}
+		return nil
+	case tcell.KeyEscape, tcell.KeyCtrlG:
+		r.stopSearch()
+		r.inputField.SetText(r.curCommand, true)
+		return nil
+	}
+	r.stopSearch()
+	return r.handleKeyPush(event)
+}
+
+// search looks for the query in the commands before the given index, keeping the current match if there is none
+func (r *RepleeTerminal) search(before int) {
+	if i := searchHistory(r.commandHistory, r.searchQuery, before); i >= 0 {
+		r.searchIndex = i
+		r.showSearch(true)
+		return
+	}
+	r.showSearch(false)
+}
+
+func (r *RepleeTerminal) showSearch(found bool) {
+	label := searchPrompt
+	if !found {
+		label = failedSearchPrompt
+	}
+	r.inputField.SetLabel(fmt.Sprintf(label, tview.Escape(r.searchQuery)))
+	if found && r.searchIndex < len(r.commandHistory) {
+		r.inputField.SetText(r.commandHistory[r.searchIndex], true)
+	}
+}
+
+func (r *RepleeTerminal) stopSearch() {
+	r.searching = false
+	r.commandHistoryIndex = 0
+	r.inputField.SetLabel(prompt)
+}
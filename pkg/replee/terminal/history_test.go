@@ -0,0 +1,81 @@
+package terminal
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppendHistory(t *testing.T) {
+	history := appendHistory(nil, "a")
+	history = appendHistory(history, "b")
+	history = appendHistory(history, "c")
+	// running a command again moves it to the end
+	history = appendHistory(history, "a")
+	assert.Equal(t, []string{"b", "c", "a"}, history)
+
+	history = nil
+	for i := 0; i < maxHistory+10; i++ {
+		history = appendHistory(history, fmt.Sprintf("command %d", i))
+	}
+	assert.Len(t, history, maxHistory)
+	assert.Equal(t, "command 10", history[0])
+	assert.Equal(t, fmt.Sprintf("command %d", maxHistory+9), history[maxHistory-1])
+}
+
+func TestSearchHistory(t *testing.T) {
+	history := []string{"deppy.solve(p)", "let p = 1", "deppy.lint(p)", "view()"}
+	assert.Equal(t, 2, searchHistory(history, "deppy", len(history)))
+	// older matches are found from the current one
+	assert.Equal(t, 0, searchHistory(history, "deppy", 2))
+	assert.Equal(t, -1, searchHistory(history, "deppy", 0))
+	assert.Equal(t, 3, searchHistory(history, "", len(history)))
+	assert.Equal(t, -1, searchHistory(history, "missing", len(history)))
+	// indexes past the end search the whole history
+	assert.Equal(t, 2, searchHistory(history, "lint", 100))
+}
+
+func TestDecodeHistory(t *testing.T) {
+	data, err := encodeHistory([]string{"a", "b"})
+	assert.NoError(t, err)
+	commands, err := decodeHistory(data)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, commands)
+
+	commands, err = decodeHistory([]byte("  "))
+	assert.NoError(t, err)
+	assert.Empty(t, commands)
+
+	_, err = decodeHistory([]byte("not json"))
+	assert.Error(t, err)
+}
+
+type memoryHistoryStorage struct {
+	commands []string
+	loads    int
+}
+
+func (s *memoryHistoryStorage) Load() ([]string, error) {
+	s.loads++
+	return s.commands, nil
+}
+
+func (s *memoryHistoryStorage) Save(commands []string) error {
+	s.commands = commands
+	return nil
+}
+
+func TestSharedHistory_Load(t *testing.T) {
+	storage := &memoryHistoryStorage{commands: []string{"a", "b"}}
+	history := newSharedHistory(storage)
+	first := history.load()
+	assert.Equal(t, []string{"a", "b"}, first)
+
+	// commands run in a tab aren't in the history of the tabs opened after it
+	first = appendHistory(first, "c")
+	assert.NoError(t, storage.Save(first))
+	second := history.load()
+	assert.Equal(t, []string{"a", "b"}, second)
+	assert.Equal(t, 1, storage.loads)
+}
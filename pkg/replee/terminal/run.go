@@ -0,0 +1,79 @@
+package terminal
+
+import (
+	"fmt"
+	"github.com/gdamore/tcell/v2"
+	"time"
+)
+
+// spinnerFrames are the frames of the spinner shown in place of the prompt while a command runs
+var spinnerFrames = []rune("⠋⠙⠹⠸⠼⠴⠦⠧⠇⠏")
+
+const spinnerInterval = 100 * time.Millisecond
+
+// Running returns true while a command runs
+func (r *RepleeTerminal) Running() bool {
+	return r.running
+}
+
+// run executes the command off the goroutine of the application, so that the UI keeps responding, with a spinner
+// in place of the prompt until it's done
+func (r *RepleeTerminal) run(command string) {
+	r.running = true
+	r.startedAt = time.Now()
+	r.showSpinner()
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(spinnerInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				r.app.QueueUpdateDraw(r.showSpinner)
+			}
+		}
+	}()
+	go func() {
+		result := r.executeRecovered(command)
+		close(done)
+		r.app.QueueUpdateDraw(func() {
+			r.finish(result)
+		})
+	}()
+}
+
+// executeRecovered executes the command, turning a panic into an error output. A panic off the goroutine of the
+// application would take it down and leave the terminal in raw mode.
+func (r *RepleeTerminal) executeRecovered(command string) (result *Output) {
+	defer func() {
+		if p := recover(); p != nil {
+			result = &Output{IsErr: true, Output: fmt.Sprintf("panic: %v", p)}
+		}
+	}()
+	return r.execute(command)
+}
+
+func (r *RepleeTerminal) showSpinner() {
+	if !r.running {
+		return
+	}
+	elapsed := time.Since(r.startedAt)
+	frame := spinnerFrames[int(elapsed/spinnerInterval)%len(spinnerFrames)]
+	r.inputField.SetLabel(fmt.Sprintf("[yellow]%c %.1fs [gray](Ctrl-C interrupts)[-] ", frame, elapsed.Seconds()))
+}
+
+// finish adds the output of the command and gives the prompt back
+func (r *RepleeTerminal) finish(result *Output) {
+	r.running = false
+	r.inputField.SetLabel(prompt)
+	color := tcell.ColorViolet
+	if result.IsErr {
+		color = tcell.ColorRed
+	}
+	for _, line := range outputLines(result.Output, result.Styled, color) {
+		r.addLineHistory(line)
+	}
+	r.output.bottom()
+}
@@ -0,0 +1,18 @@
+package terminal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecuteRecovered(t *testing.T) {
+	r := &RepleeTerminal{execute: func(command string) *Output {
+		if command == "boom" {
+			panic("boom")
+		}
+		return &Output{Output: command}
+	}}
+	assert.Equal(t, &Output{Output: "1 + 1"}, r.executeRecovered("1 + 1"))
+	assert.Equal(t, &Output{IsErr: true, Output: "panic: boom"}, r.executeRecovered("boom"))
+}
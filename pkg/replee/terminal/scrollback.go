@@ -0,0 +1,221 @@
+package terminal
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"strings"
+)
+
+// defaultScrollback is the number of lines the terminal keeps by default
+const defaultScrollback = 10000
+
+const highlightTag = "[black:yellow]"
+
+// scrollback draws the lines of the terminal that fit in its rect, scrolled up from the bottom by a number of
+// lines, with the matches of the search query highlighted. Lines are plain text, colored by type.
+type scrollback struct {
+	*tview.Box
+	lines    []lineHistoryEntry
+	maxLines int
+	// scroll is the number of lines scrolled up from the bottom, zero follows the output
+	scroll int
+	// rows is the height the scrollback was last drawn with
+	rows  int
+	query string
+	// match is the index of the line of the current search match, -1 if there is none
+	match int
+}
+
+func newScrollback() *scrollback {
+	return &scrollback{
+		Box:      tview.NewBox(),
+		maxLines: defaultScrollback,
+		match:    -1,
+	}
+}
+
+func (s *scrollback) add(line lineHistoryEntry) {
+	s.lines = append(s.lines, line)
+	s.trim()
+	if s.scroll > 0 {
+		// keep the lines the user is looking at in place
+		s.scroll++
+	}
+}
+
+func (s *scrollback) clear() {
+	s.lines = nil
+	s.scroll = 0
+	s.match = -1
+	s.query = ""
+}
+
+func (s *scrollback) setMaxLines(n int) {
+	if n < 1 {
+		n = 1
+	}
+	s.maxLines = n
+	s.trim()
+	s.clampScroll(s.rows)
+}
+
+// trim drops the oldest lines beyond maxLines, along with the search match if it's one of them
+func (s *scrollback) trim() {
+	if len(s.lines) <= s.maxLines {
+		return
+	}
+	dropped := len(s.lines) - s.maxLines
+	s.lines = s.lines[dropped:]
+	s.match -= dropped
+	if s.match < 0 {
+		s.match = -1
+	}
+}
+
+// visibleLines returns the number of lines to show in the given number of rows
+func (s *scrollback) visibleLines(rows int) int {
+	s.clampScroll(rows)
+	visible := len(s.lines) - s.scroll
+	if visible > rows {
+		return rows
+	}
+	return visible
+}
+
+func (s *scrollback) clampScroll(rows int) {
+	maxScroll := len(s.lines) - rows
+	if maxScroll < 0 {
+		maxScroll = 0
+	}
+	if s.scroll > maxScroll {
+		s.scroll = maxScroll
+	}
+	if s.scroll < 0 {
+		s.scroll = 0
+	}
+}
+
+func (s *scrollback) scrollBy(n int) {
+	s.scroll += n
+	s.clampScroll(s.rows)
+}
+
+func (s *scrollback) pageSize() int {
+	if s.rows > 1 {
+		return s.rows - 1
+	}
+	return 1
+}
+
+func (s *scrollback) top() {
+	s.scroll = len(s.lines)
+	s.clampScroll(s.rows)
+}
+
+func (s *scrollback) bottom() {
+	s.scroll = 0
+}
+
+func (s *scrollback) scrolled() bool {
+	return s.scroll > 0
+}
+
+// find searches for the query from the current match, or from the bottom of the view if there is none, towards older
+// lines unless forward is set, and scrolls the match into view. It returns false if there is no match.
+func (s *scrollback) find(query string, forward bool) bool {
+	if query == "" {
+		return false
+	}
+	if query != s.query {
+		s.query = query
+		s.match = -1
+	}
+	from := s.match
+	if from < 0 {
+		from = len(s.lines) - s.scroll
+		if forward {
+			from = len(s.lines) - s.scroll - s.rows - 1
+		}
+	}
+	step := -1
+	if forward {
+		step = 1
+	}
+	for i := from + step; i >= 0 && i < len(s.lines); i += step {
+		if strings.Contains(s.lines[i].text, query) {
+			s.match = i
+			s.reveal(i)
+			return true
+		}
+	}
+	return false
+}
+
+// clearSearch stops highlighting the matches of the search
+func (s *scrollback) clearSearch() {
+	s.query = ""
+	s.match = -1
+}
+
+// reveal scrolls the line into view, leaving the view alone if it's already visible
+func (s *scrollback) reveal(i int) {
+	end := len(s.lines) - s.scroll
+	start := end - s.rows
+	if i >= start && i < end {
+		return
+	}
+	s.scroll = len(s.lines) - i - s.rows/2 - 1
+	s.clampScroll(s.rows)
+}
+
+func (s *scrollback) Draw(screen tcell.Screen) {
+	s.Box.DrawForSubclass(screen, s)
+	x, y, width, rows := s.GetInnerRect()
+	s.rows = rows
+	s.clampScroll(rows)
+	end := len(s.lines) - s.scroll
+	start := end - rows
+	if start < 0 {
+		start = 0
+	}
+	for i := start; i < end; i++ {
+		line := s.lines[i]
+		prefixWidth := 0
+		switch line.lineType {
+		case lineTypePrompt:
+			_, prefixWidth = tview.Print(screen, prompt, x, y, width, tview.AlignLeft, tcell.ColorWhite)
+		case lineTypeInput:
+			prefixWidth = len(notPrompt)
+		}
+		text := line.styled
+		if text == "" || s.query != "" && strings.Contains(line.text, s.query) {
+			text = s.highlight(line.text, i == s.match)
+		}
+		tview.Print(screen, text, x+prefixWidth, y, width-prefixWidth, tview.AlignLeft, line.color)
+		y++
+	}
+}
+
+// highlight escapes the text and highlights the matches of the search query, the whole line if it's the current match
+func (s *scrollback) highlight(text string, current bool) string {
+	if s.query == "" || !strings.Contains(text, s.query) {
+		return tview.Escape(text)
+	}
+	sb := strings.Builder{}
+	if current {
+		sb.WriteString("[::u]")
+	}
+	for {
+		i := strings.Index(text, s.query)
+		if i < 0 {
+			break
+		}
+		sb.WriteString(tview.Escape(text[:i]))
+		sb.WriteString(highlightTag)
+		sb.WriteString(tview.Escape(s.query))
+		sb.WriteString("[-:-]")
+		text = text[i+len(s.query):]
+	}
+	sb.WriteString(tview.Escape(text))
+	return sb.String()
+}
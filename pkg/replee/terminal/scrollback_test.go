@@ -0,0 +1,82 @@
+package terminal
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestScrollback(rows int, lines ...string) *scrollback {
+	s := newScrollback()
+	s.rows = rows
+	for _, line := range lines {
+		s.add(lineHistoryEntry{text: line})
+	}
+	return s
+}
+
+func TestScrollback_Add(t *testing.T) {
+	s := newTestScrollback(2, "a", "b", "c")
+	s.setMaxLines(3)
+	s.scrollBy(1)
+	// the lines in view stay in place, and the oldest line is dropped
+	s.add(lineHistoryEntry{text: "d"})
+	assert.Equal(t, 2, s.scroll)
+	assert.Len(t, s.lines, 3)
+	assert.Equal(t, "b", s.lines[0].text)
+
+	// the output is followed when the view isn't scrolled
+	s.bottom()
+	s.add(lineHistoryEntry{text: "e"})
+	assert.Equal(t, 0, s.scroll)
+}
+
+func TestScrollback_Find(t *testing.T) {
+	s := newTestScrollback(2, "match 0", "other", "match 2", "other", "match 4", "other")
+	assert.True(t, s.find("match", false))
+	assert.Equal(t, 4, s.match)
+	assert.True(t, s.find("match", false))
+	assert.Equal(t, 2, s.match)
+	// the match is scrolled into view
+	assert.Equal(t, 2, s.scroll)
+	assert.True(t, s.find("match", false))
+	assert.Equal(t, 0, s.match)
+	assert.False(t, s.find("match", false))
+	assert.Equal(t, 0, s.match)
+	assert.True(t, s.find("match", true))
+	assert.Equal(t, 2, s.match)
+
+	// a new query starts from the view
+	s.bottom()
+	assert.True(t, s.find("other", false))
+	assert.Equal(t, 5, s.match)
+	assert.False(t, s.find("missing", false))
+	assert.False(t, s.find("", false))
+}
+
+func TestScrollback_TrimMatch(t *testing.T) {
+	var lines []string
+	for i := 0; i < 10; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	s := newTestScrollback(2, lines...)
+	assert.True(t, s.find("line", false))
+	assert.True(t, s.find("line", false))
+	assert.Equal(t, 8, s.match)
+
+	// the match follows its line when older lines are dropped
+	s.setMaxLines(5)
+	assert.Len(t, s.lines, 5)
+	assert.Equal(t, 3, s.match)
+	assert.LessOrEqual(t, s.scroll, 3)
+	assert.True(t, s.find("line", false))
+	assert.Equal(t, 2, s.match)
+	assert.Equal(t, "line 7", s.lines[s.match].text)
+
+	// and is gone with its line
+	s.setMaxLines(2)
+	assert.Equal(t, -1, s.match)
+	s.add(lineHistoryEntry{text: "line 10"})
+	assert.Equal(t, -1, s.match)
+}
@@ -0,0 +1,363 @@
+package terminal
+
+import (
+	"fmt"
+	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+	"github.com/rivo/tview"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	// tokenText is everything that isn't highlighted: identifiers, operators and white space
+	tokenText tokenKind = iota
+	tokenKeyword
+	tokenString
+	tokenRegexp
+	tokenNumber
+	tokenComment
+	tokenBracket
+)
+
+// token is a span of the text, from start to end in bytes. Unterminated is set on strings and block comments that
+// run to the end of the text.
+type token struct {
+	kind         tokenKind
+	start        int
+	end          int
+	unterminated bool
+}
+
+var tokenColors = map[tokenKind]tcell.Color{
+	tokenKeyword: tcell.ColorDodgerBlue,
+	tokenString:  tcell.ColorLightGreen,
+	tokenRegexp:  tcell.ColorLightSalmon,
+	tokenNumber:  tcell.ColorGold,
+	tokenComment: tcell.ColorGray,
+}
+
+// unmatchedBracketColor is the color of the brackets that have no pair
+const unmatchedBracketColor = tcell.ColorRed
+
+// matchedBracketBackground is the background of the bracket next to the cursor and of its pair
+const matchedBracketBackground = tcell.ColorTeal
+
+var keywords = map[string]struct{}{
+	"async": {}, "await": {}, "break": {}, "case": {}, "catch": {}, "class": {}, "const": {}, "continue": {},
+	"debugger": {}, "default": {}, "delete": {}, "do": {}, "else": {}, "export": {}, "extends": {}, "false": {},
+	"finally": {}, "for": {}, "function": {}, "if": {}, "import": {}, "in": {}, "instanceof": {}, "let": {},
+	"new": {}, "null": {}, "of": {}, "return": {}, "super": {}, "switch": {}, "this": {}, "throw": {},
+	"true": {}, "try": {}, "typeof": {}, "undefined": {}, "var": {}, "void": {}, "while": {}, "with": {},
+	"yield": {},
+}
+
+// operandKeywords are the keywords that are values, which a slash after divides
+var operandKeywords = map[string]struct{}{
+	"false": {}, "null": {}, "super": {}, "this": {}, "true": {}, "undefined": {},
+}
+
+var closingBrackets = map[byte]byte{'(': ')', '[': ']', '{': '}'}
+
+// tokenize splits JavaScript source into tokens, well enough to highlight it and to match its brackets. Template
+// literals are single strings, substitutions included. A slash starts a regular expression literal where an operand
+// is expected, that is unless it follows a value: an identifier, a literal, or a closing parenthesis or bracket.
+func tokenize(text string) []token {
+	var tokens []token
+	// operand is set when the last token, white space and comments aside, ends a value
+	operand := false
+	for i := 0; i < len(text); {
+		c := text[i]
+		start := i
+		kind := tokenText
+		unterminated := false
+		switch {
+		case c == '/' && i+1 < len(text) && text[i+1] == '/':
+			kind = tokenComment
+			i = indexFrom(text, "\n", i)
+		case c == '/' && i+1 < len(text) && text[i+1] == '*':
+			kind = tokenComment
+			if end := strings.Index(text[i+2:], "*/"); end >= 0 {
+				i += 2 + end + 2
+			} else {
+				i = len(text)
+				unterminated = true
+			}
+		case c == '/' && !operand:
+			kind = tokenRegexp
+			i = regexpEnd(text, i)
+			operand = true
+		case c == '"' || c == '\'' || c == '`':
+			kind = tokenString
+			i, unterminated = stringEnd(text, i)
+			operand = true
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(text) && text[i+1] >= '0' && text[i+1] <= '9':
+			kind = tokenNumber
+			for i < len(text) && (isIdentifierByte(text[i]) || text[i] == '.') {
+				i++
+			}
+			operand = true
+		case isIdentifierByte(c):
+			for i < len(text) && isIdentifierByte(text[i]) {
+				i++
+			}
+			operand = true
+			// property names are never keywords
+			if _, ok := keywords[text[start:i]]; ok && (start == 0 || text[start-1] != '.') {
+				kind = tokenKeyword
+				_, operand = operandKeywords[text[start:i]]
+			}
+		case strings.IndexByte("()[]{}", c) >= 0:
+			kind = tokenBracket
+			i++
+			// a closing brace more often ends a block than an object literal
+			operand = c == ')' || c == ']'
+		default:
+			i++
+			if c != ' ' && c != '\t' && c != '\n' && c != '\r' {
+				operand = false
+			}
+		}
+		if kind == tokenText && len(tokens) > 0 && tokens[len(tokens)-1].kind == tokenText {
+			tokens[len(tokens)-1].end = i
+			continue
+		}
+		tokens = append(tokens, token{kind: kind, start: start, end: i, unterminated: unterminated})
+	}
+	return tokens
+}
+
+// stringEnd returns the end of the string literal starting at i, and whether it's unterminated. Line breaks only
+// end single and double quoted strings.
+func stringEnd(text string, i int) (int, bool) {
+	quote := text[i]
+	for i++; i < len(text); i++ {
+		switch {
+		case text[i] == '\\':
+			i++
+		case text[i] == quote:
+			return i + 1, false
+		case text[i] == '\n' && quote != '`':
+			return i, true
+		}
+	}
+	return len(text), true
+}
+
+// regexpEnd returns the end of the regular expression literal starting at i, flags included. Slashes in character
+// classes don't end it. A line break does, as the literal can't span lines, leaving the error to the parser.
+func regexpEnd(text string, i int) int {
+	class := false
+	for i++; i < len(text); i++ {
+		switch {
+		case text[i] == '\\':
+			i++
+		case text[i] == '\n':
+			return i
+		case text[i] == '[':
+			class = true
+		case text[i] == ']':
+			class = false
+		case text[i] == '/' && !class:
+			for i++; i < len(text) && isIdentifierByte(text[i]); i++ {
+			}
+			return i
+		}
+	}
+	return len(text)
+}
+
+func indexFrom(text string, substr string, i int) int {
+	if end := strings.Index(text[i:], substr); end >= 0 {
+		return i + end
+	}
+	return len(text)
+}
+
+func isIdentifierByte(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c >= 0x80
+}
+
+// brackets pairs the brackets of the text by offset
+type brackets struct {
+	match     map[int]int
+	unmatched map[int]struct{}
+	// open are the offsets of the brackets left open at the end of the text, innermost last
+	open []int
+}
+
+func matchBrackets(text string, tokens []token) brackets {
+	b := brackets{match: map[int]int{}, unmatched: map[int]struct{}{}}
+	for _, t := range tokens {
+		if t.kind != tokenBracket {
+			continue
+		}
+		c := text[t.start]
+		if _, ok := closingBrackets[c]; ok {
+			b.open = append(b.open, t.start)
+			continue
+		}
+		if len(b.open) == 0 || closingBrackets[text[b.open[len(b.open)-1]]] != c {
+			b.unmatched[t.start] = struct{}{}
+			continue
+		}
+		opening := b.open[len(b.open)-1]
+		b.open = b.open[:len(b.open)-1]
+		b.match[opening] = t.start
+		b.match[t.start] = opening
+	}
+	return b
+}
+
+// incomplete returns true if the text leaves brackets, a string or a comment open, so that Enter continues it on a
+// new line rather than running it. Text with brackets that close nothing is complete, if wrong, so that running it
+// reports the error.
+func incomplete(text string) bool {
+	tokens := tokenize(text)
+	if len(tokens) > 0 && tokens[len(tokens)-1].unterminated {
+		return true
+	}
+	b := matchBrackets(text, tokens)
+	return len(b.open) > 0 && len(b.unmatched) == 0
+}
+
+// depth returns the number of brackets the text leaves open
+func depth(text string) int {
+	return len(matchBrackets(text, tokenize(text)).open)
+}
+
+func indentation(depth int) string {
+	if depth < 0 {
+		depth = 0
+	}
+	return strings.Repeat("  ", depth)
+}
+
+// tokenColor returns the color of the token, or false if the token keeps the color of the text
+func tokenColor(t token, b brackets) (tcell.Color, bool) {
+	if t.kind == tokenBracket {
+		if _, ok := b.unmatched[t.start]; ok {
+			return unmatchedBracketColor, true
+		}
+		return 0, false
+	}
+	color, ok := tokenColors[t.kind]
+	return color, ok
+}
+
+// highlightLines returns the lines of the JavaScript source with color tags. Text that keeps its color is escaped
+// in runs, as brackets in separate tokens could otherwise make up tags.
+func highlightLines(text string) []string {
+	tokens := tokenize(text)
+	b := matchBrackets(text, tokens)
+	var lines []string
+	line := &strings.Builder{}
+	plain := &strings.Builder{}
+	flush := func() {
+		line.WriteString(tview.Escape(plain.String()))
+		plain.Reset()
+	}
+	for _, t := range tokens {
+		color, colored := tokenColor(t, b)
+		for i, part := range strings.Split(text[t.start:t.end], "\n") {
+			if i > 0 {
+				flush()
+				lines = append(lines, line.String())
+				line.Reset()
+			}
+			switch {
+			case part == "":
+			case colored:
+				flush()
+				line.WriteString(fmt.Sprintf("[#%06x]%s[-]", color.Hex(), tview.Escape(part)))
+			default:
+				plain.WriteString(part)
+			}
+		}
+	}
+	flush()
+	return append(lines, line.String())
+}
+
+// continueCommand continues the command on a new line, indented by the brackets it leaves open
+func (r *RepleeTerminal) continueCommand(command string) {
+	r.inputField.SetText(command+"\n"+indentation(depth(command)), true)
+}
+
+// dedent types the closing bracket at the indentation of the line opening its bracket, if the cursor is on a line
+// holding nothing but white space. It returns false if the bracket should be typed as usual.
+func (r *RepleeTerminal) dedent(closing rune) bool {
+	if r.inputField.HasSelection() {
+		return false
+	}
+	_, _, cursor := r.inputField.GetSelection()
+	text := r.inputField.GetText()
+	lineStart := strings.LastIndex(text[:cursor], "\n") + 1
+	if lineStart == 0 || strings.TrimSpace(text[lineStart:cursor]) != "" {
+		return false
+	}
+	r.inputField.Replace(lineStart, cursor, indentation(depth(text[:cursor])-1)+string(closing))
+	return true
+}
+
+// drawSyntax colors the text of the input, drawn by the text area, and highlights the bracket next to the cursor
+// along with its pair
+func (r *RepleeTerminal) drawSyntax(screen tcell.Screen) {
+	text := r.inputField.GetText()
+	if text == "" || r.searching || r.finding {
+		return
+	}
+	x, y, width, height := r.inputField.GetInnerRect()
+	labelWidth := tview.TaggedStringWidth(r.inputField.GetLabel())
+	x += labelWidth
+	width -= labelWidth
+	rowOffset, columnOffset := r.inputField.GetOffset()
+	tokens := tokenize(text)
+	b := matchBrackets(text, tokens)
+	matched := r.matchedBrackets(text, b)
+
+	row, column := 0, 0
+	for _, t := range tokens {
+		color, colored := tokenColor(t, b)
+		for i, ch := range text[t.start:t.end] {
+			if ch == '\n' {
+				row++
+				column = 0
+				continue
+			}
+			screenX, screenY := x+column-columnOffset, y+row-rowOffset
+			if ch == '\t' {
+				column += tview.TabSize
+			} else {
+				column += runewidth.RuneWidth(ch)
+			}
+			_, isMatched := matched[t.start+i]
+			if !colored && !isMatched || screenX < x || screenX >= x+width || screenY < y || screenY >= y+height {
+				continue
+			}
+			mainc, combc, style, _ := screen.GetContent(screenX, screenY)
+			if colored {
+				style = style.Foreground(color)
+			}
+			if isMatched {
+				style = style.Background(matchedBracketBackground)
+			}
+			screen.SetContent(screenX, screenY, mainc, combc, style)
+		}
+	}
+}
+
+// matchedBrackets returns the offsets of the bracket before the cursor, or else after it, and of its pair
+func (r *RepleeTerminal) matchedBrackets(text string, b brackets) map[int]struct{} {
+	if r.inputField.HasSelection() {
+		return nil
+	}
+	_, _, cursor := r.inputField.GetSelection()
+	for _, offset := range []int{cursor - 1, cursor} {
+		if pair, ok := b.match[offset]; ok {
+			return map[int]struct{}{offset: {}, pair: {}}
+		}
+	}
+	return nil
+}
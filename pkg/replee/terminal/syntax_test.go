@@ -0,0 +1,146 @@
+package terminal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenize(t *testing.T) {
+	type span struct {
+		kind tokenKind
+		text string
+	}
+	for _, tt := range []struct {
+		name   string
+		text   string
+		tokens []span
+	}{
+		{
+			name: "keywords, numbers and strings",
+			text: `let x = 1.5 + "a\"b"`,
+			tokens: []span{
+				{tokenKeyword, "let"}, {tokenText, " x = "}, {tokenNumber, "1.5"}, {tokenText, " + "}, {tokenString, `"a\"b"`},
+			},
+		},
+		{
+			name:   "property names aren't keywords",
+			text:   "p.new",
+			tokens: []span{{tokenText, "p.new"}},
+		},
+		{
+			name:   "comments",
+			text:   "a // b\n/* c */",
+			tokens: []span{{tokenText, "a "}, {tokenComment, "// b"}, {tokenText, "\n"}, {tokenComment, "/* c */"}},
+		},
+		{
+			name: "regular expression as an argument",
+			text: `"a'b".split(/'/g)`,
+			tokens: []span{
+				{tokenString, `"a'b"`}, {tokenText, ".split"}, {tokenBracket, "("}, {tokenRegexp, "/'/g"}, {tokenBracket, ")"},
+			},
+		},
+		{
+			name:   "slash in a character class",
+			text:   "x = /[/]/",
+			tokens: []span{{tokenText, "x = "}, {tokenRegexp, "/[/]/"}},
+		},
+		{
+			name:   "regular expression after a keyword",
+			text:   "return /a/",
+			tokens: []span{{tokenKeyword, "return"}, {tokenText, " "}, {tokenRegexp, "/a/"}},
+		},
+		{
+			name:   "division after an identifier",
+			text:   "a / b / c",
+			tokens: []span{{tokenText, "a / b / c"}},
+		},
+		{
+			name: "division after a closing parenthesis",
+			text: "(a) / 2",
+			tokens: []span{
+				{tokenBracket, "("}, {tokenText, "a"}, {tokenBracket, ")"}, {tokenText, " / "}, {tokenNumber, "2"},
+			},
+		},
+		{
+			name:   "division after a value keyword",
+			text:   "this / 2",
+			tokens: []span{{tokenKeyword, "this"}, {tokenText, " / "}, {tokenNumber, "2"}},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var spans []span
+			for _, token := range tokenize(tt.text) {
+				spans = append(spans, span{token.kind, tt.text[token.start:token.end]})
+			}
+			assert.Equal(t, tt.tokens, spans)
+		})
+	}
+}
+
+func TestTokenize_Unterminated(t *testing.T) {
+	for text, unterminated := range map[string]bool{
+		`"abc"`:       false,
+		`"abc`:        true,
+		"`abc\ndef":   true,
+		"'abc\ndef":   false,
+		"/* abc":      true,
+		"/* abc */":   false,
+		"x.match(/a'": false,
+	} {
+		tokens := tokenize(text)
+		assert.Equal(t, unterminated, tokens[len(tokens)-1].unterminated, text)
+	}
+}
+
+func TestMatchBrackets(t *testing.T) {
+	text := "f([a], {b: (c)}) ]"
+	b := matchBrackets(text, tokenize(text))
+	assert.Equal(t, map[int]int{
+		1: 15, 15: 1,
+		2: 4, 4: 2,
+		7: 14, 14: 7,
+		11: 13, 13: 11,
+	}, b.match)
+	assert.Equal(t, map[int]struct{}{17: {}}, b.unmatched)
+	assert.Empty(t, b.open)
+
+	text = "f({a: [1, \"(\""
+	b = matchBrackets(text, tokenize(text))
+	assert.Equal(t, []int{1, 2, 6}, b.open)
+	assert.Empty(t, b.unmatched)
+
+	text = "f(a]"
+	b = matchBrackets(text, tokenize(text))
+	assert.Equal(t, []int{1}, b.open)
+	assert.Equal(t, map[int]struct{}{3: {}}, b.unmatched)
+}
+
+func TestIncomplete(t *testing.T) {
+	for text, want := range map[string]bool{
+		"":                         false,
+		"1 + 2":                    false,
+		"f(":                       true,
+		"f(\n  a,\n  b\n)":         false,
+		"{a: [1,":                  true,
+		"\"(\"":                    false,
+		"`abc":                     true,
+		"/* (":                     true,
+		"// (":                     false,
+		"f(a]":                     false,
+		"\"a'b\".split(/'/)":       false,
+		"\"a'b\".split(/'/":        true,
+		"x.replace(/[(]/, \"\")":   false,
+		"x / 2 + f('(')":           false,
+		"if (a) {\n  b = a / 2\n":  true,
+		"if (a) {\n  b = a / 2\n}": false,
+	} {
+		assert.Equal(t, want, incomplete(text), text)
+	}
+}
+
+func TestDepth(t *testing.T) {
+	assert.Equal(t, 0, depth("f(a)"))
+	assert.Equal(t, 2, depth("f({"))
+	assert.Equal(t, 2, depth("[/]/, ("))
+}
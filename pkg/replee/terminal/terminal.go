@@ -5,28 +5,38 @@ import (
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 	"strings"
+	"time"
 )
 
 type Output struct {
-	IsErr       bool
-	IsSyntaxErr bool
-	Output      string
+	IsErr  bool
+	Output string
+	// Styled is the output with color tags, line for line, shown instead of Output if it's set
+	Styled string
 }
 
 type RepleeTerminal struct {
 	*tview.Flex
 	inputField          *tview.TextArea
+	output              *scrollback
 	app                 *tview.Application
 	commandHistory      []string
-	lineHistory         []lineHistoryEntry
-	lineIndex           int
 	commandHistoryIndex int
 	curCommand          string
 	totalHeight         int
 	execute             func(string) *Output
 	onChange            func()
-	currentIndent       int
-	scrollOffset        int
+	history             historyStorage
+	searching           bool
+	searchQuery         string
+	searchIndex         int
+	finding             bool
+	findText            string
+	completer           Completer
+	completion          *completion
+	// running is set while a command runs, the input is read only until it's done
+	running   bool
+	startedAt time.Time
 }
 
 const (
@@ -38,23 +48,30 @@ const (
 type lineHistoryEntry struct {
 	lineType string
 	text     string
+	color    tcell.Color
+	// styled is the text with color tags, if it's highlighted
+	styled string
 }
 
 const prompt = "[yellow]replee:> "
 const notPrompt = "         "
-const maxLines = 100
+const findPrompt = "[yellow]/"
 
-func NewRepleeTerminal(app *tview.Application, repl func(string) *Output) *RepleeTerminal {
+// NewRepleeTerminal returns a terminal running its commands with repl. The terminals of an application share
+// their persisted history.
+func NewRepleeTerminal(app *tview.Application, history *SharedHistory, repl func(string) *Output) *RepleeTerminal {
 	inputField := tview.NewTextArea().SetWrap(false).SetLabel(prompt)
 
 	out := &RepleeTerminal{
 		Flex:                tview.NewFlex().SetDirection(tview.FlexRow).SetFullScreen(true),
 		app:                 app,
 		inputField:          inputField,
+		output:              newScrollback(),
+		commandHistory:      history.load(),
 		commandHistoryIndex: 0,
-		lineIndex:           0,
 		execute:             repl,
 		onChange:            func() {},
+		history:             history.storage,
 	}
 	inputField.SetInputCapture(out.handleKeyPush)
 	inputField.SetMouseCapture(func(action tview.MouseAction, event *tcell.EventMouse) (tview.MouseAction, *tcell.EventMouse) {
@@ -62,18 +79,23 @@ func NewRepleeTerminal(app *tview.Application, repl func(string) *Output) *Reple
 	})
 	out.Flex.SetMouseCapture(func(action tview.MouseAction, event *tcell.EventMouse) (tview.MouseAction, *tcell.EventMouse) {
 		if action == tview.MouseScrollUp {
-			out.lineIndex -= 1
-			out.scrollOffset = -1
+			out.output.scrollBy(1)
 		} else if action == tview.MouseScrollDown {
-			out.lineIndex += 1
-			out.scrollOffset = 1
+			out.output.scrollBy(-1)
 		}
 		return action, event
 	})
+	out.AddItem(out.output, 0, 0, false)
 	out.AddItem(inputField, 0, 1, true)
 	return out
 }
 
+// SetScrollback sets the number of lines of input and output the terminal keeps
+func (r *RepleeTerminal) SetScrollback(lines int) *RepleeTerminal {
+	r.output.setMaxLines(lines)
+	return r
+}
+
 func (r *RepleeTerminal) AddItem(item tview.Primitive, fixedSize, proportion int, focus bool) *RepleeTerminal {
 	r.Flex.AddItem(item, fixedSize, proportion, focus)
 	r.totalHeight += 1
@@ -87,58 +109,168 @@ func (r *RepleeTerminal) RemoveItem(item tview.Primitive) *RepleeTerminal {
 }
 
 func (r *RepleeTerminal) addLineHistory(line lineHistoryEntry) {
-	r.lineHistory = append(r.lineHistory, line)
-	if len(r.lineHistory) > maxLines {
-		r.lineHistory = r.lineHistory[1:]
+	r.output.add(line)
+}
+
+// Print adds text to the output, in the given color or with the color tags of styled if it's set line for line.
+// It must be called from the goroutine of the application, e.g. in a function queued with QueueUpdateDraw.
+func (r *RepleeTerminal) Print(text string, styled string, color tcell.Color) {
+	for _, line := range outputLines(text, styled, color) {
+		r.addLineHistory(line)
 	}
 }
 
+// outputLines splits output into lines, leaving out the blank ones
+func outputLines(text string, styled string, color tcell.Color) []lineHistoryEntry {
+	var entries []lineHistoryEntry
+	lines := strings.Split(text, "\n")
+	styledLines := strings.Split(styled, "\n")
+	for index, line := range lines {
+		line = strings.TrimRight(line, " \t")
+		if line == "" {
+			continue
+		}
+		entry := lineHistoryEntry{
+			lineType: lineTypeOutput,
+			text:     line,
+			color:    color,
+		}
+		if len(styledLines) == len(lines) {
+			entry.styled = styledLines[index]
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
 func (r *RepleeTerminal) Draw(screen tcell.Screen) {
 	r.render()
 	r.Flex.Draw(screen)
+	r.drawSyntax(screen)
+	r.drawCompletion(screen)
 }
 
+// render sizes the output to the lines it shows, so that the input follows the output until the screen is full
 func (r *RepleeTerminal) render() {
 	_, _, _, maxRows := r.Flex.GetRect()
 	numInputLines := len(strings.Split(r.inputField.GetText(), "\n"))
 	maxRows = maxRows - numInputLines
-	if r.lineIndex < 0 {
-		r.lineIndex = 0
-		return
-	}
-	if r.lineIndex+maxRows > len(r.lineHistory) {
-		r.lineIndex = len(r.lineHistory) - maxRows
-		if r.lineIndex < 0 {
-			r.lineIndex = 0
-		}
-	}
-	r.Clear()
-	if maxRows < len(r.lineHistory) && r.scrollOffset == 0 {
-		r.lineIndex = len(r.lineHistory) - maxRows
-	}
-	for i := r.lineIndex; i <= r.lineIndex+maxRows && i <= len(r.lineHistory); i++ {
-		if i == len(r.lineHistory) {
-			r.AddItem(r.inputField, 0, 1, true)
-		} else {
-			line := r.lineHistory[i]
-			commandView := tview.NewTextView().
-				SetChangedFunc(r.onChange).
-				SetDynamicColors(true).
-				SetText(line.text)
-
-			if line.lineType == lineTypePrompt {
-				commandView.SetLabel(prompt)
-			}
-			r.AddItem(commandView, 1, 1, false)
+	if maxRows < 0 {
+		maxRows = 0
+	}
+	r.Flex.ResizeItem(r.output, r.output.visibleLines(maxRows), 0)
+}
+
+// handleScrollKey handles the keys that page through the output, searches it with /, and, while the output is
+// scrolled back and the input is empty, the less-like keys n and N for the next and previous match, g and G for the
+// top and bottom, and q to get back to the bottom
+func (r *RepleeTerminal) handleScrollKey(event *tcell.EventKey) (*tcell.EventKey, bool) {
+	switch {
+	case event.Key() == tcell.KeyPgUp:
+		r.output.scrollBy(r.output.pageSize())
+		return nil, true
+	case event.Key() == tcell.KeyPgDn:
+		r.output.scrollBy(-r.output.pageSize())
+		return nil, true
+	case event.Key() == tcell.KeyHome && event.Modifiers()&tcell.ModCtrl != 0:
+		r.output.top()
+		return nil, true
+	case event.Key() == tcell.KeyEnd && event.Modifiers()&tcell.ModCtrl != 0:
+		r.output.bottom()
+		return nil, true
+	}
+	if event.Key() != tcell.KeyRune || r.inputField.GetText() != "" {
+		return event, false
+	}
+	if event.Rune() == '/' {
+		r.finding = true
+		r.inputField.SetLabel(findPrompt)
+		return nil, true
+	}
+	if !r.output.scrolled() {
+		return event, false
+	}
+	switch event.Rune() {
+	case 'n':
+		r.output.find(r.findText, false)
+	case 'N':
+		r.output.find(r.findText, true)
+	case 'g':
+		r.output.top()
+	case 'G':
+		r.output.bottom()
+	case 'q':
+		r.output.clearSearch()
+		r.output.bottom()
+	default:
+		return event, false
+	}
+	return nil, true
+}
+
+// handleFindKey handles the keys pushed while typing a search of the output, which Enter runs and Escape cancels
+func (r *RepleeTerminal) handleFindKey(event *tcell.EventKey) *tcell.EventKey {
+	switch event.Key() {
+	case tcell.KeyEnter:
+		r.findText = r.inputField.GetText()
+		r.stopFind()
+		r.output.clearSearch()
+		if !r.output.find(r.findText, false) {
+			r.inputField.SetLabel(fmt.Sprintf("[red]pattern not found: %s[-] %s", tview.Escape(r.findText), prompt))
+		}
+		return nil
+	case tcell.KeyEscape:
+		r.stopFind()
+		return nil
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if r.inputField.GetText() == "" {
+			r.stopFind()
+			return nil
 		}
 	}
-	r.scrollOffset = 0
+	return event
+}
+
+func (r *RepleeTerminal) stopFind() {
+	r.finding = false
+	r.inputField.SetText("", true)
+	r.inputField.SetLabel(prompt)
 }
 
 func (r *RepleeTerminal) handleKeyPush(event *tcell.EventKey) *tcell.EventKey {
 	if event.Key() == tcell.KeyRune && event.Rune() == ' ' && event.Modifiers() == tcell.ModAlt {
 		return nil
 	}
+	if r.running {
+		// the output can be scrolled while the command runs, which the input waits for
+		if event, handled := r.handleScrollKey(event); handled {
+			return event
+		}
+		return nil
+	}
+	if r.searching {
+		return r.handleSearchKey(event)
+	}
+	if r.finding {
+		return r.handleFindKey(event)
+	}
+	if r.completion != nil {
+		return r.handleCompletionKey(event)
+	}
+	if event.Key() == tcell.KeyTab && r.complete() {
+		return nil
+	}
+	if event, handled := r.handleScrollKey(event); handled {
+		return event
+	}
+	r.inputField.SetLabel(prompt)
+	if event.Key() == tcell.KeyCtrlR {
+		r.startSearch()
+		return nil
+	}
+	if event.Key() == tcell.KeyRune && strings.ContainsRune(")]}", event.Rune()) && r.dedent(event.Rune()) {
+		return nil
+	}
 
 	if event.Key() == tcell.KeyUp {
 		o, _, _, _ := r.inputField.GetCursor()
@@ -176,67 +308,43 @@ func (r *RepleeTerminal) handleKeyPush(event *tcell.EventKey) *tcell.EventKey {
 	if event.Key() == tcell.KeyEnter {
 		command := r.inputField.GetText()
 		if command == "clear" {
-			r.lineHistory = []lineHistoryEntry{}
+			r.output.clear()
+			r.inputField.SetText("", true)
 			return nil
 		}
 		if strings.TrimSpace(command) == "" {
 			return nil
 		}
-		result := r.execute(command)
-		if result.IsSyntaxErr {
-			indenters := []string{"{", "(", "[", "."}
-			for _, indenter := range indenters {
-				if strings.HasSuffix(command, indenter) {
-					r.currentIndent += 2
-					indent := ""
-					for i := 0; i < r.currentIndent; i++ {
-						indent += " "
-					}
-					r.inputField.SetText(fmt.Sprintf("%s\n%s", command, indent), true)
-					return nil
-				}
-			}
-			r.currentIndent -= 2
-			if r.currentIndent < 0 {
-				r.currentIndent = 0
-			}
-			r.inputField.SetText(fmt.Sprintf("%s\n", command), true)
+		if incomplete(command) {
+			r.continueCommand(command)
 			return nil
 		}
-		color := "[violet]"
-		if result.IsErr {
-			color = "[red]"
-		}
-		output := strings.Trim(result.Output, " \n\t")
-		if r.commandHistoryIndex == 0 {
-			r.commandHistory = append(r.commandHistory, command)
-		}
+		r.commandHistory = appendHistory(r.commandHistory, command)
+		r.saveHistory(command)
 		r.commandHistoryIndex = 0
-		r.currentIndent = 0
 
-		r.RemoveItem(r.inputField)
 		r.inputField.SetText("", true)
+		r.output.clearSearch()
+		r.output.bottom()
+		styled := highlightLines(command)
 		for index, line := range strings.Split(command, "\n") {
 			if index == 0 {
 				r.addLineHistory(lineHistoryEntry{
 					lineType: lineTypePrompt,
 					text:     line,
+					color:    tcell.ColorWhite,
+					styled:   styled[index],
 				})
 			} else {
 				r.addLineHistory(lineHistoryEntry{
 					lineType: lineTypeInput,
 					text:     line,
+					color:    tcell.ColorWhite,
+					styled:   styled[index],
 				})
 			}
 		}
-		for _, line := range strings.Split(output, "\n") {
-			if line != "" {
-				r.addLineHistory(lineHistoryEntry{
-					lineType: lineTypeOutput,
-					text:     fmt.Sprintf("%s%s[white]", color, line),
-				})
-			}
-		}
+		r.run(command)
 		return nil
 	}
 	return event
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"fmt"
+	"github.com/dop251/goja"
+	"github.com/gdamore/tcell/v2"
+	"github.com/perdasilva/replee/pkg/deppy"
+	"github.com/perdasilva/replee/pkg/deppy/resolver"
+	"github.com/perdasilva/replee/pkg/replee/repl"
+	"github.com/perdasilva/replee/pkg/replee/terminal"
+	"github.com/rivo/tview"
+	"strings"
+	"sync"
+)
+
+// workspace is a tab of the UI: a runtime with its own bindings, the terminal to run commands in it and its last solve.
+// Commands run off the goroutine of the application, which the bindings touching the UI queue their updates on.
+type workspace struct {
+	page         string
+	name         string
+	app          *tview.Application
+	vm           *goja.Runtime
+	declarations *repl.Declarations
+	commands     *repl.CommandContexts
+	loop         *repl.EventLoop
+	terminal     *terminal.RepleeTerminal
+	docs         *repl.Help
+	renderers    *repl.Renderers
+	lastProblem  deppy.ResolutionProblem
+	lastSolution *resolver.Solution
+	// lock is held while the runtime runs a command, which other tabs can't copy from or to in the meantime, and
+	// while the UI completes names or browses the help of the tab
+	lock sync.Mutex
+}
+
+// styleColors are the colors of the styles of rendered results, the other styles keep the color of the output
+var styleColors = map[repl.Style]tcell.Color{
+	repl.StyleNumber:     tcell.ColorGold,
+	repl.StyleString:     tcell.ColorLightGreen,
+	repl.StyleLiteral:    tcell.ColorDodgerBlue,
+	repl.StyleKey:        tcell.ColorLightSkyBlue,
+	repl.StyleType:       tcell.ColorLightSlateGray,
+	repl.StyleIdentifier: tcell.ColorWhite,
+	repl.StyleSelected:   tcell.ColorLime,
+	repl.StyleError:      tcell.ColorRed,
+	repl.StyleHint:       tcell.ColorGray,
+}
+
+// levelColors are the colors of the text console calls print, but for the styles that have colors of their own
+var levelColors = map[repl.LogLevel]tcell.Color{
+	repl.LevelLog:   tcell.ColorSilver,
+	repl.LevelWarn:  tcell.ColorYellow,
+	repl.LevelError: tcell.ColorRed,
+}
+
+var workspaceDocs = []repl.Doc{
+	{
+		Name:        "view",
+		Signature:   "(problem?: ResolutionProblem, solution?: Solution)",
+		Description: "Shows the problem in the graph pane, F2 toggles it. Both default to the last solve of the tab.",
+		Args: []repl.Arg{
+			{Name: "problem", Description: "the problem to explore"},
+			{Name: "solution", Description: "a solution of the problem, whose selection or conflict set is highlighted"},
+		},
+		Example: "view(p, deppy.solve(p))",
+	},
+	{
+		Name:      "copyFrom",
+		Signature: "(tab: string | number, expression: string): any",
+		Description: "Evaluates the expression in another tab and returns its value, copied by a JSON round-trip. Go " +
+			"values such as problems and solutions are copied as their JSON.",
+		Args: []repl.Arg{
+			{Name: "tab", Description: "the name of the tab, or its number from 1"},
+			{Name: "expression", Description: "the expression to evaluate in the tab"},
+		},
+		Example: "variant = copyFrom(\"tab 1\", \"p\")",
+	},
+	{
+		Name:        "copyTo",
+		Signature:   "(tab: string | number, name: string, value: any)",
+		Description: "Copies the value to a global variable of another tab by a JSON round-trip.",
+		Args: []repl.Arg{
+			{Name: "tab", Description: "the name of the tab, or its number from 1"},
+			{Name: "name", Description: "the name of the global variable"},
+			{Name: "value", Description: "the value to copy"},
+		},
+		Example: "copyTo(2, \"solution\", deppy.solve(p))",
+	},
+	{
+		Name:      "scrollback",
+		Signature: "(lines: number)",
+		Description: "Sets the number of lines of input and output the tab keeps, 10000 by default. The oldest lines " +
+			"are dropped.",
+		Args:    []repl.Arg{{Name: "lines", Description: "the number of lines to keep"}},
+		Example: "scrollback(50000)",
+	},
+}
+
+// bind sets the bindings of the UI in the runtime of the workspace
+func (w *workspace) bind(ui *ReplUI) error {
+	w.docs.Register(workspaceDocs...)
+	// view(problem, solution) shows the problem in the graph pane, both default to the last solve
+	if err := w.vm.Set("view", func(problem deppy.ResolutionProblem, solution ...*resolver.Solution) {
+		switch {
+		case problem == nil:
+			problem = w.lastProblem
+			solution = []*resolver.Solution{w.lastSolution}
+		case len(solution) == 0 && problem == w.lastProblem:
+			solution = []*resolver.Solution{w.lastSolution}
+		case len(solution) == 0:
+			solution = []*resolver.Solution{nil}
+		}
+		w.app.QueueUpdateDraw(func() {
+			ui.graph.SetProblem(problem, solution[0])
+			ui.showGraph()
+		})
+	}); err != nil {
+		return err
+	}
+	if err := w.vm.Set("copyFrom", func(tab goja.Value, expression string) (goja.Value, error) {
+		from, err := ui.findWorkspace(tab)
+		if err != nil {
+			return nil, err
+		}
+		var copied goja.Value
+		err = w.enter(from, func() error {
+			value, err := from.vm.RunString(expression)
+			if err != nil {
+				return err
+			}
+			copied, err = copyValue(from.vm, value, w.vm)
+			return err
+		})
+		return copied, err
+	}); err != nil {
+		return err
+	}
+	if err := w.vm.Set("scrollback", func(lines int) {
+		w.app.QueueUpdateDraw(func() {
+			w.terminal.SetScrollback(lines)
+		})
+	}); err != nil {
+		return err
+	}
+	return w.vm.Set("copyTo", func(tab goja.Value, name string, value goja.Value) error {
+		to, err := ui.findWorkspace(tab)
+		if err != nil {
+			return err
+		}
+		return w.enter(to, func() error {
+			copied, err := copyValue(w.vm, value, to.vm)
+			if err != nil {
+				return err
+			}
+			return to.vm.Set(name, copied)
+		})
+	})
+}
+
+// enter calls fn with the runtime of the other workspace to itself, failing if it runs a command of its own
+func (w *workspace) enter(other *workspace, fn func() error) error {
+	if other == w {
+		return fn()
+	}
+	if !other.lock.TryLock() {
+		return fmt.Errorf("tab %s is running a command", other.name)
+	}
+	defer other.lock.Unlock()
+	return fn()
+}
+
+// onSolve keeps the last solve, so that the graph pane can show what it selected
+func (w *workspace) onSolve(problem deppy.ResolutionProblem, solution *resolver.Solution) {
+	w.lastProblem = problem
+	w.lastSolution = solution
+}
+
+// print adds what the console prints to the output of the terminal
+func (w *workspace) print(level repl.LogLevel, display repl.Display) {
+	w.app.QueueUpdateDraw(func() {
+		w.terminal.Print(display.String(), markup(display), levelColors[level])
+	})
+}
+
+// interrupt stops the command running, along with the builds and solves it started
+func (w *workspace) interrupt() {
+	w.commands.Interrupt()
+	w.vm.Interrupt("interrupted")
+}
+
+// execute runs the command, which the terminal does off the goroutine of the application
+func (w *workspace) execute(command string) *terminal.Output {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	ctx, end := w.commands.Begin()
+	defer end()
+	w.vm.ClearInterrupt()
+	w.declarations.Record(command)
+
+	response := &terminal.Output{
+		IsErr:  false,
+		Output: "",
+	}
+	value, err := w.loop.Run(ctx, func() (goja.Value, error) {
+		return w.vm.RunString(command)
+	})
+	var display repl.Display
+	if err != nil {
+		response.IsErr = true
+		display = w.renderers.RenderError(w.vm, err)
+	} else {
+		if goja.IsNull(value) || goja.IsUndefined(value) {
+			return response
+		}
+		display = w.renderers.Render(w.vm, value)
+		// the expansion of the last result isn't a result of its own, so that expand() can be repeated
+		if !repl.IsExpansion(value) {
+			if err := w.vm.Set("_", value); err != nil {
+				response.IsErr = true
+				display = w.renderers.RenderError(w.vm, err)
+			}
+		}
+	}
+	response.Output = display.String()
+	response.Styled = markup(display)
+	return response
+}
+
+// markup returns the lines of the display with color tags. Text that keeps its color is escaped in runs, as
+// brackets in separate spans could otherwise make up tags.
+func markup(display repl.Display) string {
+	lines := make([]string, len(display))
+	for i, line := range display {
+		sb := strings.Builder{}
+		plain := strings.Builder{}
+		for _, span := range line {
+			color, ok := styleColors[span.Style]
+			if !ok {
+				plain.WriteString(span.Text)
+				continue
+			}
+			sb.WriteString(tview.Escape(plain.String()))
+			plain.Reset()
+			sb.WriteString(fmt.Sprintf("[#%06x]%s[-]", color.Hex(), tview.Escape(span.Text)))
+		}
+		sb.WriteString(tview.Escape(plain.String()))
+		lines[i] = sb.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// copyValue copies a value of a runtime into another by a JSON round-trip
+func copyValue(from *goja.Runtime, value goja.Value, to *goja.Runtime) (goja.Value, error) {
+	text, err := callJSON(from, "stringify", value)
+	if err != nil {
+		return nil, err
+	}
+	if goja.IsUndefined(text) {
+		return nil, fmt.Errorf("%s can't be copied as JSON", value.String())
+	}
+	return callJSON(to, "parse", text)
+}
+
+func callJSON(vm *goja.Runtime, method string, arg goja.Value) (goja.Value, error) {
+	fn, ok := goja.AssertFunction(vm.Get("JSON").ToObject(vm).Get(method))
+	if !ok {
+		return nil, fmt.Errorf("JSON.%s is not a function", method)
+	}
+	return fn(goja.Undefined(), arg)
+}